@@ -1,6 +1,9 @@
 package main
 
 import (
+	"os"
+
+	"turtlesilicon/pkg/cli"
 	"turtlesilicon/pkg/debug"
 	"turtlesilicon/pkg/ui"
 	"turtlesilicon/pkg/utils"
@@ -14,6 +17,11 @@ import (
 const appVersion = "1.5.1"
 
 func main() {
+	// Patching commands run without starting the UI
+	if len(os.Args) > 1 && cli.IsCommand(os.Args[1]) {
+		os.Exit(cli.Run(os.Args[1:]))
+	}
+
 	TSApp := app.NewWithID("com.tairasu.turtlesilicon")
 	TSWindow := TSApp.NewWindow("TurtleSilicon v" + appVersion)
 	TSWindow.Resize(fyne.NewSize(650, 550))
@@ -28,6 +28,8 @@ type Addon struct {
 	LocalCommit  string
 	RemoteCommit string
 	NeedsUpdate  bool
+	Source       *Source // Where the addon is updated from, nil if it cannot be updated
+	NewerPinned  bool    // A newer version is available but the pin holds the addon back
 }
 
 type AddonManager struct {
@@ -50,10 +52,12 @@ func (am *AddonManager) ScanAddons() error {
 }
 
 func (am *AddonManager) ScanAddonsWithProgress(updateProgress func(string)) error {
-	return am.scanAddonsWithProgress(updateProgress, false)
+	return am.scanAddonsWithProgress(updateProgress, false, false)
 }
 
-func (am *AddonManager) scanAddonsWithProgress(updateProgress func(string), checkUpdates bool) error {
+// scanAddonsWithProgress lists the installed addons. checkUpdates asks their sources for the
+// latest versions, fullCheck also downloads zip files whose server doesn't identify versions.
+func (am *AddonManager) scanAddonsWithProgress(updateProgress func(string), checkUpdates, fullCheck bool) error {
 	if paths.TurtlewowPath == "" {
 		return fmt.Errorf("game path not set")
 	}
@@ -74,6 +78,7 @@ func (am *AddonManager) scanAddonsWithProgress(updateProgress func(string), chec
 	}
 
 	am.addons = []Addon{}
+	sources := LoadSources(addonsPath)
 
 	for i, entry := range entries {
 		if !entry.IsDir() {
@@ -85,27 +90,54 @@ func (am *AddonManager) scanAddonsWithProgress(updateProgress func(string), chec
 		}
 
 		addonPath := filepath.Join(addonsPath, entry.Name())
+		source, recorded := sources[entry.Name()]
+		if source != nil && source.Type == SourceNone {
+			source = nil
+		}
 		addon := Addon{
-			Name: entry.Name(),
-			Path: addonPath,
+			Name:   entry.Name(),
+			Path:   addonPath,
+			Source: source,
 		}
 
 		gitPath := filepath.Join(addonPath, ".git")
 		if _, err := os.Stat(gitPath); err == nil {
 			addon.HasGitRepo = true
 			addon.GitRemoteURL = am.getGitRemoteURL(addonPath)
-			addon.LocalCommit = am.getLocalCommit(addonPath)
-
-			if checkUpdates {
-				if updateProgress != nil {
-					updateProgress(fmt.Sprintf("Checking updates for %s...", entry.Name()))
-				}
+			if !recorded {
+				addon.Source = &Source{Type: SourceGit, URL: addon.GitRemoteURL}
+			}
+		}
 
-				addon.RemoteCommit = am.getRemoteCommit(addonPath)
-				addon.NeedsUpdate = addon.LocalCommit != addon.RemoteCommit && addon.RemoteCommit != ""
+		// LocalCommit and RemoteCommit hold the versions of the source, release tags for releases
+		if addon.Source != nil {
+			if provider, err := addon.Source.Provider(); err != nil {
+				debug.Printf("Addon %s: %v", entry.Name(), err)
 			} else {
-				addon.RemoteCommit = ""
-				addon.NeedsUpdate = false
+				addon.LocalCommit = provider.Installed(addonPath)
+				if checkUpdates {
+					if updateProgress != nil {
+						updateProgress(fmt.Sprintf("Checking updates for %s...", entry.Name()))
+					}
+
+					var latest string
+					if zip, ok := provider.(*zipSource); ok && fullCheck {
+						latest, err = zip.Check(addonPath)
+					} else {
+						latest, err = provider.Latest(addonPath)
+					}
+					if err != nil {
+						debug.Printf("Failed to check %s for updates: %v", entry.Name(), err)
+					}
+					addon.RemoteCommit = latest
+					addon.NeedsUpdate = addon.LocalCommit != addon.RemoteCommit && addon.RemoteCommit != ""
+
+					if git, ok := provider.(*gitSource); ok && addon.Source.Pin != "" {
+						if newest, err := git.Unpinned(addonPath); err == nil {
+							addon.NewerPinned = newest != addon.LocalCommit && newest != addon.RemoteCommit
+						}
+					}
+				}
 			}
 		}
 
@@ -122,7 +154,7 @@ func (am *AddonManager) scanAddonsWithProgress(updateProgress func(string), chec
 	}
 
 	if checkUpdates {
-		debug.Printf("Found %d addons (%d with git repos, %d need updates)", len(am.addons), am.countGitAddons(), am.countUpdatableAddons())
+		debug.Printf("Found %d addons (%d with git repos, %d need updates, %d newer but pinned)", len(am.addons), am.countGitAddons(), am.countUpdatableAddons(), am.countNewerPinnedAddons())
 	} else {
 		debug.Printf("Found %d addons (%d with git repos)", len(am.addons), am.countGitAddons())
 	}
@@ -173,65 +205,53 @@ func (am *AddonManager) countGitAddons() int {
 func (am *AddonManager) countUpdatableAddons() int {
 	count := 0
 	for _, addon := range am.addons {
-		if addon.HasGitRepo && addon.NeedsUpdate {
+		if addon.Source != nil && addon.NeedsUpdate {
 			count++
 		}
 	}
 	return count
 }
 
-func (am *AddonManager) getLocalCommit(addonPath string) string {
-	cmd := exec.Command("git", "rev-parse", "HEAD")
-	cmd.Dir = addonPath
-
-	output, err := cmd.Output()
-	if err != nil {
-		debug.Printf("Failed to get local commit for %s: %v", addonPath, err)
-		return ""
-	}
-
-	return strings.TrimSpace(string(output))
-}
-
-func (am *AddonManager) getRemoteCommit(addonPath string) string {
-	fetchCmd := exec.Command("git", "fetch", "origin")
-	fetchCmd.Dir = addonPath
-	fetchCmd.Run()
-
-	// Get the remote commit hash
-	cmd := exec.Command("git", "rev-parse", "origin/HEAD")
-	cmd.Dir = addonPath
-
-	output, err := cmd.Output()
-	if err != nil {
-		cmd = exec.Command("git", "rev-parse", "origin/main")
-		cmd.Dir = addonPath
-		output, err = cmd.Output()
-		if err != nil {
-			cmd = exec.Command("git", "rev-parse", "origin/master")
-			cmd.Dir = addonPath
-			output, err = cmd.Output()
-			if err != nil {
-				debug.Printf("Failed to get remote commit for %s: %v", addonPath, err)
-				return ""
-			}
+func (am *AddonManager) countNewerPinnedAddons() int {
+	count := 0
+	for _, addon := range am.addons {
+		if addon.NewerPinned {
+			count++
 		}
 	}
-
-	return strings.TrimSpace(string(output))
+	return count
 }
 
 func (am *AddonManager) UpdateAddon(addon *Addon) error {
-	if !addon.HasGitRepo {
-		return fmt.Errorf("addon %s does not have a git repository", addon.Name)
+	if addon.Source == nil {
+		return fmt.Errorf("addon %s has no source to update from", addon.Name)
+	}
+	provider, err := addon.Source.Provider()
+	if err != nil {
+		return fmt.Errorf("failed to update addon %s: %v", addon.Name, err)
 	}
 
-	debug.Printf("Updating addon: %s", addon.Name)
+	debug.Printf("Updating addon: %s from %s", addon.Name, provider.Name())
 
-	if err := am.runGitPull(addon.Path); err != nil {
+	previous := provider.Installed(addon.Path)
+	version, err := provider.Update(addon.Path)
+	if err != nil {
 		return fmt.Errorf("failed to update addon %s: %v", addon.Name, err)
 	}
 
+	// git keeps its version in the repository and records the commit to roll back to, the other
+	// sources record the version
+	if addon.Source.Type == SourceGit {
+		if previous != "" && previous != version {
+			addon.Source.Previous = previous
+		}
+	} else {
+		addon.Source.Version = version
+	}
+	if err := SaveSource(filepath.Dir(addon.Path), addon.Name, addon.Source); err != nil {
+		debug.Printf("Warning: %v", err)
+	}
+
 	// Update the directory's modification time to reflect the update
 	now := time.Now()
 	if err := os.Chtimes(addon.Path, now, now); err != nil {
@@ -239,6 +259,8 @@ func (am *AddonManager) UpdateAddon(addon *Addon) error {
 	}
 
 	addon.LastUpdated = now
+	addon.LocalCommit = version
+	addon.NeedsUpdate = false
 	debug.Printf("Successfully updated addon: %s", addon.Name)
 	return nil
 }
@@ -249,6 +271,9 @@ func (am *AddonManager) DeleteAddon(addon *Addon) error {
 	if err := os.RemoveAll(addon.Path); err != nil {
 		return fmt.Errorf("failed to delete addon %s: %v", addon.Name, err)
 	}
+	if err := SaveSource(filepath.Dir(addon.Path), addon.Name, nil); err != nil {
+		debug.Printf("Warning: %v", err)
+	}
 
 	for i, a := range am.addons {
 		if a.Name == addon.Name {
@@ -261,20 +286,6 @@ func (am *AddonManager) DeleteAddon(addon *Addon) error {
 	return nil
 }
 
-func (am *AddonManager) runGitPull(addonPath string) error {
-	cmd := exec.Command("git", "pull")
-	cmd.Dir = addonPath
-
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		debug.Printf("Git pull failed: %s", string(output))
-		return fmt.Errorf("git pull failed: %v", err)
-	}
-
-	debug.Printf("Git pull output: %s", string(output))
-	return nil
-}
-
 func (am *AddonManager) ShowAddonManager() {
 	if am.currentPopup != nil {
 		am.currentPopup.Hide()
@@ -327,7 +338,11 @@ func (am *AddonManager) createAddonManagerPopup() {
 	titleText := widget.NewLabel("Addon Manager")
 	titleText.TextStyle = fyne.TextStyle{Bold: true}
 
-	summaryText := widget.NewLabel(fmt.Sprintf("Found %d addons (%d git repos, %d need updates)", len(am.addons), am.countGitAddons(), am.countUpdatableAddons()))
+	summary := fmt.Sprintf("Found %d addons (%d git repos, %d need updates)", len(am.addons), am.countGitAddons(), am.countUpdatableAddons())
+	if held := am.countNewerPinnedAddons(); held > 0 {
+		summary += fmt.Sprintf(", %d newer but pinned", held)
+	}
+	summaryText := widget.NewLabel(summary)
 	summaryText.TextStyle = fyne.TextStyle{Italic: true}
 
 	refreshButton := widget.NewButton("Refresh", func() {})
@@ -423,7 +438,7 @@ func (am *AddonManager) createAddonManagerPopup() {
 	closeButton.OnTapped = closeAction
 
 	refreshButton.OnTapped = func() {
-		am.refreshWithUpdateCheck()
+		am.refreshWithUpdateCheck(true)
 	}
 
 	canvas.SetOnTypedKey(func(key *fyne.KeyEvent) {
@@ -495,7 +510,9 @@ func (am *AddonManager) refreshAddonList() {
 	am.addonsList.Refresh()
 }
 
-func (am *AddonManager) refreshWithUpdateCheck() {
+// refreshWithUpdateCheck scans the addons and checks them for updates, fullCheck is set for
+// update checks the user asked for
+func (am *AddonManager) refreshWithUpdateCheck(fullCheck bool) {
 	if am.currentPopup != nil {
 		am.currentPopup.Hide()
 		am.currentPopup = nil
@@ -525,7 +542,7 @@ func (am *AddonManager) refreshWithUpdateCheck() {
 			})
 		}
 
-		if err := am.scanAddonsWithProgress(updateProgress, true); err != nil {
+		if err := am.scanAddonsWithProgress(updateProgress, true, fullCheck); err != nil {
 			fyne.Do(func() {
 				progressBar.Stop()
 				loadingPopup.Hide()
@@ -565,12 +582,16 @@ func (am *AddonManager) createAddonCard(addon *Addon) *fyne.Container {
 	infoContainer.Resize(fyne.NewSize(400, 50))
 
 	var gitButton *widget.Button
-	if addon.HasGitRepo {
-		var gitText string
+	if addon.Source != nil {
+		gitText := strings.ToUpper(addon.Source.Type)
+		if addon.Source.Pin != "" {
+			gitText += " @ " + shortCommit(addon.Source.Pin)
+		}
 		if addon.NeedsUpdate {
-			gitText = "GIT*"
-		} else {
-			gitText = "GIT"
+			gitText += "*"
+		}
+		if addon.NewerPinned {
+			gitText += " (newer available)"
 		}
 
 		gitButton = widget.NewButton(gitText, func() {})
@@ -590,10 +611,23 @@ func (am *AddonManager) createAddonCard(addon *Addon) *fyne.Container {
 		am.updateSingleAddon(addon)
 	})
 	updateButton.Importance = widget.MediumImportance
-	if !addon.HasGitRepo || !addon.NeedsUpdate {
+	if addon.Source == nil || !addon.NeedsUpdate {
 		updateButton.Disable()
 	}
 
+	sourceButton := widget.NewButton("Source", func() {
+		am.showSourceDialog(addon)
+	})
+	sourceButton.Importance = widget.LowImportance
+
+	var pinButton *widget.Button
+	if addon.HasGitRepo && addon.Source != nil && addon.Source.Type == SourceGit {
+		pinButton = widget.NewButton("Pin", func() {
+			am.showPinDialog(addon)
+		})
+		pinButton.Importance = widget.LowImportance
+	}
+
 	deleteButton := widget.NewButton("Delete", func() {
 		am.confirmDeleteAddon(addon)
 	})
@@ -608,6 +642,10 @@ func (am *AddonManager) createAddonCard(addon *Addon) *fyne.Container {
 	if infoButton != nil {
 		buttons = append(buttons, infoButton)
 	}
+	buttons = append(buttons, sourceButton)
+	if pinButton != nil {
+		buttons = append(buttons, pinButton)
+	}
 	buttons = append(buttons, updateButton, deleteButton)
 
 	buttonsContainer = container.NewHBox(buttons...)
@@ -646,7 +684,7 @@ func (am *AddonManager) updateSingleAddon(addon *Addon) {
 func (am *AddonManager) updateAllAddons() {
 	updatableAddons := []Addon{}
 	for _, addon := range am.addons {
-		if addon.HasGitRepo && addon.NeedsUpdate {
+		if addon.Source != nil && addon.NeedsUpdate {
 			updatableAddons = append(updatableAddons, addon)
 		}
 	}
@@ -811,6 +849,11 @@ func (am *AddonManager) showAddAddonPopup() {
 	})
 	addMultipleButton.Importance = widget.MediumImportance
 
+	fromArchiveButton := widget.NewButton("From Zip or Folder", func() {
+		// This will be set when the popup is created
+	})
+	fromArchiveButton.Importance = widget.MediumImportance
+
 	contentContainer := container.NewVBox(
 		titleText,
 		widget.NewSeparator(),
@@ -819,7 +862,7 @@ func (am *AddonManager) showAddAddonPopup() {
 		widget.NewSeparator(),
 		container.NewCenter(findAddonsButton),
 		widget.NewSeparator(),
-		container.NewHBox(installButton, addMultipleButton),
+		container.NewHBox(installButton, addMultipleButton, fromArchiveButton),
 	)
 
 	windowSize := am.window.Content().Size()
@@ -865,6 +908,11 @@ func (am *AddonManager) showAddAddonPopup() {
 		am.showAddMultipleAddonsPopup()
 	}
 
+	fromArchiveButton.OnTapped = func() {
+		popup.Hide()
+		am.showInstallArchivePopup()
+	}
+
 	popup.Show()
 }
 
@@ -0,0 +1,330 @@
+package addons
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"turtlesilicon/pkg/debug"
+	"turtlesilicon/pkg/paths"
+	"turtlesilicon/pkg/utils"
+)
+
+// ErrNoAddons is returned for a zip or folder without any .toc file
+var ErrNoAddons = errors.New("no addon found, addon folders contain a .toc file")
+
+// StagedAddon is an addon folder found in a zip archive or local folder
+type StagedAddon struct {
+	Name   string // Folder name in Interface/Addons, the name of its .toc file
+	Path   string // Folder that holds the .toc file
+	Exists bool   // An addon of that name is installed already
+}
+
+// StagedInstall holds the addons of a zip archive, zip URL or local folder until they are
+// installed. Cleanup removes the extracted files.
+type StagedInstall struct {
+	Source  string
+	Addons  []StagedAddon
+	Version string // Version of a downloaded zip, as zipSource.Check reports it
+
+	tempDir string
+}
+
+// addonsDir returns the Interface/Addons directory of the game
+func addonsDir() (string, error) {
+	if paths.TurtlewowPath == "" {
+		return "", fmt.Errorf("game path not set")
+	}
+	return filepath.Join(paths.TurtlewowPath, "Interface", "Addons"), nil
+}
+
+// StageAddons finds the addons in a local zip file, a zip URL or a local folder. Zip files are
+// extracted to a temporary directory first.
+func StageAddons(source string) (*StagedInstall, error) {
+	source = strings.TrimSpace(source)
+	if source == "" {
+		return nil, fmt.Errorf("no zip file, URL or folder given")
+	}
+	addonsPath, err := addonsDir()
+	if err != nil {
+		return nil, err
+	}
+
+	staged := &StagedInstall{Source: source}
+	root := source
+	if isURL(source) || (utils.PathExists(source) && !utils.DirExists(source)) {
+		staged.tempDir, err = os.MkdirTemp("", "TurtleSilicon-addon-*")
+		if err != nil {
+			return nil, fmt.Errorf("failed to create temporary directory: %v", err)
+		}
+
+		zipPath := source
+		if !utils.PathExists(source) {
+			zipPath = filepath.Join(staged.tempDir, "addon.zip")
+			if staged.Version, err = downloadFile(source, zipPath); err != nil {
+				staged.Cleanup()
+				return nil, err
+			}
+		}
+		root = filepath.Join(staged.tempDir, "extracted")
+		if err := extractZip(zipPath, root); err != nil {
+			staged.Cleanup()
+			return nil, fmt.Errorf("failed to extract %s: %v", filepath.Base(source), err)
+		}
+	} else if !utils.DirExists(source) {
+		return nil, fmt.Errorf("%s does not exist", source)
+	}
+
+	staged.Addons, err = findAddonFolders(root)
+	if err != nil {
+		staged.Cleanup()
+		return nil, err
+	}
+	if len(staged.Addons) == 0 {
+		staged.Cleanup()
+		return nil, ErrNoAddons
+	}
+	for i := range staged.Addons {
+		staged.Addons[i].Exists = utils.DirExists(filepath.Join(addonsPath, staged.Addons[i].Name))
+	}
+
+	debug.Printf("Found %d addons in %s", len(staged.Addons), source)
+	return staged, nil
+}
+
+// isURL returns true if source is a web address rather than a local path
+func isURL(source string) bool {
+	return strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://")
+}
+
+// Names returns the folder names the addons are installed as
+func (s *StagedInstall) Names() []string {
+	names := make([]string, len(s.Addons))
+	for i, addon := range s.Addons {
+		names[i] = addon.Name
+	}
+	return names
+}
+
+// Only keeps the addon named name, ignoring case, and drops the others. It returns false if there
+// is no such addon.
+func (s *StagedInstall) Only(name string) bool {
+	for _, addon := range s.Addons {
+		if strings.EqualFold(addon.Name, name) {
+			addon.Name = name
+			s.Addons = []StagedAddon{addon}
+			return true
+		}
+	}
+	return false
+}
+
+// Existing returns the names of the addons that would replace installed ones
+func (s *StagedInstall) Existing() []string {
+	var existing []string
+	for _, addon := range s.Addons {
+		if addon.Exists {
+			existing = append(existing, addon.Name)
+		}
+	}
+	return existing
+}
+
+// Install copies the addons into Interface/Addons. Installed addons of the same name are only
+// replaced if overwrite is set.
+func (s *StagedInstall) Install(overwrite bool) error {
+	addonsPath, err := addonsDir()
+	if err != nil {
+		return err
+	}
+	if existing := s.Existing(); len(existing) > 0 && !overwrite {
+		return fmt.Errorf("addon '%s' already exists", strings.Join(existing, "', '"))
+	}
+	if err := os.MkdirAll(addonsPath, 0755); err != nil {
+		return fmt.Errorf("failed to create addons directory: %v", err)
+	}
+
+	for _, addon := range s.Addons {
+		addonPath := filepath.Join(addonsPath, addon.Name)
+
+		// Copy next to the target first so a failed copy leaves the installed addon alone
+		tempPath := addonPath + ".installing"
+		os.RemoveAll(tempPath)
+		if err := utils.CopyDir(addon.Path, tempPath); err != nil {
+			os.RemoveAll(tempPath)
+			return fmt.Errorf("failed to copy %s: %v", addon.Name, err)
+		}
+
+		// Move the installed addon aside instead of deleting it, so it can be put back
+		previousPath := addonPath + ".previous"
+		os.RemoveAll(previousPath)
+		replacing := utils.PathExists(addonPath)
+		if replacing {
+			if err := os.Rename(addonPath, previousPath); err != nil {
+				os.RemoveAll(tempPath)
+				return fmt.Errorf("failed to replace %s: %v", addon.Name, err)
+			}
+		}
+		if err := os.Rename(tempPath, addonPath); err != nil {
+			os.RemoveAll(tempPath)
+			if replacing {
+				if restoreErr := os.Rename(previousPath, addonPath); restoreErr != nil {
+					return fmt.Errorf("failed to install %s: %v, the previous version is in %s", addon.Name, err, previousPath)
+				}
+			}
+			return fmt.Errorf("failed to install %s: %v", addon.Name, err)
+		}
+		if replacing {
+			os.RemoveAll(previousPath)
+		}
+		debug.Printf("Installed addon %s from %s", addon.Name, s.Source)
+	}
+	return nil
+}
+
+// Cleanup removes the files extracted for the install
+func (s *StagedInstall) Cleanup() {
+	if s.tempDir != "" {
+		os.RemoveAll(s.tempDir)
+		s.tempDir = ""
+	}
+}
+
+// findAddonFolders returns the folders below root that contain a .toc file. Folders inside an
+// addon are not searched, so bundled libraries are not installed separately. Each addon is named
+// after its .toc file, which turns GitHub layouts like Addon-master/Addon.toc into Addon.
+func findAddonFolders(root string) ([]StagedAddon, error) {
+	var found []StagedAddon
+	err := filepath.WalkDir(root, func(path string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() {
+			return nil
+		}
+		if path != root && (strings.HasPrefix(entry.Name(), ".") || entry.Name() == "__MACOSX") {
+			return filepath.SkipDir
+		}
+
+		name, ok := tocName(path)
+		if !ok {
+			return nil
+		}
+		for _, addon := range found {
+			if strings.EqualFold(addon.Name, name) {
+				return fmt.Errorf("the archive contains the addon %s twice", name)
+			}
+		}
+		found = append(found, StagedAddon{Name: name, Path: path})
+		return filepath.SkipDir
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].Name < found[j].Name })
+	return found, nil
+}
+
+// tocName returns the addon name of a folder from its .toc files. A folder with several .toc
+// files, e.g. one per client version, is named after the one matching the folder or the shortest.
+func tocName(dir string) (string, bool) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", false
+	}
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.EqualFold(filepath.Ext(entry.Name()), ".toc") {
+			name := strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+			if strings.EqualFold(name, filepath.Base(dir)) {
+				return name, true
+			}
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return "", false
+	}
+	sort.Slice(names, func(i, j int) bool { return len(names[i]) < len(names[j]) })
+	return names[0], true
+}
+
+// extractZip extracts a zip archive to dest, refusing entries that would end up outside of it
+func extractZip(zipPath string, dest string) error {
+	archive, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+
+	for _, file := range archive.File {
+		target := filepath.Join(dest, filepath.FromSlash(file.Name))
+		if !strings.HasPrefix(target, filepath.Clean(dest)+string(os.PathSeparator)) {
+			return fmt.Errorf("invalid path in archive: %s", file.Name)
+		}
+		if file.FileInfo().IsDir() {
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		if err := extractZipFile(file, target); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func extractZipFile(file *zip.File, target string) error {
+	reader, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	out, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	_, err = io.Copy(out, reader)
+	return err
+}
+
+// downloadFile downloads url to path and returns the version of the file: the ETag or
+// modification date the server sent, or its SHA-256 if there is neither
+func downloadFile(url string, path string) (string, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return "", fmt.Errorf("failed to download from %s: %v", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("server returned status %d for %s", resp.StatusCode, url)
+	}
+
+	out, err := os.Create(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to create %s: %v", path, err)
+	}
+	defer out.Close()
+	hasher := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(out, hasher), resp.Body); err != nil {
+		return "", fmt.Errorf("failed to download from %s: %v", url, err)
+	}
+	if version := headerVersion(resp.Header); version != "" {
+		return version, nil
+	}
+	return hashVersion(hasher.Sum(nil)), nil
+}
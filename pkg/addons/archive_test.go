@@ -0,0 +1,169 @@
+package addons
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"turtlesilicon/pkg/paths"
+)
+
+// writeFiles creates files below root, directories end with a slash
+func writeFiles(t *testing.T, root string, files ...string) {
+	t.Helper()
+	for _, file := range files {
+		path := filepath.Join(root, filepath.FromSlash(file))
+		if strings.HasSuffix(file, "/") {
+			if err := os.MkdirAll(path, 0755); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(file), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestTocName(t *testing.T) {
+	tests := []struct {
+		name   string
+		files  []string
+		want   string
+		wantOK bool
+	}{
+		{"single toc", []string{"Addon/Other.toc"}, "Other", true},
+		{"toc matching the folder", []string{"Addon/Addon_Vanilla.toc", "Addon/addon.toc"}, "addon", true},
+		{"shortest toc", []string{"Addon/Questie-Classic.toc", "Addon/Questie.toc", "Addon/Questie-BCC.toc"}, "Questie", true},
+		{"no toc", []string{"Addon/readme.txt"}, "", false},
+		{"toc folder is ignored", []string{"Addon/Sub.toc/"}, "", false},
+	}
+
+	for _, tt := range tests {
+		root := t.TempDir()
+		writeFiles(t, root, tt.files...)
+		got, ok := tocName(filepath.Join(root, "Addon"))
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("%s: tocName() = %q, %v, want %q, %v", tt.name, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestFindAddonFolders(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   []string
+		want    []string
+		wantErr bool
+	}{
+		{"addon at the root", []string{"Addon.toc", "main.lua"}, []string{"Addon"}, false},
+		{"GitHub layout", []string{"Addon-master/Addon.toc"}, []string{"Addon"}, false},
+		{"several addons", []string{"pack/B/B.toc", "pack/A/A.toc"}, []string{"A", "B"}, false},
+		{"bundled libraries are skipped", []string{"Addon/Addon.toc", "Addon/Libs/Lib/Lib.toc"}, []string{"Addon"}, false},
+		{"hidden and macOS folders are skipped", []string{"__MACOSX/Addon/Addon.toc", ".git/Other.toc", "Addon/Addon.toc"}, []string{"Addon"}, false},
+		{"addon twice", []string{"one/Addon/Addon.toc", "two/Addon/addon.toc"}, nil, true},
+		{"no addon", []string{"readme.txt"}, nil, false},
+	}
+
+	for _, tt := range tests {
+		root := t.TempDir()
+		writeFiles(t, root, tt.files...)
+		found, err := findAddonFolders(root)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: findAddonFolders() error = %v, want error %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		var names []string
+		for _, addon := range found {
+			names = append(names, addon.Name)
+		}
+		if strings.Join(names, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("%s: findAddonFolders() = %v, want %v", tt.name, names, tt.want)
+		}
+	}
+}
+
+func TestExtractZip(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []string
+		wantErr bool
+	}{
+		{"regular entries", []string{"Addon/", "Addon/Addon.toc", "Addon/Libs/lib.lua"}, false},
+		{"parent directory", []string{"../evil.lua"}, true},
+		{"nested parent directory", []string{"Addon/../../evil.lua"}, true},
+		{"sibling with the same prefix", []string{"../extracted-evil/evil.lua"}, true},
+	}
+
+	for _, tt := range tests {
+		dir := t.TempDir()
+		zipPath := filepath.Join(dir, "addon.zip")
+		out, err := os.Create(zipPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		writer := zip.NewWriter(out)
+		for _, entry := range tt.entries {
+			w, err := writer.Create(entry)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasSuffix(entry, "/") {
+				w.Write([]byte(entry))
+			}
+		}
+		writer.Close()
+		out.Close()
+
+		dest := filepath.Join(dir, "extracted")
+		err = extractZip(zipPath, dest)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: extractZip() error = %v, want error %v", tt.name, err, tt.wantErr)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "evil.lua")); err == nil {
+			t.Errorf("%s: extractZip() wrote outside of the destination", tt.name)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "extracted-evil")); err == nil {
+			t.Errorf("%s: extractZip() wrote outside of the destination", tt.name)
+		}
+	}
+}
+
+func TestStagedInstallReplaces(t *testing.T) {
+	gamePath := t.TempDir()
+	defer func(path string) { paths.TurtlewowPath = path }(paths.TurtlewowPath)
+	paths.TurtlewowPath = gamePath
+	addonsPath := filepath.Join(gamePath, "Interface", "Addons")
+	writeFiles(t, addonsPath, "Addon/Addon.toc", "Addon/old.lua")
+
+	source := t.TempDir()
+	writeFiles(t, source, "Addon/Addon.toc", "Addon/new.lua")
+	staged, err := StageAddons(source)
+	if err != nil {
+		t.Fatalf("StageAddons() error = %v", err)
+	}
+	defer staged.Cleanup()
+
+	if err := staged.Install(false); err == nil {
+		t.Errorf("Install(false) over an installed addon = nil, want error")
+	}
+	if err := staged.Install(true); err != nil {
+		t.Fatalf("Install(true) error = %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(addonsPath, "Addon", "new.lua")); err != nil {
+		t.Errorf("new version not installed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(addonsPath, "Addon", "old.lua")); err == nil {
+		t.Errorf("old version still installed")
+	}
+	entries, _ := os.ReadDir(addonsPath)
+	if len(entries) != 1 {
+		t.Errorf("addons directory has %d entries, want only the addon without leftovers", len(entries))
+	}
+}
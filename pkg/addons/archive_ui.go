@@ -0,0 +1,189 @@
+package addons
+
+import (
+	"fmt"
+	"strings"
+
+	"turtlesilicon/pkg/debug"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+)
+
+// showInstallArchivePopup installs addons from a local zip, a zip URL or a local folder
+func (am *AddonManager) showInstallArchivePopup() {
+	titleText := widget.NewLabel("Add Addon from Zip or Folder")
+	titleText.TextStyle = fyne.TextStyle{Bold: true}
+
+	instructionText := widget.NewLabel("Choose a zip file or folder, or enter the URL of a zip file:")
+	instructionText.TextStyle = fyne.TextStyle{Italic: true}
+
+	sourceEntry := widget.NewEntry()
+	sourceEntry.SetPlaceHolder("https://example.com/addon.zip or /path/to/addon.zip")
+
+	chooseZipButton := widget.NewButton("Choose Zip...", func() {
+		fileDialog := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+			if err != nil || reader == nil {
+				return
+			}
+			defer reader.Close()
+			sourceEntry.SetText(reader.URI().Path())
+		}, am.window)
+		fileDialog.SetFilter(storage.NewExtensionFileFilter([]string{".zip"}))
+		fileDialog.Show()
+	})
+	chooseZipButton.Importance = widget.MediumImportance
+
+	chooseFolderButton := widget.NewButton("Choose Folder...", func() {
+		dialog.ShowFolderOpen(func(uri fyne.ListableURI, err error) {
+			if err != nil || uri == nil {
+				return
+			}
+			sourceEntry.SetText(uri.Path())
+		}, am.window)
+	})
+	chooseFolderButton.Importance = widget.MediumImportance
+
+	installButton := widget.NewButton("Install", nil)
+	installButton.Importance = widget.HighImportance
+
+	contentContainer := container.NewVBox(
+		titleText,
+		widget.NewSeparator(),
+		instructionText,
+		sourceEntry,
+		container.NewHBox(chooseZipButton, chooseFolderButton),
+		widget.NewSeparator(),
+		container.NewHBox(installButton),
+	)
+
+	windowSize := am.window.Content().Size()
+	popupWidth := windowSize.Width * 2 / 3
+	popupHeight := windowSize.Height / 3
+
+	closeButton := widget.NewButton("✕", func() {
+		// This will be set when the popup is created
+	})
+	closeButton.Importance = widget.LowImportance
+
+	topBar := container.NewBorder(
+		nil,
+		nil,
+		closeButton,
+		nil,
+		nil,
+	)
+
+	mainContainer := container.NewBorder(
+		topBar,
+		nil,
+		nil,
+		nil,
+		contentContainer,
+	)
+
+	popup := widget.NewModalPopUp(mainContainer, am.window.Canvas())
+	popup.Resize(fyne.NewSize(popupWidth, popupHeight))
+
+	closeButton.OnTapped = func() {
+		popup.Hide()
+	}
+
+	installButton.OnTapped = func() {
+		source := strings.TrimSpace(sourceEntry.Text)
+		if source == "" {
+			dialog.ShowError(fmt.Errorf("please choose a zip file or folder, or enter a zip URL"), am.window)
+			return
+		}
+		popup.Hide()
+		am.installAddonsFromArchive(source)
+	}
+
+	popup.Show()
+}
+
+// installAddonsFromArchive finds the addons in source, shows their folder names and installs them
+// once confirmed. Replacing installed addons needs a second confirmation.
+func (am *AddonManager) installAddonsFromArchive(source string) {
+	progressDialog := dialog.NewProgressInfinite("Installing addon", "Looking for addons...", am.window)
+	progressDialog.Show()
+
+	go func() {
+		staged, err := StageAddons(source)
+		fyne.Do(func() {
+			progressDialog.Hide()
+			if err != nil {
+				debug.Printf("Failed to read addons from %s: %v", source, err)
+				dialog.ShowError(fmt.Errorf("failed to install addon: %v", err), am.window)
+				return
+			}
+
+			var lines []string
+			for _, addon := range staged.Addons {
+				line := "- " + addon.Name
+				if addon.Exists {
+					line += " (replaces the installed addon)"
+				}
+				lines = append(lines, line)
+			}
+			message := fmt.Sprintf("These addon folders will be installed:\n\n%s", strings.Join(lines, "\n"))
+
+			dialog.ShowConfirm("Install Addons", message, func(confirmed bool) {
+				if !confirmed {
+					staged.Cleanup()
+					return
+				}
+				existing := staged.Existing()
+				if len(existing) == 0 {
+					am.installStaged(staged, false)
+					return
+				}
+				overwriteMessage := fmt.Sprintf("'%s' is already installed. Replace it? Its files, including any changes you made, are deleted.", strings.Join(existing, "', '"))
+				dialog.ShowConfirm("Replace Installed Addons", overwriteMessage, func(overwrite bool) {
+					if !overwrite {
+						staged.Cleanup()
+						return
+					}
+					am.installStaged(staged, true)
+				}, am.window)
+			}, am.window)
+		})
+	}()
+}
+
+// installStaged copies staged addons into the addons directory and refreshes the list
+func (am *AddonManager) installStaged(staged *StagedInstall, overwrite bool) {
+	go func() {
+		defer staged.Cleanup()
+		err := staged.Install(overwrite)
+		if err == nil && isURL(staged.Source) {
+			recordZipSource(staged)
+		}
+		fyne.Do(func() {
+			if err != nil {
+				dialog.ShowError(fmt.Errorf("failed to install addon: %v", err), am.window)
+				return
+			}
+			dialog.ShowInformation("Success", fmt.Sprintf("Installed %s", strings.Join(staged.Names(), ", ")), am.window)
+			am.refreshAddonManager()
+		})
+	}()
+}
+
+// recordZipSource records the zip URL the addons were installed from, so they can be updated
+func recordZipSource(staged *StagedInstall) {
+	addonsPath, err := addonsDir()
+	if err != nil {
+		return
+	}
+	source := &Source{Type: SourceZip, URL: staged.Source, Version: staged.Version}
+	for _, name := range staged.Names() {
+		addonSource := *source
+		if err := SaveSource(addonsPath, name, &addonSource); err != nil {
+			debug.Printf("Warning: %v", err)
+		}
+	}
+}
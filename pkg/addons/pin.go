@@ -0,0 +1,86 @@
+package addons
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"turtlesilicon/pkg/debug"
+)
+
+// PinAddon holds a git addon at a branch, tag or commit and checks it out. An empty pin follows
+// the branch of the source again and updates the addon to its newest commit.
+func (am *AddonManager) PinAddon(addon *Addon, pin string) error {
+	if addon.Source == nil || addon.Source.Type != SourceGit {
+		return fmt.Errorf("addon %s is not updated with git, only git addons can be pinned", addon.Name)
+	}
+
+	source := *addon.Source
+	source.Pin = strings.TrimSpace(pin)
+	git := &gitSource{source: &source}
+
+	update := git
+	if source.Pin == "" && source.Branch == "" {
+		// Leave a pinned branch for the default branch, pulling would stay on it
+		branch, err := defaultBranch(addon.Path)
+		if err != nil {
+			return fmt.Errorf("failed to unpin addon %s: %v", addon.Name, err)
+		}
+		update = &gitSource{source: &Source{Type: SourceGit, Branch: branch}}
+	}
+
+	previous := git.Installed(addon.Path)
+	version, err := update.Update(addon.Path)
+	if err != nil {
+		if source.Pin == "" {
+			return fmt.Errorf("failed to unpin addon %s: %v", addon.Name, err)
+		}
+		return fmt.Errorf("failed to pin addon %s to %s: %v", addon.Name, source.Pin, err)
+	}
+	if previous != "" && previous != version {
+		source.Previous = previous
+	}
+	if err := SaveSource(filepath.Dir(addon.Path), addon.Name, &source); err != nil {
+		return err
+	}
+
+	debug.Printf("Addon %s is now at %s (%s)", addon.Name, shortCommit(version), git.Name())
+	addon.Source = &source
+	addon.LocalCommit = version
+	addon.NeedsUpdate = false
+	return nil
+}
+
+// RollbackAddon checks out the commit a git addon had before its last update or pin change. The
+// addon is pinned to that commit so the next update does not undo the rollback.
+func (am *AddonManager) RollbackAddon(addon *Addon) error {
+	if addon.Source == nil || addon.Source.Type != SourceGit || addon.Source.Previous == "" {
+		return fmt.Errorf("addon %s has no previous commit to roll back to", addon.Name)
+	}
+
+	source := *addon.Source
+	git := &gitSource{source: &source}
+
+	current := git.Installed(addon.Path)
+	if err := git.Rollback(addon.Path, source.Previous); err != nil {
+		return fmt.Errorf("failed to roll back addon %s: %v", addon.Name, err)
+	}
+	source.Pin, source.Previous = source.Previous, current
+	if err := SaveSource(filepath.Dir(addon.Path), addon.Name, &source); err != nil {
+		return err
+	}
+
+	debug.Printf("Rolled back addon %s to %s", addon.Name, shortCommit(source.Pin))
+	addon.Source = &source
+	addon.LocalCommit = source.Pin
+	addon.NeedsUpdate = false
+	return nil
+}
+
+// shortCommit shortens full commit hashes for display and leaves branch and tag names alone
+func shortCommit(ref string) string {
+	if len(ref) == 40 && strings.Trim(ref, "0123456789abcdef") == "" {
+		return ref[:7]
+	}
+	return ref
+}
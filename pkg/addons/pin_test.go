@@ -0,0 +1,135 @@
+package addons
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// testGitAddon clones a bare repository with two commits on main, the first tagged v1.0, into the
+// addons directory. It returns the addon and the two commits.
+func testGitAddon(t *testing.T) (*Addon, string, string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	t.Setenv("GIT_AUTHOR_NAME", "Test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "Test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+
+	git := func(dir string, args ...string) string {
+		t.Helper()
+		output, err := runGit(dir, args...)
+		if err != nil {
+			t.Fatalf("git %v: %v", args, err)
+		}
+		return output
+	}
+
+	root := t.TempDir()
+	origin := filepath.Join(root, "origin.git")
+	work := filepath.Join(root, "work")
+	git(root, "init", "--bare", "--initial-branch=main", origin)
+	git(root, "clone", origin, work)
+	git(work, "checkout", "-b", "main")
+
+	writeFiles(t, work, "Addon.toc")
+	git(work, "add", "-A")
+	git(work, "commit", "-m", "first")
+	git(work, "tag", "v1.0")
+	first := git(work, "rev-parse", "HEAD")
+	if err := os.WriteFile(filepath.Join(work, "Addon.lua"), []byte("second"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	git(work, "add", "-A")
+	git(work, "commit", "-m", "second")
+	second := git(work, "rev-parse", "HEAD")
+	git(work, "push", "--tags", "origin", "main")
+
+	addonsPath := filepath.Join(root, "Interface", "Addons")
+	if err := os.MkdirAll(addonsPath, 0755); err != nil {
+		t.Fatal(err)
+	}
+	addonPath := filepath.Join(addonsPath, "Addon")
+	git(addonsPath, "clone", origin, addonPath)
+
+	addon := &Addon{
+		Name:       "Addon",
+		Path:       addonPath,
+		HasGitRepo: true,
+		Source:     &Source{Type: SourceGit, URL: origin},
+	}
+	return addon, first, second
+}
+
+// headState returns the commit of HEAD and the branch it is on, "" if it is detached
+func headState(t *testing.T, addonPath string) (string, string) {
+	t.Helper()
+	commit, err := runGit(addonPath, "rev-parse", "HEAD")
+	if err != nil {
+		t.Fatalf("git rev-parse HEAD: %v", err)
+	}
+	branch, _ := runGit(addonPath, "symbolic-ref", "--quiet", "--short", "HEAD")
+	return commit, branch
+}
+
+func TestPinAndRollbackAddon(t *testing.T) {
+	addon, first, second := testGitAddon(t)
+	am := &AddonManager{}
+	addonsPath := filepath.Dir(addon.Path)
+
+	steps := []struct {
+		name         string
+		action       func() error
+		wantCommit   string
+		wantBranch   string
+		wantPin      string
+		wantPrevious string
+	}{
+		{"pin to a tag", func() error { return am.PinAddon(addon, " v1.0 ") }, first, "", "v1.0", second},
+		{"roll back", func() error { return am.RollbackAddon(addon) }, second, "", second, first},
+		{"roll back the rollback", func() error { return am.RollbackAddon(addon) }, first, "", first, second},
+		{"unpin", func() error { return am.PinAddon(addon, "") }, second, "main", "", first},
+	}
+
+	for _, step := range steps {
+		if err := step.action(); err != nil {
+			t.Fatalf("%s: error = %v", step.name, err)
+		}
+		commit, branch := headState(t, addon.Path)
+		if commit != step.wantCommit || branch != step.wantBranch {
+			t.Errorf("%s: HEAD = %s on %q, want %s on %q", step.name, shortCommit(commit), branch, shortCommit(step.wantCommit), step.wantBranch)
+		}
+		if addon.LocalCommit != step.wantCommit {
+			t.Errorf("%s: LocalCommit = %s, want %s", step.name, shortCommit(addon.LocalCommit), shortCommit(step.wantCommit))
+		}
+		saved := LoadSources(addonsPath)["Addon"]
+		if saved == nil {
+			t.Fatalf("%s: no source recorded", step.name)
+		}
+		for _, source := range []*Source{addon.Source, saved} {
+			if source.Pin != step.wantPin || source.Previous != step.wantPrevious {
+				t.Errorf("%s: Pin, Previous = %s, %s, want %s, %s", step.name, shortCommit(source.Pin), shortCommit(source.Previous), shortCommit(step.wantPin), shortCommit(step.wantPrevious))
+			}
+		}
+	}
+
+	if err := am.PinAddon(addon, "missing"); err == nil {
+		t.Errorf("PinAddon() to a missing ref = nil, want error")
+	}
+	addon.Source.Previous = ""
+	if err := am.RollbackAddon(addon); err == nil {
+		t.Errorf("RollbackAddon() without a previous commit = nil, want error")
+	}
+	zip := &Addon{Name: "Zip", Path: filepath.Join(addonsPath, "Zip"), Source: &Source{Type: SourceZip, URL: "https://example.com/zip.zip", Previous: first}}
+	if err := am.PinAddon(zip, "v1.0"); err == nil {
+		t.Errorf("PinAddon() of a zip addon = nil, want error")
+	}
+	if err := am.RollbackAddon(zip); err == nil {
+		t.Errorf("RollbackAddon() of a zip addon = nil, want error")
+	}
+}
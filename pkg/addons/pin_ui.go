@@ -0,0 +1,151 @@
+package addons
+
+import (
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// showPinDialog lets the user pin a git addon to a branch, tag or commit, unpin it or roll it
+// back to the commit it had before
+func (am *AddonManager) showPinDialog(addon *Addon) {
+	source := addon.Source
+	git := &gitSource{source: source}
+
+	titleText := widget.NewLabel("Pin " + addon.Name)
+	titleText.TextStyle = fyne.TextStyle{Bold: true}
+
+	statusText := "Not pinned, updates follow the default branch"
+	if source.Pin != "" {
+		statusText = "Pinned to " + shortCommit(source.Pin)
+	} else if source.Branch != "" {
+		statusText = "Not pinned, updates follow the branch " + source.Branch
+	}
+	statusLabel := widget.NewLabel(fmt.Sprintf("%s\nInstalled commit: %s", statusText, shortCommit(addon.LocalCommit)))
+
+	newerLabel := widget.NewLabel(fmt.Sprintf("A newer version is available but pinned. Unpin %s or pin a newer version to update it.", addon.Name))
+	newerLabel.TextStyle = fyne.TextStyle{Italic: true}
+	newerLabel.Wrapping = fyne.TextWrapWord
+	if !addon.NewerPinned {
+		newerLabel.Hide()
+	}
+
+	pinEntry := widget.NewSelectEntry(git.Refs(addon.Path))
+	pinEntry.SetPlaceHolder("Branch, tag or commit")
+	pinEntry.SetText(source.Pin)
+
+	pinButton := widget.NewButton("Pin", nil)
+	pinButton.Importance = widget.HighImportance
+
+	unpinButton := widget.NewButton("Unpin", nil)
+	unpinButton.Importance = widget.MediumImportance
+	if source.Pin == "" {
+		unpinButton.Disable()
+	}
+
+	rollbackButton := widget.NewButton("Roll Back", nil)
+	rollbackButton.Importance = widget.MediumImportance
+	if source.Previous == "" {
+		rollbackButton.Disable()
+	} else {
+		rollbackButton.SetText("Roll Back to " + shortCommit(source.Previous))
+	}
+
+	contentContainer := container.NewVBox(
+		titleText,
+		widget.NewSeparator(),
+		statusLabel,
+		newerLabel,
+		pinEntry,
+		widget.NewSeparator(),
+		container.NewHBox(pinButton, unpinButton, rollbackButton),
+	)
+
+	windowSize := am.window.Content().Size()
+	popupWidth := windowSize.Width * 2 / 3
+	popupHeight := windowSize.Height / 3
+
+	closeButton := widget.NewButton("✕", func() {
+		// This will be set when the popup is created
+	})
+	closeButton.Importance = widget.LowImportance
+
+	topBar := container.NewBorder(
+		nil,
+		nil,
+		closeButton,
+		nil,
+		nil,
+	)
+
+	mainContainer := container.NewBorder(
+		topBar,
+		nil,
+		nil,
+		nil,
+		contentContainer,
+	)
+
+	popup := widget.NewModalPopUp(mainContainer, am.window.Canvas())
+	popup.Resize(fyne.NewSize(popupWidth, popupHeight))
+
+	closeButton.OnTapped = func() {
+		popup.Hide()
+	}
+
+	pinButton.OnTapped = func() {
+		pin := strings.TrimSpace(pinEntry.Text)
+		if pin == "" {
+			dialog.ShowError(fmt.Errorf("please enter the branch, tag or commit to pin %s to", addon.Name), am.window)
+			return
+		}
+		popup.Hide()
+		am.runPinAction(fmt.Sprintf("Pinning %s to %s...", addon.Name, pin), func() error {
+			return am.PinAddon(addon, pin)
+		})
+	}
+
+	unpinButton.OnTapped = func() {
+		popup.Hide()
+		am.runPinAction(fmt.Sprintf("Updating %s...", addon.Name), func() error {
+			return am.PinAddon(addon, "")
+		})
+	}
+
+	rollbackButton.OnTapped = func() {
+		message := fmt.Sprintf("Roll back %s to commit %s? It stays pinned to that commit until you change the pin.", addon.Name, shortCommit(source.Previous))
+		dialog.ShowConfirm("Roll Back Addon", message, func(confirmed bool) {
+			if !confirmed {
+				return
+			}
+			popup.Hide()
+			am.runPinAction(fmt.Sprintf("Rolling back %s...", addon.Name), func() error {
+				return am.RollbackAddon(addon)
+			})
+		}, am.window)
+	}
+
+	popup.Show()
+}
+
+// runPinAction runs a pin change in the background and refreshes the addon list afterwards
+func (am *AddonManager) runPinAction(message string, action func() error) {
+	progressDialog := dialog.NewProgressInfinite("Pinning addon", message, am.window)
+	progressDialog.Show()
+
+	go func() {
+		err := action()
+		fyne.Do(func() {
+			progressDialog.Hide()
+			if err != nil {
+				dialog.ShowError(err, am.window)
+				return
+			}
+			am.refreshWithUpdateCheck(false)
+		})
+	}()
+}
@@ -0,0 +1,576 @@
+package addons
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"turtlesilicon/pkg/debug"
+)
+
+// sourcesFileName records where the addons in Interface/Addons were installed from
+const sourcesFileName = "addon_sources.json"
+
+// Source types
+const (
+	SourceGit    = "git"    // A git repository, updated by pulling a branch
+	SourceGitHub = "github" // Packaged GitHub releases
+	SourceGitLab = "gitlab" // Packaged GitLab releases
+	SourceGitea  = "gitea"  // Packaged Gitea or Forgejo releases, e.g. on Codeberg
+	SourceZip    = "zip"    // A zip file at a fixed URL
+	SourceNone   = "none"   // Not updated, keeps a cloned addon from defaulting to git
+)
+
+// SourceTypes lists the source types in the order the addon manager offers them
+var SourceTypes = []string{SourceGit, SourceGitHub, SourceGitLab, SourceGitea, SourceZip}
+
+var ErrUnknownSource = errors.New("unknown addon source type")
+
+// Source records where an addon is installed and updated from
+type Source struct {
+	Type         string `json:"type"`
+	URL          string `json:"url"` // Repository or zip URL, git uses origin
+	Branch       string `json:"branch,omitempty"`
+	AssetPattern string `json:"asset_pattern,omitempty"` // Release asset to download, e.g. *.zip
+	Version      string `json:"version,omitempty"`       // Installed release tag or zip ETag, git uses HEAD
+	Pin          string `json:"pin,omitempty"`           // git branch, tag or commit the addon is held at
+	Previous     string `json:"previous,omitempty"`      // git commit before the last update, for rolling back
+}
+
+// AddonSource checks and installs the versions a source offers. Versions are commit hashes for
+// git, release tags for releases and the ETag, modification date or SHA-256 for zip URLs.
+type AddonSource interface {
+	// Name describes the source, e.g. "GitHub releases"
+	Name() string
+	// Installed returns the version of the addon in addonPath, "" if unknown
+	Installed(addonPath string) string
+	// Latest returns the newest version the source offers for the addon in addonPath
+	Latest(addonPath string) (string, error)
+	// Update replaces the addon in addonPath with the newest version and returns that version
+	Update(addonPath string) (string, error)
+}
+
+// Provider returns the AddonSource for the source type
+func (s *Source) Provider() (AddonSource, error) {
+	switch s.Type {
+	case SourceGit:
+		return &gitSource{source: s}, nil
+	case SourceGitHub, SourceGitLab, SourceGitea:
+		return &releaseSource{source: s}, nil
+	case SourceZip:
+		return &zipSource{source: s}, nil
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnknownSource, s.Type)
+}
+
+func sourcesPath(addonsPath string) string {
+	return filepath.Join(addonsPath, sourcesFileName)
+}
+
+// LoadSources returns the recorded sources of the addons keyed by folder name
+func LoadSources(addonsPath string) map[string]*Source {
+	sources := make(map[string]*Source)
+	data, err := os.ReadFile(sourcesPath(addonsPath))
+	if err != nil {
+		return sources
+	}
+	if err := json.Unmarshal(data, &sources); err != nil {
+		debug.Printf("Failed to parse %s: %v", sourcesFileName, err)
+		return make(map[string]*Source)
+	}
+	return sources
+}
+
+// SaveSource records the source of an addon. A nil source removes the record.
+func SaveSource(addonsPath string, addonName string, source *Source) error {
+	sources := LoadSources(addonsPath)
+	if source == nil {
+		delete(sources, addonName)
+	} else {
+		sources[addonName] = source
+	}
+	data, err := json.MarshalIndent(sources, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode addon sources: %v", err)
+	}
+	if err := os.WriteFile(sourcesPath(addonsPath), data, 0644); err != nil {
+		return fmt.Errorf("failed to save addon sources: %v", err)
+	}
+	return nil
+}
+
+// gitSource updates an addon cloned with git from its remote
+type gitSource struct {
+	source *Source
+}
+
+func (g *gitSource) Name() string {
+	if g.source.Pin != "" {
+		return "git, pinned to " + g.source.Pin
+	}
+	if g.source.Branch != "" {
+		return "git branch " + g.source.Branch
+	}
+	return "git"
+}
+
+func (g *gitSource) Installed(addonPath string) string {
+	output, err := runGit(addonPath, "rev-parse", "HEAD")
+	if err != nil {
+		debug.Printf("Failed to get local commit for %s: %v", addonPath, err)
+		return ""
+	}
+	return output
+}
+
+// Latest returns the commit the addon is updated to. A pinned branch moves with origin, a pinned
+// tag or commit stays where it is.
+func (g *gitSource) Latest(addonPath string) (string, error) {
+	pin := g.source.Pin
+	if pin == "" {
+		return g.Unpinned(addonPath)
+	}
+
+	refs, err := lsRemote(addonPath, "refs/heads/"+pin, "refs/tags/"+pin, "refs/tags/"+pin+"^{}")
+	if err != nil {
+		return "", err
+	}
+	// Annotated tags list the tag object and, with ^{}, the commit it points at
+	for _, ref := range []string{"refs/heads/" + pin, "refs/tags/" + pin + "^{}", "refs/tags/" + pin} {
+		if commit, ok := refs[ref]; ok {
+			return commit, nil
+		}
+	}
+	if commit, err := runGit(addonPath, "rev-parse", "--verify", "--quiet", pin+"^{commit}"); err == nil {
+		return commit, nil
+	}
+	return "", fmt.Errorf("origin has no branch, tag or commit %s", pin)
+}
+
+// Unpinned asks origin for the commit of the branch without fetching, the default branch if none
+// is set. The pin is ignored.
+func (g *gitSource) Unpinned(addonPath string) (string, error) {
+	ref := "HEAD"
+	if g.source.Branch != "" {
+		ref = "refs/heads/" + g.source.Branch
+	}
+	refs, err := lsRemote(addonPath, ref)
+	if err != nil {
+		return "", err
+	}
+	commit, ok := refs[ref]
+	if !ok {
+		return "", fmt.Errorf("origin has no %s", ref)
+	}
+	return commit, nil
+}
+
+// Update fetches origin and checks out the pin, or pulls the followed branch
+func (g *gitSource) Update(addonPath string) (string, error) {
+	if g.source.Pin != "" {
+		if err := g.checkoutPin(addonPath); err != nil {
+			return "", err
+		}
+		return g.Installed(addonPath), nil
+	}
+
+	branch := g.source.Branch
+	if branch == "" {
+		// A former pin leaves HEAD detached, which has nothing to pull
+		if _, err := runGit(addonPath, "symbolic-ref", "--quiet", "HEAD"); err != nil {
+			if branch, err = defaultBranch(addonPath); err != nil {
+				return "", err
+			}
+		}
+	}
+	args := []string{"pull"}
+	if branch != "" {
+		if _, err := runGit(addonPath, "checkout", branch); err != nil {
+			return "", err
+		}
+		args = append(args, "origin", branch)
+	}
+	if _, err := runGit(addonPath, args...); err != nil {
+		return "", err
+	}
+	return g.Installed(addonPath), nil
+}
+
+// checkoutPin checks out a pinned branch and fast-forwards it, or detaches HEAD at a pinned tag
+// or commit
+func (g *gitSource) checkoutPin(addonPath string) error {
+	pin := g.source.Pin
+	if _, err := runGit(addonPath, "fetch", "--tags", "origin"); err != nil {
+		return err
+	}
+	if _, err := runGit(addonPath, "rev-parse", "--verify", "--quiet", "refs/remotes/origin/"+pin); err == nil {
+		if _, err := runGit(addonPath, "checkout", pin); err != nil {
+			return err
+		}
+		_, err := runGit(addonPath, "merge", "--ff-only", "origin/"+pin)
+		return err
+	}
+	if _, err := runGit(addonPath, "rev-parse", "--verify", "--quiet", pin+"^{commit}"); err != nil {
+		return fmt.Errorf("origin has no branch, tag or commit %s", pin)
+	}
+	_, err := runGit(addonPath, "checkout", "--detach", pin)
+	return err
+}
+
+// Rollback checks out a commit the addon had before
+func (g *gitSource) Rollback(addonPath string, commit string) error {
+	_, err := runGit(addonPath, "checkout", "--detach", commit)
+	return err
+}
+
+// Refs returns the branches of origin and the tags known locally, for choosing a pin
+func (g *gitSource) Refs(addonPath string) []string {
+	output, err := runGit(addonPath, "for-each-ref", "--format=%(refname)", "refs/remotes/origin", "refs/tags")
+	if err != nil {
+		return nil
+	}
+	var refs []string
+	for _, ref := range strings.Fields(output) {
+		if strings.HasPrefix(ref, "refs/remotes/origin/") {
+			if ref = strings.TrimPrefix(ref, "refs/remotes/origin/"); ref != "HEAD" {
+				refs = append(refs, ref)
+			}
+		} else {
+			refs = append(refs, strings.TrimPrefix(ref, "refs/tags/"))
+		}
+	}
+	return refs
+}
+
+// defaultBranch returns the branch origin/HEAD points at
+func defaultBranch(addonPath string) (string, error) {
+	output, err := runGit(addonPath, "symbolic-ref", "--short", "refs/remotes/origin/HEAD")
+	if err != nil {
+		if _, err := runGit(addonPath, "remote", "set-head", "origin", "--auto"); err != nil {
+			return "", err
+		}
+		if output, err = runGit(addonPath, "symbolic-ref", "--short", "refs/remotes/origin/HEAD"); err != nil {
+			return "", err
+		}
+	}
+	return strings.TrimPrefix(output, "origin/"), nil
+}
+
+// lsRemote asks origin for the commits of refs without fetching, keyed by ref name
+func lsRemote(addonPath string, refs ...string) (map[string]string, error) {
+	output, err := runGit(addonPath, append([]string{"ls-remote", "origin"}, refs...)...)
+	if err != nil {
+		return nil, err
+	}
+	commits := make(map[string]string)
+	for _, line := range strings.Split(output, "\n") {
+		if fields := strings.Fields(line); len(fields) == 2 {
+			commits[fields[1]] = fields[0]
+		}
+	}
+	return commits, nil
+}
+
+// runGit runs git in dir and returns its trimmed output
+func runGit(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		debug.Printf("git %s failed: %s", strings.Join(args, " "), string(output))
+		return "", fmt.Errorf("git %s failed: %v", args[0], err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// release is a release of a GitHub, GitLab or Gitea repository
+type release struct {
+	Tag    string
+	Assets map[string]string // Download URL by asset name
+	ZipURL string            // Source archive of the tag
+}
+
+// releaseSource updates an addon from the packaged releases of a repository
+type releaseSource struct {
+	source *Source
+}
+
+func (r *releaseSource) Name() string {
+	switch r.source.Type {
+	case SourceGitHub:
+		return "GitHub releases"
+	case SourceGitLab:
+		return "GitLab releases"
+	}
+	return "Gitea releases"
+}
+
+func (r *releaseSource) Installed(addonPath string) string {
+	return r.source.Version
+}
+
+func (r *releaseSource) Latest(addonPath string) (string, error) {
+	latest, err := r.fetchLatest()
+	if err != nil {
+		return "", err
+	}
+	return latest.Tag, nil
+}
+
+func (r *releaseSource) Update(addonPath string) (string, error) {
+	latest, err := r.fetchLatest()
+	if err != nil {
+		return "", err
+	}
+	downloadURL, err := latest.download(r.source.AssetPattern)
+	if err != nil {
+		return "", err
+	}
+	if _, err := replaceFromZip(downloadURL, addonPath); err != nil {
+		return "", err
+	}
+	return latest.Tag, nil
+}
+
+// fetchLatest asks the API of the forge for the latest release
+func (r *releaseSource) fetchLatest() (*release, error) {
+	host, repo, err := splitRepositoryURL(r.source.URL, r.source.Type == SourceGitLab)
+	if err != nil {
+		return nil, err
+	}
+
+	latest := &release{Assets: make(map[string]string)}
+	switch r.source.Type {
+	case SourceGitHub:
+		var data struct {
+			TagName    string `json:"tag_name"`
+			ZipballURL string `json:"zipball_url"`
+			Assets     []struct {
+				Name string `json:"name"`
+				URL  string `json:"browser_download_url"`
+			} `json:"assets"`
+		}
+		if err := fetchJSON(fmt.Sprintf("https://api.%s/repos/%s/releases/latest", host, repo), &data); err != nil {
+			return nil, err
+		}
+		latest.Tag, latest.ZipURL = data.TagName, data.ZipballURL
+		for _, asset := range data.Assets {
+			latest.Assets[asset.Name] = asset.URL
+		}
+	case SourceGitLab:
+		var data struct {
+			TagName string `json:"tag_name"`
+			Assets  struct {
+				Links []struct {
+					Name string `json:"name"`
+					URL  string `json:"direct_asset_url"`
+				} `json:"links"`
+				Sources []struct {
+					Format string `json:"format"`
+					URL    string `json:"url"`
+				} `json:"sources"`
+			} `json:"assets"`
+		}
+		if err := fetchJSON(fmt.Sprintf("https://%s/api/v4/projects/%s/releases/permalink/latest", host, url.PathEscape(repo)), &data); err != nil {
+			return nil, err
+		}
+		latest.Tag = data.TagName
+		for _, link := range data.Assets.Links {
+			latest.Assets[link.Name] = link.URL
+		}
+		for _, source := range data.Assets.Sources {
+			if source.Format == "zip" {
+				latest.ZipURL = source.URL
+			}
+		}
+	case SourceGitea:
+		var data struct {
+			TagName    string `json:"tag_name"`
+			ZipballURL string `json:"zipball_url"`
+			Assets     []struct {
+				Name string `json:"name"`
+				URL  string `json:"browser_download_url"`
+			} `json:"assets"`
+		}
+		if err := fetchJSON(fmt.Sprintf("https://%s/api/v1/repos/%s/releases/latest", host, repo), &data); err != nil {
+			return nil, err
+		}
+		latest.Tag, latest.ZipURL = data.TagName, data.ZipballURL
+		for _, asset := range data.Assets {
+			latest.Assets[asset.Name] = asset.URL
+		}
+	}
+
+	if latest.Tag == "" {
+		return nil, fmt.Errorf("%s has no releases", r.source.URL)
+	}
+	return latest, nil
+}
+
+// download returns the URL of the asset matching pattern. Without a pattern the only zip asset
+// is used, or the source archive of the tag if there is none.
+func (r *release) download(pattern string) (string, error) {
+	if pattern == "" {
+		var zips []string
+		for name, assetURL := range r.Assets {
+			if strings.EqualFold(path.Ext(name), ".zip") {
+				zips = append(zips, assetURL)
+			}
+		}
+		if len(zips) == 1 {
+			return zips[0], nil
+		}
+		if len(zips) > 1 {
+			return "", fmt.Errorf("release %s has several zip files, set an asset pattern", r.Tag)
+		}
+		if r.ZipURL == "" {
+			return "", fmt.Errorf("release %s has no zip file", r.Tag)
+		}
+		return r.ZipURL, nil
+	}
+
+	for name, assetURL := range r.Assets {
+		if matched, err := path.Match(strings.ToLower(pattern), strings.ToLower(name)); err == nil && matched {
+			return assetURL, nil
+		}
+	}
+	return "", fmt.Errorf("release %s has no asset matching %s", r.Tag, pattern)
+}
+
+// zipSource updates an addon from a zip file whose URL stays the same between versions
+type zipSource struct {
+	source *Source
+}
+
+func (z *zipSource) Name() string {
+	return "zip URL"
+}
+
+func (z *zipSource) Installed(addonPath string) string {
+	return z.source.Version
+}
+
+// Latest identifies the current file by its ETag or modification date, without downloading it.
+// It returns an empty version for servers that send neither, Check downloads the file for those.
+func (z *zipSource) Latest(addonPath string) (string, error) {
+	resp, err := http.Head(z.source.URL)
+	if err != nil {
+		return "", fmt.Errorf("failed to check %s: %v", z.source.URL, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("server returned status %d for %s", resp.StatusCode, z.source.URL)
+	}
+	return headerVersion(resp.Header), nil
+}
+
+// Check is Latest for explicit update checks, servers without an ETag or modification date are
+// checked by the SHA-256 of the file
+func (z *zipSource) Check(addonPath string) (string, error) {
+	latest, err := z.Latest(addonPath)
+	if err != nil || latest != "" {
+		return latest, err
+	}
+	return contentHash(z.source.URL)
+}
+
+// headerVersion identifies a file by the ETag or modification date the server sent for it
+func headerVersion(header http.Header) string {
+	if etag := header.Get("ETag"); etag != "" {
+		return etag
+	}
+	return header.Get("Last-Modified")
+}
+
+// contentHash downloads a file and returns its SHA-256 as a version
+func contentHash(fileURL string) (string, error) {
+	resp, err := http.Get(fileURL)
+	if err != nil {
+		return "", fmt.Errorf("failed to check %s: %v", fileURL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("server returned status %d for %s", resp.StatusCode, fileURL)
+	}
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, resp.Body); err != nil {
+		return "", fmt.Errorf("failed to check %s: %v", fileURL, err)
+	}
+	return hashVersion(hasher.Sum(nil)), nil
+}
+
+// hashVersion formats the SHA-256 of a file as a version
+func hashVersion(sum []byte) string {
+	return "sha256:" + hex.EncodeToString(sum)
+}
+
+// Update records the version of the download it installed, so a file that changes on the
+// server during the update is not mistaken for the installed one
+func (z *zipSource) Update(addonPath string) (string, error) {
+	return replaceFromZip(z.source.URL, addonPath)
+}
+
+// replaceFromZip downloads a zip and replaces the addon in addonPath with the addon of the same
+// name in it. It returns the version of the downloaded zip.
+func replaceFromZip(zipURL string, addonPath string) (string, error) {
+	staged, err := StageAddons(zipURL)
+	if err != nil {
+		return "", err
+	}
+	defer staged.Cleanup()
+
+	name := filepath.Base(addonPath)
+	if !staged.Only(name) {
+		return "", fmt.Errorf("the download does not contain the addon %s, only %s", name, strings.Join(staged.Names(), ", "))
+	}
+	if err := staged.Install(true); err != nil {
+		return "", err
+	}
+	return staged.Version, nil
+}
+
+// splitRepositoryURL splits https://host/owner/name into host and owner/name. A leading www. is
+// dropped from the host, the APIs are not served there. Anything after the name, like
+// /tree/main, is dropped unless the forge nests groups like GitLab does.
+func splitRepositoryURL(repositoryURL string, nestedGroups bool) (string, string, error) {
+	parsed, err := url.Parse(strings.TrimSuffix(strings.TrimSpace(repositoryURL), ".git"))
+	if err != nil || parsed.Host == "" {
+		return "", "", fmt.Errorf("invalid repository URL %q", repositoryURL)
+	}
+	parts := strings.Split(strings.Trim(parsed.Path, "/"), "/")
+	if len(parts) < 2 || parts[0] == "" {
+		return "", "", fmt.Errorf("invalid repository URL %q, expected https://host/owner/name", repositoryURL)
+	}
+	if !nestedGroups {
+		parts = parts[:2]
+	}
+	return strings.TrimPrefix(strings.ToLower(parsed.Host), "www."), strings.Join(parts, "/"), nil
+}
+
+// fetchJSON downloads and decodes a JSON document
+func fetchJSON(apiURL string, v interface{}) error {
+	resp, err := http.Get(apiURL)
+	if err != nil {
+		return fmt.Errorf("failed to download from %s: %v", apiURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("server returned status %d for %s", resp.StatusCode, apiURL)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to parse response of %s: %v", apiURL, err)
+	}
+	return nil
+}
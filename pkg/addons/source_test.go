@@ -0,0 +1,235 @@
+package addons
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"turtlesilicon/pkg/paths"
+)
+
+func TestSplitRepositoryURL(t *testing.T) {
+	tests := []struct {
+		url          string
+		nestedGroups bool
+		wantHost     string
+		wantRepo     string
+		wantErr      bool
+	}{
+		{"https://github.com/owner/addon", false, "github.com", "owner/addon", false},
+		{"https://github.com/owner/addon.git", false, "github.com", "owner/addon", false},
+		{"https://www.github.com/owner/addon/tree/main", false, "github.com", "owner/addon", false},
+		{" https://WWW.GitHub.com/owner/addon/ ", false, "github.com", "owner/addon", false},
+		{"https://gitlab.com/group/subgroup/addon", true, "gitlab.com", "group/subgroup/addon", false},
+		{"https://gitlab.com/group/subgroup/addon", false, "gitlab.com", "group/subgroup", false},
+		{"https://github.com/owner", false, "", "", true},
+		{"github.com/owner/addon", false, "", "", true},
+		{"", false, "", "", true},
+	}
+
+	for _, tt := range tests {
+		host, repo, err := splitRepositoryURL(tt.url, tt.nestedGroups)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("splitRepositoryURL(%q) error = %v, want error %v", tt.url, err, tt.wantErr)
+			continue
+		}
+		if host != tt.wantHost || repo != tt.wantRepo {
+			t.Errorf("splitRepositoryURL(%q) = %q, %q, want %q, %q", tt.url, host, repo, tt.wantHost, tt.wantRepo)
+		}
+	}
+}
+
+func TestReleaseDownload(t *testing.T) {
+	tests := []struct {
+		name    string
+		release release
+		pattern string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "only zip asset",
+			release: release{Tag: "v1", Assets: map[string]string{"Addon.ZIP": "asset.zip", "notes.txt": "notes"}, ZipURL: "source.zip"},
+			want:    "asset.zip",
+		},
+		{
+			name:    "source archive without assets",
+			release: release{Tag: "v1", Assets: map[string]string{}, ZipURL: "source.zip"},
+			want:    "source.zip",
+		},
+		{
+			name:    "several zip assets",
+			release: release{Tag: "v1", Assets: map[string]string{"a.zip": "a", "b.zip": "b"}},
+			wantErr: true,
+		},
+		{
+			name:    "pattern picks one of several",
+			release: release{Tag: "v1", Assets: map[string]string{"Addon-classic.zip": "classic", "Addon-retail.zip": "retail"}},
+			pattern: "*classic*",
+			want:    "classic",
+		},
+		{
+			name:    "pattern without match",
+			release: release{Tag: "v1", Assets: map[string]string{"Addon-retail.zip": "retail"}, ZipURL: "source.zip"},
+			pattern: "*classic*",
+			wantErr: true,
+		},
+		{
+			name:    "nothing to download",
+			release: release{Tag: "v1", Assets: map[string]string{}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		got, err := tt.release.download(tt.pattern)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: download() error = %v, want error %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: download() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestZipSourceLatest(t *testing.T) {
+	content := "zip content"
+	var etag, modified string
+	downloads := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if etag != "" {
+			w.Header().Set("ETag", etag)
+		}
+		if modified != "" {
+			w.Header().Set("Last-Modified", modified)
+		}
+		if r.Method == http.MethodGet {
+			downloads++
+		}
+		w.Write([]byte(content))
+	}))
+	defer server.Close()
+	source := &zipSource{source: &Source{Type: SourceZip, URL: server.URL}}
+
+	etag, modified = `"abc"`, "Mon, 02 Jan 2006 15:04:05 GMT"
+	if got, err := source.Latest(""); err != nil || got != `"abc"` {
+		t.Errorf("Latest() with an ETag = %q, %v, want the ETag", got, err)
+	}
+
+	etag = ""
+	if got, err := source.Latest(""); err != nil || got != modified {
+		t.Errorf("Latest() with Last-Modified = %q, %v, want %q", got, err, modified)
+	}
+	if got, err := source.Check(""); err != nil || got != modified {
+		t.Errorf("Check() with Last-Modified = %q, %v, want %q", got, err, modified)
+	}
+	if downloads != 0 {
+		t.Errorf("Latest() and Check() downloaded the file %d times, want 0 when the server sends headers", downloads)
+	}
+
+	modified = ""
+	if got, err := source.Latest(""); err != nil || got != "" {
+		t.Errorf("Latest() without headers = %q, %v, want no version", got, err)
+	}
+	if downloads != 0 {
+		t.Errorf("Latest() downloaded the file %d times, want 0", downloads)
+	}
+	first, err := source.Check("")
+	if err != nil || !strings.HasPrefix(first, "sha256:") {
+		t.Fatalf("Check() without headers = %q, %v, want a content hash", first, err)
+	}
+	content = "changed zip content"
+	if second, _ := source.Check(""); second == first {
+		t.Errorf("Check() = %q after the file changed, want a different version", second)
+	}
+}
+
+func TestZipSourceUpdate(t *testing.T) {
+	gamePath := t.TempDir()
+	defer func(path string) { paths.TurtlewowPath = path }(paths.TurtlewowPath)
+	paths.TurtlewowPath = gamePath
+	addonsPath := filepath.Join(gamePath, "Interface", "Addons")
+	writeFiles(t, addonsPath, "Addon/Addon.toc", "Addon/old.lua")
+
+	var content bytes.Buffer
+	writer := zip.NewWriter(&content)
+	for _, name := range []string{"Addon/Addon.toc", "Addon/new.lua"} {
+		w, err := writer.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(name))
+	}
+	writer.Close()
+
+	var etag string
+	downloads := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if etag != "" {
+			w.Header().Set("ETag", etag)
+		}
+		if r.Method == http.MethodGet {
+			downloads++
+		}
+		w.Write(content.Bytes())
+	}))
+	defer server.Close()
+	source := &zipSource{source: &Source{Type: SourceZip, URL: server.URL}}
+
+	// Without headers the hash of the installed download is recorded, it matches a later check
+	version, err := source.Update(filepath.Join(addonsPath, "Addon"))
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	sum := sha256.Sum256(content.Bytes())
+	if want := "sha256:" + hex.EncodeToString(sum[:]); version != want {
+		t.Errorf("Update() = %q, want %q", version, want)
+	}
+	if downloads != 1 {
+		t.Errorf("Update() downloaded the file %d times, want 1", downloads)
+	}
+	if checked, _ := source.Check(""); checked != version {
+		t.Errorf("Check() after Update() = %q, want %q", checked, version)
+	}
+	if _, err := os.Stat(filepath.Join(addonsPath, "Addon", "new.lua")); err != nil {
+		t.Errorf("new version not installed: %v", err)
+	}
+
+	etag = `"v2"`
+	if version, err := source.Update(filepath.Join(addonsPath, "Addon")); err != nil || version != etag {
+		t.Errorf("Update() with an ETag = %q, %v, want %q", version, err, etag)
+	}
+}
+
+func TestScanAddonsSourceNone(t *testing.T) {
+	gamePath := t.TempDir()
+	defer func(path string) { paths.TurtlewowPath = path }(paths.TurtlewowPath)
+	paths.TurtlewowPath = gamePath
+	addonsPath := filepath.Join(gamePath, "Interface", "Addons")
+	writeFiles(t, addonsPath, "Cloned/Cloned.toc", "Cloned/.git/", "Stopped/Stopped.toc", "Stopped/.git/")
+	if err := SaveSource(addonsPath, "Stopped", &Source{Type: SourceNone}); err != nil {
+		t.Fatal(err)
+	}
+
+	am := &AddonManager{}
+	if err := am.ScanAddons(); err != nil {
+		t.Fatalf("ScanAddons() error = %v", err)
+	}
+	want := map[string]bool{"Cloned": true, "Stopped": false}
+	for _, addon := range am.addons {
+		if hasSource := addon.Source != nil; hasSource != want[addon.Name] {
+			t.Errorf("ScanAddons() %s has a source = %v, want %v", addon.Name, hasSource, want[addon.Name])
+		}
+	}
+	if sources := LoadSources(addonsPath); sources["Stopped"] == nil || sources["Stopped"].Type != SourceNone {
+		t.Errorf("LoadSources() Stopped = %+v, want the none record kept", sources["Stopped"])
+	}
+}
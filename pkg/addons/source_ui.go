@@ -0,0 +1,89 @@
+package addons
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// showSourceDialog lets the user choose where an addon is updated from
+func (am *AddonManager) showSourceDialog(addon *Addon) {
+	current := addon.Source
+	if current == nil {
+		current = &Source{}
+	}
+
+	typeSelect := widget.NewSelect(append(append([]string{}, SourceTypes...), SourceNone), nil)
+	if current.Type != "" {
+		typeSelect.SetSelected(current.Type)
+	} else {
+		typeSelect.SetSelected(SourceNone)
+	}
+
+	urlEntry := widget.NewEntry()
+	urlEntry.SetPlaceHolder("https://github.com/owner/addon")
+	urlEntry.SetText(current.URL)
+
+	branchEntry := widget.NewEntry()
+	branchEntry.SetPlaceHolder("Default branch")
+	branchEntry.SetText(current.Branch)
+
+	patternEntry := widget.NewEntry()
+	patternEntry.SetPlaceHolder("The only .zip file of the release")
+	patternEntry.SetText(current.AssetPattern)
+
+	typeItem := widget.NewFormItem("Type", typeSelect)
+	typeItem.HintText = "git needs a cloned addon, releases need the repository URL"
+
+	dialog.ShowForm("Update "+addon.Name+" From", "Save", "Cancel", []*widget.FormItem{
+		typeItem,
+		widget.NewFormItem("URL", urlEntry),
+		widget.NewFormItem("Git branch", branchEntry),
+		widget.NewFormItem("Release asset", patternEntry),
+	}, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+		addonsPath := filepath.Dir(addon.Path)
+
+		// Record the choice, a cloned addon without a record is updated with git
+		if typeSelect.Selected == SourceNone {
+			if err := SaveSource(addonsPath, addon.Name, &Source{Type: SourceNone}); err != nil {
+				dialog.ShowError(err, am.window)
+				return
+			}
+			am.refreshAddonManager()
+			return
+		}
+
+		source := &Source{
+			Type:         typeSelect.Selected,
+			URL:          strings.TrimSpace(urlEntry.Text),
+			Branch:       strings.TrimSpace(branchEntry.Text),
+			AssetPattern: strings.TrimSpace(patternEntry.Text),
+		}
+		if source.Type == SourceGit && !addon.HasGitRepo {
+			dialog.ShowError(fmt.Errorf("%s was not cloned with git, choose releases or a zip URL instead", addon.Name), am.window)
+			return
+		}
+		if source.Type != SourceGit && !isURL(source.URL) {
+			dialog.ShowError(fmt.Errorf("please enter the URL to update %s from", addon.Name), am.window)
+			return
+		}
+		// Keep the installed version and pin when only the details of the same source change
+		if source.Type == current.Type && source.URL == current.URL {
+			source.Version = current.Version
+		}
+		if source.Type == SourceGit && current.Type == SourceGit {
+			source.Pin, source.Previous = current.Pin, current.Previous
+		}
+		if err := SaveSource(addonsPath, addon.Name, source); err != nil {
+			dialog.ShowError(err, am.window)
+			return
+		}
+		am.refreshWithUpdateCheck(false)
+	}, am.window)
+}
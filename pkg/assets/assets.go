@@ -0,0 +1,52 @@
+// Package assets embeds the DLLs and rosettax87 binaries that patching installs into the game
+// directory, together with a generated manifest of their sizes and SHA-256 digests.
+package assets
+
+import (
+	"embed"
+	"fmt"
+	"sort"
+)
+
+//go:generate go run gen_manifest.go
+
+// Every asset is listed explicitly so a missing file fails the build
+//
+//go:embed winerosetta/d3d9.dll winerosetta/libDllLdr.dll winerosetta/libSiliconPatch.dll winerosetta/winerosetta.dll
+//go:embed rosettax87/rosettax87 rosettax87/libRuntimeRosettax87
+var files embed.FS
+
+// Asset describes a bundled file
+type Asset struct {
+	Name   string // Slash separated, e.g. "winerosetta/d3d9.dll"
+	Size   int64
+	SHA256 string
+}
+
+// Lookup returns the manifest entry of a bundled asset
+func Lookup(name string) (Asset, bool) {
+	asset, ok := manifest[name]
+	return asset, ok
+}
+
+// ReadFile returns the content of a bundled asset
+func ReadFile(name string) ([]byte, error) {
+	if _, ok := manifest[name]; !ok {
+		return nil, fmt.Errorf("unknown bundled asset %s", name)
+	}
+	data, err := files.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bundled asset %s: %v", name, err)
+	}
+	return data, nil
+}
+
+// Names returns the names of all bundled assets in sorted order
+func Names() []string {
+	names := make([]string, 0, len(manifest))
+	for name := range manifest {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,26 @@
+package assets
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+)
+
+func TestManifestMatchesEmbeddedFiles(t *testing.T) {
+	// A stale manifest means go generate was not run after an asset was replaced
+	for _, name := range Names() {
+		data, err := ReadFile(name)
+		if err != nil {
+			t.Fatalf("ReadFile(%s) failed: %v", name, err)
+		}
+
+		asset, _ := Lookup(name)
+		if int64(len(data)) != asset.Size {
+			t.Errorf("%s: size = %d, manifest says %d", name, len(data), asset.Size)
+		}
+		sum := sha256.Sum256(data)
+		if hash := hex.EncodeToString(sum[:]); hash != asset.SHA256 {
+			t.Errorf("%s: sha256 = %s, manifest says %s", name, hash, asset.SHA256)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+//go:build ignore
+
+// gen_manifest writes manifest_gen.go with the size and SHA-256 of every bundled asset.
+// Run it with go generate after changing anything in winerosetta/ or rosettax87/.
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"go/format"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+var assetDirs = []string{"winerosetta", "rosettax87"}
+
+func main() {
+	var names []string
+	for _, dir := range assetDirs {
+		err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !entry.IsDir() {
+				names = append(names, filepath.ToSlash(path))
+			}
+			return nil
+		})
+		if err != nil {
+			log.Fatalf("failed to list %s: %v", dir, err)
+		}
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by gen_manifest.go; DO NOT EDIT.\n\n")
+	buf.WriteString("package assets\n\n")
+	buf.WriteString("var manifest = map[string]Asset{\n")
+	for _, name := range names {
+		data, err := os.ReadFile(filepath.FromSlash(name))
+		if err != nil {
+			log.Fatalf("failed to read %s: %v", name, err)
+		}
+		sum := sha256.Sum256(data)
+		fmt.Fprintf(&buf, "\t%q: {Name: %q, Size: %d, SHA256: %q},\n", name, name, len(data), hex.EncodeToString(sum[:]))
+	}
+	buf.WriteString("}\n")
+
+	source, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatalf("failed to format manifest: %v", err)
+	}
+	if err := os.WriteFile("manifest_gen.go", source, 0644); err != nil {
+		log.Fatalf("failed to write manifest: %v", err)
+	}
+}
@@ -0,0 +1,12 @@
+// Code generated by gen_manifest.go; DO NOT EDIT.
+
+package assets
+
+var manifest = map[string]Asset{
+	"rosettax87/libRuntimeRosettax87": {Name: "rosettax87/libRuntimeRosettax87", Size: 54400, SHA256: "e00210da78649ecdb22fea9818a97ec851261ad2c841ac2bc09af9016b79ea15"},
+	"rosettax87/rosettax87":           {Name: "rosettax87/rosettax87", Size: 343632, SHA256: "cf740b3c37ab40e33fd59d97011ce0a0155828ba4ef0e54ab5a8c33a3e4fa83a"},
+	"winerosetta/d3d9.dll":            {Name: "winerosetta/d3d9.dll", Size: 4035369, SHA256: "22511d1fbb15cdbc5365dfcb231f028af427533bfb6957505720dac0aca98e3e"},
+	"winerosetta/libDllLdr.dll":       {Name: "winerosetta/libDllLdr.dll", Size: 1290752, SHA256: "f6fb65426f3abb3ebd9ece2fcf3accc8d886cdb6f65e6d94c9c9ca3321ca9f60"},
+	"winerosetta/libSiliconPatch.dll": {Name: "winerosetta/libSiliconPatch.dll", Size: 313344, SHA256: "0ea3b8f49802abf671cf281fb0d9b2ba758c56a9eea10614d368d1b2bf894095"},
+	"winerosetta/winerosetta.dll":     {Name: "winerosetta/winerosetta.dll", Size: 10752, SHA256: "e3dbb378ea8d0edfc34445361c1ec39a4641998096abd7349ef1d4818020dae4"},
+}
@@ -0,0 +1,408 @@
+// Package backup keeps a copy of every game file TurtleSilicon overwrites or deletes. Each game
+// directory has its own store in the config directory. File contents are stored once per SHA-256
+// digest and an index records which path was backed up, when and by which operation.
+package backup
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"turtlesilicon/pkg/debug"
+)
+
+// Operations recorded in the index
+const (
+	OpPatch       = "patch"
+	OpUnpatch     = "unpatch"
+	OpRepair      = "repair"
+	OpModManager  = "mod-manager"
+	OpEpochUpdate = "epoch-update"
+	OpRestore     = "restore"
+)
+
+// maxEntriesPerPath limits the history kept per file. The first entry, the original file, is
+// always kept.
+const maxEntriesPerPath = 20
+
+// maxStoreSize limits the stored contents of a game directory. Beyond it the oldest versions are
+// dropped, the originals of the files last.
+var maxStoreSize int64 = 1 << 30
+
+// ErrNoBackup is returned when a path has no backup
+var ErrNoBackup = errors.New("no backup of this file")
+
+// Entry is one backed up version of a file
+type Entry struct {
+	Path      string      `json:"path"` // Slash separated, relative to the game directory
+	SHA256    string      `json:"sha256"`
+	Size      int64       `json:"size"`
+	Mode      os.FileMode `json:"mode"`
+	Time      time.Time   `json:"time"`
+	Operation string      `json:"operation"`
+}
+
+// Store is the backup store of one game directory
+type Store struct {
+	GamePath string  `json:"game_path"`
+	Entries  []Entry `json:"entries"` // Oldest first
+
+	dir string
+}
+
+// storeMutex serializes changes to the index files
+var storeMutex sync.Mutex
+
+// storeDir returns the directory the store of a game directory is kept in
+func storeDir(gamePath string) (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	abs, err := filepath.Abs(gamePath)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(filepath.Clean(abs)))
+	return filepath.Join(dir, "TurtleSilicon", "backups", hex.EncodeToString(sum[:8])), nil
+}
+
+// Open loads the backup store of a game directory. A store that does not exist yet is empty.
+func Open(gamePath string) (*Store, error) {
+	if gamePath == "" {
+		return nil, fmt.Errorf("game path not set")
+	}
+	dir, err := storeDir(gamePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate backup store: %v", err)
+	}
+
+	store := &Store{GamePath: gamePath, dir: dir}
+	data, err := os.ReadFile(filepath.Join(dir, "index.json"))
+	if os.IsNotExist(err) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup index: %v", err)
+	}
+	if err := json.Unmarshal(data, store); err != nil {
+		return nil, fmt.Errorf("failed to parse backup index: %v", err)
+	}
+	store.GamePath = gamePath
+	store.dir = dir
+	return store, nil
+}
+
+// relPath returns the slash separated path of a file relative to the game directory
+func (s *Store) relPath(path string) (string, error) {
+	rel, err := filepath.Rel(s.GamePath, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is outside the game directory", path)
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+func (s *Store) objectPath(hash string) string {
+	return filepath.Join(s.dir, "objects", hash[:2], hash)
+}
+
+// Save backs up a file before it is overwritten or deleted. Directories are backed up file by
+// file. Paths that do not exist are ignored.
+func (s *Store) Save(path string, operation string) error {
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to back up %s: %v", path, err)
+	}
+
+	storeMutex.Lock()
+	defer storeMutex.Unlock()
+
+	// Another store of the same game directory may have added entries since this one was opened
+	if current, err := Open(s.GamePath); err == nil {
+		s.Entries = current.Entries
+	}
+
+	if !info.IsDir() {
+		if err := s.saveFile(path, info, operation); err != nil {
+			return err
+		}
+		s.pruneSize()
+		return s.writeIndex()
+	}
+
+	err = filepath.WalkDir(path, func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		fileInfo, err := d.Info()
+		if err != nil {
+			return err
+		}
+		return s.saveFile(filePath, fileInfo, operation)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to back up %s: %v", path, err)
+	}
+	s.pruneSize()
+	return s.writeIndex()
+}
+
+// saveFile copies a file into the object store unless its content is already there and adds an
+// index entry
+func (s *Store) saveFile(path string, info os.FileInfo, operation string) error {
+	rel, err := s.relPath(path)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to back up %s: %v", path, err)
+	}
+	defer file.Close()
+
+	if err := os.MkdirAll(filepath.Join(s.dir, "objects"), 0755); err != nil {
+		return fmt.Errorf("failed to create backup store: %v", err)
+	}
+	temp, err := os.CreateTemp(filepath.Join(s.dir, "objects"), ".object-")
+	if err != nil {
+		return fmt.Errorf("failed to create backup store: %v", err)
+	}
+	defer os.Remove(temp.Name())
+
+	hasher := sha256.New()
+	size, err := io.Copy(io.MultiWriter(temp, hasher), file)
+	temp.Close()
+	if err != nil {
+		return fmt.Errorf("failed to back up %s: %v", path, err)
+	}
+	hash := hex.EncodeToString(hasher.Sum(nil))
+
+	objectPath := s.objectPath(hash)
+	if _, err := os.Stat(objectPath); os.IsNotExist(err) {
+		if err := os.MkdirAll(filepath.Dir(objectPath), 0755); err != nil {
+			return fmt.Errorf("failed to create backup store: %v", err)
+		}
+		if err := os.Rename(temp.Name(), objectPath); err != nil {
+			return fmt.Errorf("failed to store backup of %s: %v", path, err)
+		}
+	}
+
+	if latest, ok := s.Latest(rel); ok && latest.SHA256 == hash {
+		// Nothing changed since the last backup of this file
+		return nil
+	}
+	s.Entries = append(s.Entries, Entry{
+		Path:      rel,
+		SHA256:    hash,
+		Size:      size,
+		Mode:      info.Mode().Perm(),
+		Time:      time.Now(),
+		Operation: operation,
+	})
+	s.prune(rel)
+	debug.Printf("Backed up %s (%s, sha256 %s)", rel, operation, hash)
+	return nil
+}
+
+// prune drops the oldest entries of a path beyond maxEntriesPerPath, keeping the original
+func (s *Store) prune(rel string) {
+	excess := len(s.History(rel)) - maxEntriesPerPath
+	if excess <= 0 {
+		return
+	}
+
+	kept := s.Entries[:0]
+	seen := 0
+	for _, entry := range s.Entries {
+		if entry.Path == rel {
+			seen++
+			// Entry 1 is the original, entries 2 to excess+1 are dropped
+			if seen > 1 && seen <= excess+1 {
+				continue
+			}
+		}
+		kept = append(kept, entry)
+	}
+	s.Entries = kept
+	s.removeUnreferencedObjects()
+}
+
+// Size returns the size of the stored contents. Entries with the same content share it.
+func (s *Store) Size() int64 {
+	counted := make(map[string]bool)
+	var size int64
+	for _, entry := range s.Entries {
+		if !counted[entry.SHA256] {
+			counted[entry.SHA256] = true
+			size += entry.Size
+		}
+	}
+	return size
+}
+
+// pruneSize drops the oldest entries until the store fits in maxStoreSize. Later versions go
+// first, the originals only if they alone are too large. The newest entry is always kept.
+func (s *Store) pruneSize() {
+	if s.Size() <= maxStoreSize {
+		return
+	}
+
+	for _, originals := range []bool{false, true} {
+		for i := 0; i < len(s.Entries)-1 && s.Size() > maxStoreSize; {
+			entry := s.Entries[i]
+			if s.isOriginal(i) != originals {
+				i++
+				continue
+			}
+			debug.Printf("Dropping backup of %s from %s to stay under the backup size limit", entry.Path, entry.Time.Format(time.RFC3339))
+			s.Entries = append(s.Entries[:i], s.Entries[i+1:]...)
+		}
+	}
+	s.removeUnreferencedObjects()
+}
+
+// isOriginal returns true if no earlier entry has the path of entry i
+func (s *Store) isOriginal(i int) bool {
+	for _, entry := range s.Entries[:i] {
+		if entry.Path == s.Entries[i].Path {
+			return false
+		}
+	}
+	return true
+}
+
+// removeUnreferencedObjects deletes stored contents no index entry refers to anymore
+func (s *Store) removeUnreferencedObjects() {
+	referenced := make(map[string]bool)
+	for _, entry := range s.Entries {
+		referenced[entry.SHA256] = true
+	}
+	filepath.WalkDir(filepath.Join(s.dir, "objects"), func(path string, d fs.DirEntry, err error) error {
+		if err == nil && d.Type().IsRegular() && !strings.HasPrefix(d.Name(), ".") && !referenced[d.Name()] {
+			os.Remove(path)
+		}
+		return nil
+	})
+}
+
+func (s *Store) writeIndex() error {
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create backup store: %v", err)
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode backup index: %v", err)
+	}
+	indexPath := filepath.Join(s.dir, "index.json")
+	if err := os.WriteFile(indexPath+".tmp", data, 0644); err != nil {
+		return fmt.Errorf("failed to save backup index: %v", err)
+	}
+	if err := os.Rename(indexPath+".tmp", indexPath); err != nil {
+		return fmt.Errorf("failed to save backup index: %v", err)
+	}
+	return nil
+}
+
+// History returns every backed up version of a path relative to the game directory, oldest first
+func (s *Store) History(rel string) []Entry {
+	var history []Entry
+	for _, entry := range s.Entries {
+		if entry.Path == rel {
+			history = append(history, entry)
+		}
+	}
+	return history
+}
+
+// Latest returns the most recent backup of a path relative to the game directory
+func (s *Store) Latest(rel string) (Entry, bool) {
+	history := s.History(rel)
+	if len(history) == 0 {
+		return Entry{}, false
+	}
+	return history[len(history)-1], true
+}
+
+// Original returns the first backup of a path, the file as it was before TurtleSilicon changed it
+func (s *Store) Original(rel string) (Entry, bool) {
+	history := s.History(rel)
+	if len(history) == 0 {
+		return Entry{}, false
+	}
+	return history[0], true
+}
+
+// Paths returns every backed up path relative to the game directory in the order they were
+// first backed up
+func (s *Store) Paths() []string {
+	seen := make(map[string]bool)
+	var paths []string
+	for _, entry := range s.Entries {
+		if !seen[entry.Path] {
+			seen[entry.Path] = true
+			paths = append(paths, entry.Path)
+		}
+	}
+	return paths
+}
+
+// ReadFile returns the backed up content of an entry after verifying its digest
+func (s *Store) ReadFile(entry Entry) ([]byte, error) {
+	content, err := os.ReadFile(s.objectPath(entry.SHA256))
+	if err != nil {
+		return nil, fmt.Errorf("backup of %s is missing from the store: %v", entry.Path, err)
+	}
+	sum := sha256.Sum256(content)
+	if hex.EncodeToString(sum[:]) != entry.SHA256 {
+		return nil, fmt.Errorf("backup of %s is corrupted", entry.Path)
+	}
+	return content, nil
+}
+
+// Restore writes a backed up version back into the game directory. The file it replaces is
+// backed up first, so a restore can be undone as well.
+func (s *Store) Restore(entry Entry) error {
+	content, err := s.ReadFile(entry)
+	if err != nil {
+		return err
+	}
+	target := filepath.Join(s.GamePath, filepath.FromSlash(entry.Path))
+	if err := s.Save(target, OpRestore); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %v", filepath.Dir(entry.Path), err)
+	}
+	if err := os.WriteFile(target+".tmp", content, entry.Mode); err != nil {
+		return fmt.Errorf("failed to restore %s: %v", entry.Path, err)
+	}
+	if err := os.Rename(target+".tmp", target); err != nil {
+		os.Remove(target + ".tmp")
+		return fmt.Errorf("failed to restore %s: %v", entry.Path, err)
+	}
+	debug.Printf("Restored %s from backup (sha256 %s)", entry.Path, entry.SHA256)
+	return nil
+}
+
+// SaveFile backs up a single file or directory of a game directory before it is changed
+func SaveFile(gamePath string, path string, operation string) error {
+	store, err := Open(gamePath)
+	if err != nil {
+		return err
+	}
+	return store.Save(path, operation)
+}
@@ -0,0 +1,178 @@
+package backup
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testStore opens the store of a temporary game directory with the config directory moved into
+// a temporary directory as well
+func testStore(t *testing.T) (*Store, string) {
+	t.Helper()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	gamePath := t.TempDir()
+	store, err := Open(gamePath)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	return store, gamePath
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSave(t *testing.T) {
+	store, gamePath := testStore(t)
+	path := filepath.Join(gamePath, "Data", "patch.mpq")
+
+	if err := store.Save(path, OpPatch); err != nil {
+		t.Fatalf("Save() of a missing file error = %v, want nil", err)
+	}
+	if len(store.Entries) != 0 {
+		t.Errorf("Save() of a missing file added %d entries, want 0", len(store.Entries))
+	}
+
+	for _, content := range []string{"one", "one", "two"} {
+		writeFile(t, path, content)
+		if err := store.Save(path, OpPatch); err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+	}
+	history := store.History("Data/patch.mpq")
+	if len(history) != 2 {
+		t.Fatalf("History() has %d entries, want 2 (unchanged content is not saved again)", len(history))
+	}
+
+	reopened, err := Open(gamePath)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	original, ok := reopened.Original("Data/patch.mpq")
+	if !ok {
+		t.Fatalf("Original() found no entry after reopening the store")
+	}
+	if content, err := reopened.ReadFile(original); err != nil || string(content) != "one" {
+		t.Errorf("ReadFile(original) = %q, %v, want \"one\"", content, err)
+	}
+
+	outside := filepath.Join(t.TempDir(), "outside.txt")
+	writeFile(t, outside, "x")
+	if err := store.Save(outside, OpPatch); err == nil {
+		t.Errorf("Save() of a file outside the game directory = nil, want error")
+	}
+}
+
+func TestPrune(t *testing.T) {
+	store, gamePath := testStore(t)
+	path := filepath.Join(gamePath, "WoW.exe")
+
+	for i := 0; i < maxEntriesPerPath+5; i++ {
+		writeFile(t, path, strings.Repeat("x", i+1))
+		if err := store.Save(path, OpPatch); err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+	}
+
+	history := store.History("WoW.exe")
+	if len(history) != maxEntriesPerPath {
+		t.Fatalf("History() has %d entries, want %d", len(history), maxEntriesPerPath)
+	}
+	if history[0].Size != 1 {
+		t.Errorf("first entry has size %d, want the original of size 1", history[0].Size)
+	}
+	if latest := history[len(history)-1]; latest.Size != maxEntriesPerPath+5 {
+		t.Errorf("latest entry has size %d, want %d", latest.Size, maxEntriesPerPath+5)
+	}
+	if _, err := os.Stat(store.objectPath(store.Entries[1].SHA256)); err != nil {
+		t.Errorf("content of a kept entry is missing: %v", err)
+	}
+}
+
+func TestPruneSize(t *testing.T) {
+	store, gamePath := testStore(t)
+	defer func(size int64) { maxStoreSize = size }(maxStoreSize)
+	maxStoreSize = 25
+
+	tests := []struct {
+		path    string
+		content string
+		want    []string // Stored entries after the save
+	}{
+		{"a.dll", "aaaaaaaaaa", []string{"a.dll"}},
+		{"a.dll", "AAAAAAAAAA", []string{"a.dll", "a.dll"}},
+		{"b.dll", "bbbbbbbbbb", []string{"a.dll", "b.dll"}}, // Drops the later a.dll before any original
+		{"c.dll", "cccccccccc", []string{"b.dll", "c.dll"}}, // Only originals left, drops the oldest
+	}
+	for _, tt := range tests {
+		writeFile(t, filepath.Join(gamePath, tt.path), tt.content)
+		if err := store.Save(filepath.Join(gamePath, tt.path), OpPatch); err != nil {
+			t.Fatalf("Save(%s) error = %v", tt.path, err)
+		}
+
+		var paths []string
+		for _, entry := range store.Entries {
+			paths = append(paths, entry.Path)
+		}
+		if strings.Join(paths, " ") != strings.Join(tt.want, " ") {
+			t.Errorf("after saving %s entries = %v, want %v", tt.content, paths, tt.want)
+		}
+		if size := store.Size(); size > maxStoreSize {
+			t.Errorf("after saving %s Size() = %d, want at most %d", tt.content, size, maxStoreSize)
+		}
+	}
+
+	// The newest entry is kept even if it alone is over the limit
+	writeFile(t, filepath.Join(gamePath, "d.dll"), strings.Repeat("d", 30))
+	if err := store.Save(filepath.Join(gamePath, "d.dll"), OpPatch); err != nil {
+		t.Fatalf("Save(d.dll) error = %v", err)
+	}
+	if len(store.Entries) != 1 || store.Entries[0].Path != "d.dll" {
+		t.Errorf("entries = %+v, want only d.dll", store.Entries)
+	}
+}
+
+func TestRestore(t *testing.T) {
+	store, gamePath := testStore(t)
+	path := filepath.Join(gamePath, "dlls.txt")
+
+	writeFile(t, path, "original")
+	if err := store.Save(path, OpModManager); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	writeFile(t, path, "changed")
+
+	original, _ := store.Original("dlls.txt")
+	if err := store.Restore(original); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	if content, _ := os.ReadFile(path); string(content) != "original" {
+		t.Errorf("restored content = %q, want \"original\"", content)
+	}
+
+	// The replaced file was backed up, so the restore can be undone
+	latest, _ := store.Latest("dlls.txt")
+	if latest.Operation != OpRestore {
+		t.Errorf("latest entry operation = %q, want %q", latest.Operation, OpRestore)
+	}
+	if content, err := store.ReadFile(latest); err != nil || string(content) != "changed" {
+		t.Errorf("ReadFile(latest) = %q, %v, want \"changed\"", content, err)
+	}
+
+	// Corrupted contents are not written back
+	if err := os.WriteFile(store.objectPath(original.SHA256), []byte("tampered"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Restore(original); err == nil {
+		t.Errorf("Restore() of a corrupted backup = nil, want error")
+	}
+}
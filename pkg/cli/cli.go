@@ -0,0 +1,449 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"turtlesilicon/pkg/assets"
+	"turtlesilicon/pkg/backup"
+	"turtlesilicon/pkg/components"
+	"turtlesilicon/pkg/fingerprint"
+	"turtlesilicon/pkg/mods"
+	"turtlesilicon/pkg/patching"
+	"turtlesilicon/pkg/version"
+	"turtlesilicon/pkg/wine"
+)
+
+// commands lists the subcommands understood by Run
+var commands = map[string]string{
+	"patch":             "Patch the game directory",
+	"unpatch":           "Remove the patch from the game directory",
+	"plan":              "Show what patching would change without changing anything",
+	"status":            "Show the state of every patch component",
+	"verify":            "Verify the patched files against the patch manifest",
+	"repair":            "Restore missing or modified patched files",
+	"patch-crossover":   "Create the unsigned wineloader2 copy in CrossOver (other Wine runtimes need no patching)",
+	"unpatch-crossover": "Remove wineloader2 from CrossOver",
+	"identify":          "Show which known build the game executable is",
+	"backups":           "List the game files kept in the backup store",
+	"restore":           "Restore the original version of a game file: restore <path>",
+
+	"components":          "List the compatibility component builds used for the game version",
+	"components-check":    "Check the release index for newer component builds",
+	"components-update":   "Download newer component builds into the component cache",
+	"components-use":      "Choose the build of a component: components-use <name> <bundled|latest|version>",
+	"components-rollback": "Go back to the previously installed build of a component and patch again",
+
+	"mod-profiles":       "List the mod profiles of the game version",
+	"mod-profile-save":   "Save the enabled mods and their load order as a profile: mod-profile-save <name>",
+	"mod-profile-apply":  "Enable exactly the mods of a profile in its load order: mod-profile-apply <name>",
+	"mod-profile-delete": "Delete a mod profile: mod-profile-delete <name>",
+	"mod-profile-launch": "Choose the profile applied before launching, \"none\" for none: mod-profile-launch <name>",
+}
+
+// IsCommand returns true if arg is a subcommand the CLI handles
+func IsCommand(arg string) bool {
+	_, ok := commands[arg]
+	return ok || arg == "help" || arg == "-h" || arg == "--help"
+}
+
+// Run executes a CLI subcommand and returns the process exit code
+func Run(args []string) int {
+	return run(args, os.Stdout, os.Stderr)
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || !IsCommand(args[0]) {
+		printUsage(stderr)
+		return 2
+	}
+	if args[0] == "help" || strings.HasPrefix(args[0], "-") {
+		printUsage(stdout)
+		return 0
+	}
+
+	command := args[0]
+	flags := flag.NewFlagSet(command, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	versionID := flags.String("version", "", "game version ID (defaults to the version selected in the app)")
+	gamePath := flags.String("game", "", "game directory (defaults to the configured path)")
+	crossoverPath := flags.String("crossover", "", "CrossOver.app path (defaults to the configured path)")
+	runtimePath := flags.String("runtime", "", "Wine runtime to use instead of the configured one: CrossOver.app, a Wine build or a Game Porting Toolkit build")
+	indexURL := flags.String("index", components.DefaultIndexURL, "component release index URL")
+	if err := flags.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	vm, err := version.LoadVersionManager()
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: failed to load version manager: %v\n", err)
+		return 1
+	}
+
+	var ver *version.GameVersion
+	if *versionID != "" {
+		ver, err = vm.GetVersion(*versionID)
+	} else {
+		ver, err = vm.GetCurrentVersion()
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	if *gamePath == "" {
+		*gamePath = ver.GamePath
+	}
+	opts := patching.GameOptionsForVersion(ver)
+	if *crossoverPath != "" {
+		opts.CrossOverPath = *crossoverPath
+		opts.Runtime = nil
+	}
+	if *runtimePath != "" {
+		runtime, err := wine.Detect(*runtimePath)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1
+		}
+		opts.Runtime = runtime
+	}
+
+	engine := patching.NewEngine(func(event patching.PatchEvent) {
+		switch event.Kind {
+		case patching.EventStepStarted:
+			fmt.Fprintf(stdout, "[%d/%d] %s\n", event.Step, event.Total, event.Message)
+		case patching.EventWarning:
+			fmt.Fprintf(stderr, "Warning: %s\n", event.Message)
+		case patching.EventDone:
+			if event.Err == nil && event.Message != "" {
+				fmt.Fprintln(stdout, event.Message)
+			}
+		}
+	})
+
+	applyPatch := func() int {
+		plan, err := patching.PlanVersionPatch(*gamePath, opts)
+		if err != nil {
+			return fail(stderr, err)
+		}
+		plan.OnApplied = func() {
+			patching.RememberPatchDefaults(ver, opts)
+			if err := vm.UpdateVersion(ver); err != nil {
+				fmt.Fprintf(stderr, "Warning: failed to save version settings: %v\n", err)
+			}
+		}
+		if _, err := engine.Apply(plan); err != nil {
+			return fail(stderr, err)
+		}
+		return 0
+	}
+
+	switch command {
+	case "patch":
+		return applyPatch()
+
+	case "plan":
+		plan, err := patching.PlanVersionPatch(*gamePath, opts)
+		if err != nil {
+			return fail(stderr, err)
+		}
+		fmt.Fprintf(stdout, "%s (%s):\n%s\n", plan.Title, plan.Root, plan.Summary())
+
+	case "unpatch":
+		if _, err := engine.UnpatchGame(*gamePath, opts); err != nil {
+			return fail(stderr, err)
+		}
+
+	case "status":
+		status := patching.CheckPatchStatus(*gamePath, opts)
+		fmt.Fprintln(stdout, status.Report())
+		if !status.GamePatched() {
+			return 1
+		}
+
+	case "verify":
+		result, err := patching.VerifyPatchManifest(*gamePath)
+		if err != nil {
+			return fail(stderr, err)
+		}
+		fmt.Fprintln(stdout, result.Summary())
+		if !result.OK() {
+			return 1
+		}
+
+	case "repair":
+		result, err := patching.RepairPatchManifest(*gamePath)
+		if err != nil {
+			return fail(stderr, err)
+		}
+		for _, path := range result.Restored {
+			fmt.Fprintf(stdout, "Restored %s\n", path)
+		}
+		for _, path := range result.Removed {
+			fmt.Fprintf(stdout, "Removed %s\n", path)
+		}
+		for _, path := range result.Unrepairable {
+			fmt.Fprintf(stderr, "Cannot repair %s, patch the game again\n", path)
+		}
+		if len(result.Unrepairable) > 0 {
+			return 1
+		}
+
+	case "patch-crossover":
+		if _, err := engine.PatchRuntime(opts.WineRuntime()); err != nil {
+			return fail(stderr, err)
+		}
+
+	case "unpatch-crossover":
+		if _, err := engine.UnpatchRuntime(opts.WineRuntime()); err != nil {
+			return fail(stderr, err)
+		}
+
+	case "identify":
+		id, err := fingerprint.Identify(filepath.Join(*gamePath, ver.ExecutableName), ver.ID)
+		if err != nil {
+			return fail(stderr, err)
+		}
+		fmt.Fprintf(stdout, "%s\n  sha256:       %s\n  file version: %s\n", id.Describe(), id.SHA256, id.FileVersion)
+		if id.State != fingerprint.MatchKnown {
+			return 1
+		}
+
+	case "backups":
+		store, err := backup.Open(*gamePath)
+		if err != nil {
+			return fail(stderr, err)
+		}
+		if len(store.Entries) == 0 {
+			fmt.Fprintln(stdout, "The backup store is empty.")
+			return 0
+		}
+		for _, path := range store.Paths() {
+			fmt.Fprintln(stdout, path)
+			for _, entry := range store.History(path) {
+				fmt.Fprintf(stdout, "  %s  %-12s %10d bytes  %s\n", entry.Time.Format("2006-01-02 15:04:05"), entry.Operation, entry.Size, entry.SHA256[:12])
+			}
+		}
+
+	case "restore":
+		if flags.NArg() != 1 {
+			fmt.Fprintln(stderr, "Usage: TurtleSilicon restore [-version id] [-game path] <path>")
+			return 2
+		}
+		store, err := backup.Open(*gamePath)
+		if err != nil {
+			return fail(stderr, err)
+		}
+		rel := flags.Arg(0)
+		if filepath.IsAbs(rel) {
+			if rel, err = filepath.Rel(*gamePath, rel); err != nil {
+				return fail(stderr, err)
+			}
+		}
+		rel = filepath.ToSlash(filepath.Clean(rel))
+		original, ok := store.Original(rel)
+		if !ok {
+			return fail(stderr, fmt.Errorf("%w: %s", backup.ErrNoBackup, rel))
+		}
+		if err := store.Restore(original); err != nil {
+			return fail(stderr, err)
+		}
+		fmt.Fprintf(stdout, "Restored %s as it was on %s\n", rel, original.Time.Format("2006-01-02 15:04:05"))
+
+	case "components":
+		installed := components.Installed(ver.ID)
+		for _, name := range assets.Names() {
+			requested := ver.Settings.ComponentVersions[name]
+			if requested == "" {
+				requested = components.VersionBundled
+			}
+			var cached []string
+			for _, c := range components.CachedVersions(name) {
+				cached = append(cached, c.Version)
+			}
+			fmt.Fprintf(stdout, "%s\n  selected:  %s\n", name, requested)
+			if installedVersion, ok := installed[name]; ok {
+				fmt.Fprintf(stdout, "  installed: %s\n", installedVersion)
+			}
+			if len(cached) > 0 {
+				fmt.Fprintf(stdout, "  cached:    %s\n", strings.Join(cached, ", "))
+			}
+		}
+
+	case "components-check", "components-update":
+		index, err := components.FetchIndex(*indexURL)
+		if err != nil {
+			return fail(stderr, err)
+		}
+		updates := index.Updates()
+		if len(updates) == 0 {
+			fmt.Fprintln(stdout, "All components are up to date.")
+			return 0
+		}
+		for _, update := range updates {
+			if command == "components-check" {
+				fmt.Fprintf(stdout, "%s: %s -> %s\n", update.Name, update.Current, update.Release.Version)
+				continue
+			}
+			if _, err := components.Download(update.Name, update.Release); err != nil {
+				return fail(stderr, err)
+			}
+			fmt.Fprintf(stdout, "Downloaded %s %s\n", update.Name, update.Release.Version)
+		}
+
+	case "components-use":
+		if flags.NArg() != 2 {
+			fmt.Fprintln(stderr, "Usage: TurtleSilicon components-use [-version id] <name> <bundled|latest|version>")
+			return 2
+		}
+		name, ok := components.FullName(flags.Arg(0))
+		if !ok {
+			return fail(stderr, fmt.Errorf("%w: %s", components.ErrUnknownComponent, flags.Arg(0)))
+		}
+		requested := flags.Arg(1)
+		if _, err := components.Resolve(name, requested); errors.Is(err, components.ErrNotCached) {
+			// Pinning a version that was never downloaded fetches it first
+			index, err := components.FetchIndex(*indexURL)
+			if err != nil {
+				return fail(stderr, err)
+			}
+			release, ok := index.Release(name, requested)
+			if !ok {
+				return fail(stderr, fmt.Errorf("%s %s is not in the release index", name, requested))
+			}
+			if _, err := components.Download(name, release); err != nil {
+				return fail(stderr, err)
+			}
+		} else if err != nil {
+			return fail(stderr, err)
+		}
+		if err := selectComponentVersion(vm, ver, name, requested); err != nil {
+			return fail(stderr, err)
+		}
+		fmt.Fprintf(stdout, "%s now uses %s %s, patch the game again to install it\n", ver.DisplayName, name, requested)
+
+	case "components-rollback":
+		if flags.NArg() != 1 {
+			fmt.Fprintln(stderr, "Usage: TurtleSilicon components-rollback [-version id] <name>")
+			return 2
+		}
+		name, ok := components.FullName(flags.Arg(0))
+		if !ok {
+			return fail(stderr, fmt.Errorf("%w: %s", components.ErrUnknownComponent, flags.Arg(0)))
+		}
+		previous, err := components.Rollback(ver.ID, name)
+		if err != nil {
+			return fail(stderr, err)
+		}
+		if err := selectComponentVersion(vm, ver, name, previous); err != nil {
+			return fail(stderr, err)
+		}
+		fmt.Fprintf(stdout, "Rolling back %s to %s\n", name, previous)
+		opts.ComponentVersions = ver.Settings.ComponentVersions
+		return applyPatch()
+
+	case "mod-profiles":
+		names := mods.ModProfileNames(ver)
+		if len(names) == 0 {
+			fmt.Fprintln(stdout, "No mod profiles saved.")
+			return 0
+		}
+		for _, name := range names {
+			marker := ""
+			if name == ver.Settings.LaunchModProfile {
+				marker = " (applied on launch)"
+			}
+			fmt.Fprintf(stdout, "%s%s\n", name, marker)
+			for _, entry := range ver.Settings.ModProfiles[name] {
+				fmt.Fprintf(stdout, "  %s\n", entry)
+			}
+		}
+
+	case "mod-profile-save", "mod-profile-apply", "mod-profile-delete", "mod-profile-launch":
+		if flags.NArg() != 1 {
+			fmt.Fprintf(stderr, "Usage: TurtleSilicon %s [-version id] [-game path] <name>\n", command)
+			return 2
+		}
+		name := flags.Arg(0)
+		switch command {
+		case "mod-profile-save":
+			if err := mods.SaveModProfile(vm, ver, *gamePath, name); err != nil {
+				return fail(stderr, err)
+			}
+			fmt.Fprintf(stdout, "Saved mod profile %s\n", name)
+		case "mod-profile-apply":
+			skipped, warnings, err := mods.ApplyModProfile(vm, ver, *gamePath, name)
+			if err != nil {
+				return fail(stderr, err)
+			}
+			for _, reason := range skipped {
+				fmt.Fprintf(stderr, "Warning: could not enable %s\n", reason)
+			}
+			for _, warning := range warnings {
+				fmt.Fprintf(stderr, "Warning: possible conflict of %s\n", warning)
+			}
+			fmt.Fprintf(stdout, "Applied mod profile %s\n", name)
+		case "mod-profile-delete":
+			if err := mods.DeleteModProfile(vm, ver, name); err != nil {
+				return fail(stderr, err)
+			}
+			fmt.Fprintf(stdout, "Deleted mod profile %s\n", name)
+		case "mod-profile-launch":
+			if strings.EqualFold(name, "none") {
+				name = ""
+			} else if _, ok := ver.Settings.ModProfiles[name]; !ok {
+				return fail(stderr, fmt.Errorf("%w: %s", mods.ErrUnknownProfile, name))
+			}
+			ver.Settings.LaunchModProfile = name
+			if err := vm.UpdateVersion(ver); err != nil {
+				return fail(stderr, fmt.Errorf("failed to save version settings: %v", err))
+			}
+			if name == "" {
+				fmt.Fprintln(stdout, "No mod profile is applied on launch")
+			} else {
+				fmt.Fprintf(stdout, "Mod profile %s is applied on launch\n", name)
+			}
+		}
+	}
+
+	return 0
+}
+
+// selectComponentVersion stores the requested build of a component in the version settings
+func selectComponentVersion(vm *version.VersionManager, ver *version.GameVersion, name, requested string) error {
+	if ver.Settings.ComponentVersions == nil {
+		ver.Settings.ComponentVersions = make(map[string]string)
+	}
+	if requested == components.VersionBundled {
+		delete(ver.Settings.ComponentVersions, name)
+	} else {
+		ver.Settings.ComponentVersions[name] = requested
+	}
+	if err := vm.UpdateVersion(ver); err != nil {
+		return fmt.Errorf("failed to save version settings: %v", err)
+	}
+	return nil
+}
+
+func fail(stderr io.Writer, err error) int {
+	fmt.Fprintf(stderr, "Error: %v\n", err)
+	return 1
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: TurtleSilicon <command> [-version id] [-game path] [-crossover path] [-runtime path] [-index url]")
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Commands:")
+	for _, name := range []string{"patch", "unpatch", "plan", "status", "verify", "repair", "patch-crossover", "unpatch-crossover",
+		"identify", "backups", "restore", "components", "components-check", "components-update", "components-use", "components-rollback",
+		"mod-profiles", "mod-profile-save", "mod-profile-apply", "mod-profile-delete", "mod-profile-launch"} {
+		fmt.Fprintf(w, "  %-20s %s\n", name, commands[name])
+	}
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Without a command the app starts normally.")
+}
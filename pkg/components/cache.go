@@ -0,0 +1,254 @@
+package components
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"turtlesilicon/pkg/assets"
+	"turtlesilicon/pkg/debug"
+)
+
+// SigningKey is the base64 encoded ed25519 public key component releases are signed with. Release
+// builds set it with -ldflags "-X turtlesilicon/pkg/components.SigningKey=...".
+var SigningKey = ""
+
+// cacheMetaFileName is stored next to every cached component file
+const cacheMetaFileName = "component.json"
+
+// CachedComponent is a verified component build in the local component cache
+type CachedComponent struct {
+	Name         string    `json:"name"`
+	Version      string    `json:"version"`
+	SHA256       string    `json:"sha256"`
+	Size         int64     `json:"size"`
+	DownloadedAt time.Time `json:"downloaded_at"`
+	Path         string    `json:"-"`
+}
+
+// cacheDir returns the directory downloaded components are kept in
+func cacheDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "TurtleSilicon", "components"), nil
+}
+
+// versionDir returns the cache directory of one component version
+func versionDir(name, version string) (string, error) {
+	dir, err := cacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, filepath.FromSlash(name), version), nil
+}
+
+// Download fetches a release, verifies its size, SHA-256 and detached signature and stores it in
+// the component cache. Nothing is written to the cache unless every check passes.
+func Download(name string, release Release) (*CachedComponent, error) {
+	if _, ok := assets.Lookup(name); !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownComponent, name)
+	}
+	if !validVersion.MatchString(release.Version) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidVersion, release.Version)
+	}
+	publicKey, err := signingKey()
+	if err != nil {
+		return nil, err
+	}
+
+	if cached, err := loadCached(name, release.Version); err == nil && cached.SHA256 == release.SHA256 {
+		debug.Printf("Component %s %s is already cached", name, release.Version)
+		return cached, nil
+	}
+
+	debug.Printf("Downloading component %s %s from %s", name, release.Version, release.URL)
+	content, err := fetch(release.URL)
+	if err != nil {
+		return nil, err
+	}
+	if release.Size > 0 && int64(len(content)) != release.Size {
+		return nil, fmt.Errorf("%w: %s %s is %d bytes, expected %d", ErrChecksumMismatch, name, release.Version, len(content), release.Size)
+	}
+	sum := sha256.Sum256(content)
+	hash := hex.EncodeToString(sum[:])
+	if !strings.EqualFold(hash, release.SHA256) {
+		return nil, fmt.Errorf("%w: %s %s has sha256 %s, expected %s", ErrChecksumMismatch, name, release.Version, hash, release.SHA256)
+	}
+
+	encodedSignature, err := fetch(release.SignatureURL)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifySignature(publicKey, name, release.Version, hash, encodedSignature); err != nil {
+		return nil, err
+	}
+
+	dir, err := versionDir(name, release.Version)
+	if err != nil {
+		return nil, err
+	}
+	cached := &CachedComponent{
+		Name:         name,
+		Version:      release.Version,
+		SHA256:       hash,
+		Size:         int64(len(content)),
+		DownloadedAt: time.Now(),
+		Path:         filepath.Join(dir, path.Base(name)),
+	}
+	if err := writeCached(dir, cached, content); err != nil {
+		return nil, err
+	}
+
+	debug.Printf("Cached component %s %s (sha256 %s)", name, release.Version, hash)
+	return cached, nil
+}
+
+// SignedMessage returns what the signature of a release covers. It binds the file digest to the
+// component name and version, so a signed build cannot be passed off as another component or
+// version.
+func SignedMessage(name, version, sha256Hash string) []byte {
+	return []byte(fmt.Sprintf("turtlesilicon-component\n%s\n%s\n%s\n", name, version, strings.ToLower(sha256Hash)))
+}
+
+// verifySignature checks a detached base64 signature of a release
+func verifySignature(publicKey ed25519.PublicKey, name, version, sha256Hash string, encodedSignature []byte) error {
+	signature, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(encodedSignature)))
+	if err != nil || !ed25519.Verify(publicKey, SignedMessage(name, version, sha256Hash), signature) {
+		return fmt.Errorf("%w: %s %s", ErrBadSignature, name, version)
+	}
+	return nil
+}
+
+// signingKey decodes SigningKey
+func signingKey() (ed25519.PublicKey, error) {
+	if SigningKey == "" {
+		return nil, ErrNoSigningKey
+	}
+	key, err := base64.StdEncoding.DecodeString(SigningKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid component signing key")
+	}
+	return ed25519.PublicKey(key), nil
+}
+
+// fetch downloads a small file into memory
+func fetch(url string) ([]byte, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download from %s: %v", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("server returned status %d for %s", resp.StatusCode, url)
+	}
+
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download from %s: %v", url, err)
+	}
+	return content, nil
+}
+
+// writeCached stores a verified component in a scratch directory first and then moves it into
+// place, so an interrupted download never leaves a half written version behind
+func writeCached(dir string, cached *CachedComponent, content []byte) error {
+	if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+		return fmt.Errorf("failed to create component cache: %v", err)
+	}
+	tempDir, err := os.MkdirTemp(filepath.Dir(dir), ".download-")
+	if err != nil {
+		return fmt.Errorf("failed to create component cache: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	if err := os.WriteFile(filepath.Join(tempDir, filepath.Base(cached.Path)), content, 0644); err != nil {
+		return fmt.Errorf("failed to write component: %v", err)
+	}
+	meta, err := json.MarshalIndent(cached, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode component metadata: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, cacheMetaFileName), meta, 0644); err != nil {
+		return fmt.Errorf("failed to write component metadata: %v", err)
+	}
+
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("failed to replace cached component: %v", err)
+	}
+	if err := os.Rename(tempDir, dir); err != nil {
+		return fmt.Errorf("failed to store component: %v", err)
+	}
+	return nil
+}
+
+// loadCached returns the metadata of a cached component version
+func loadCached(name, version string) (*CachedComponent, error) {
+	if !validVersion.MatchString(version) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidVersion, version)
+	}
+	dir, err := versionDir(name, version)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, cacheMetaFileName))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s %s", ErrNotCached, name, version)
+	}
+	var cached CachedComponent
+	if err := json.Unmarshal(data, &cached); err != nil {
+		return nil, fmt.Errorf("failed to parse component metadata for %s %s: %v", name, version, err)
+	}
+	if cached.Name != name || cached.Version != version {
+		return nil, fmt.Errorf("component metadata in %s does not match %s %s", dir, name, version)
+	}
+	cached.Path = filepath.Join(dir, path.Base(name))
+	return &cached, nil
+}
+
+// CachedVersions returns the cached builds of a component, newest first
+func CachedVersions(name string) []CachedComponent {
+	if _, ok := assets.Lookup(name); !ok {
+		return nil
+	}
+	dir, err := cacheDir()
+	if err != nil {
+		return nil
+	}
+	entries, err := os.ReadDir(filepath.Join(dir, filepath.FromSlash(name)))
+	if err != nil {
+		return nil
+	}
+
+	var versions []CachedComponent
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		cached, err := loadCached(name, entry.Name())
+		if err != nil {
+			debug.Printf("Ignoring cached component %s/%s: %v", name, entry.Name(), err)
+			continue
+		}
+		versions = append(versions, *cached)
+	}
+
+	sort.Slice(versions, func(i, j int) bool {
+		return CompareVersions(versions[i].Version, versions[j].Version) > 0
+	})
+	return versions
+}
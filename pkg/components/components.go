@@ -0,0 +1,139 @@
+// Package components manages updatable builds of the compatibility components patching installs
+// (winerosetta.dll, d3d9.dll, libSiliconPatch.dll, libDllLdr.dll and rosettax87). Every component
+// is bundled with the app and newer builds can be downloaded from a signed release index into a
+// local cache. Each game version chooses per component whether it uses the bundled build, the
+// newest cached build or a pinned version.
+package components
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"turtlesilicon/pkg/assets"
+	"turtlesilicon/pkg/utils"
+)
+
+// Special values for a requested component version
+const (
+	VersionBundled = "bundled" // The build embedded in the app
+	VersionLatest  = "latest"  // The newest cached build, or the bundled one if nothing is cached
+)
+
+// Resolved is the concrete component build patching installs
+type Resolved struct {
+	Name    string
+	Version string // VersionBundled or a release version
+	Size    int64
+	SHA256  string
+	path    string // Cache file, empty for the bundled build
+}
+
+// Bundled returns true if the resolved build is the one embedded in the app
+func (r Resolved) Bundled() bool {
+	return r.path == ""
+}
+
+// ReadFile returns the content of the resolved build. Cached builds are verified again so a file
+// changed in the cache is never installed.
+func (r Resolved) ReadFile() ([]byte, error) {
+	if r.Bundled() {
+		return assets.ReadFile(r.Name)
+	}
+
+	content, err := os.ReadFile(r.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cached component %s %s: %v", r.Name, r.Version, err)
+	}
+	sum := sha256.Sum256(content)
+	if hex.EncodeToString(sum[:]) != r.SHA256 {
+		return nil, fmt.Errorf("%w: cached %s %s was modified", ErrChecksumMismatch, r.Name, r.Version)
+	}
+	return content, nil
+}
+
+// Matches returns true if filePath holds exactly this build
+func (r Resolved) Matches(filePath string) bool {
+	return utils.FileMatchesDigest(filePath, r.Size, r.SHA256)
+}
+
+// String returns a short description like "winerosetta/d3d9.dll 1.2.0"
+func (r Resolved) String() string {
+	return r.Name + " " + r.Version
+}
+
+// Resolve picks the build of a component for a requested version. An empty request means
+// VersionBundled. Pinned versions must already be in the cache.
+func Resolve(name, requested string) (Resolved, error) {
+	asset, ok := assets.Lookup(name)
+	if !ok {
+		return Resolved{}, fmt.Errorf("%w: %s", ErrUnknownComponent, name)
+	}
+	bundled := Resolved{Name: name, Version: VersionBundled, Size: asset.Size, SHA256: asset.SHA256}
+
+	switch requested {
+	case "", VersionBundled:
+		return bundled, nil
+	case VersionLatest:
+		cached := CachedVersions(name)
+		if len(cached) == 0 {
+			return bundled, nil
+		}
+		return resolvedFromCache(&cached[0]), nil
+	}
+
+	cached, err := loadCached(name, requested)
+	if err != nil {
+		return Resolved{}, err
+	}
+	return resolvedFromCache(cached), nil
+}
+
+func resolvedFromCache(cached *CachedComponent) Resolved {
+	return Resolved{Name: cached.Name, Version: cached.Version, Size: cached.Size, SHA256: cached.SHA256, path: cached.Path}
+}
+
+// ResolveAll resolves every component for a game version's requested versions. Components that
+// cannot be resolved fall back to the bundled build and are reported in warnings.
+func ResolveAll(requested map[string]string) (map[string]Resolved, []string) {
+	resolved := make(map[string]Resolved)
+	var warnings []string
+	for _, name := range assets.Names() {
+		r, err := Resolve(name, requested[name])
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("Using the bundled %s: %v", name, err))
+			r, _ = Resolve(name, VersionBundled)
+		}
+		resolved[name] = r
+	}
+	return resolved, warnings
+}
+
+// IsComponentBuild returns true if a SHA-256 digest is the bundled or a cached build of any
+// component, i.e. a file patching installed rather than one that came with the game
+func IsComponentBuild(sha256Hash string) bool {
+	for _, name := range assets.Names() {
+		if asset, ok := assets.Lookup(name); ok && strings.EqualFold(asset.SHA256, sha256Hash) {
+			return true
+		}
+		for _, cached := range CachedVersions(name) {
+			if strings.EqualFold(cached.SHA256, sha256Hash) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// FullName turns a component name like "d3d9.dll" into its full name "winerosetta/d3d9.dll"
+func FullName(name string) (string, bool) {
+	for _, fullName := range assets.Names() {
+		if name == fullName || name == path.Base(fullName) {
+			return fullName, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,84 @@
+package components
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"testing"
+)
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.2.0", "1.2.0", 0},
+		{"1.2", "1.2.0", 0},
+		{"v1.2.0", "1.2.0", 0},
+		{"1.10.0", "1.9.0", 1},
+		{"1.2.0", "1.2.1", -1},
+		{"2", "1.99.99", 1},
+		{"1.2.0-beta", "1.2.0-alpha", 1},
+		{"1.2.1", "1.2.0-beta", 1},
+	}
+
+	for _, tt := range tests {
+		if got := CompareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("CompareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestVerifySignature(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const hash = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+	signature := base64.StdEncoding.EncodeToString(ed25519.Sign(privateKey, SignedMessage("winerosetta/d3d9.dll", "1.2.0", hash)))
+
+	tests := []struct {
+		name      string
+		component string
+		version   string
+		sha256    string
+		signature string
+		wantErr   bool
+	}{
+		{"signed release", "winerosetta/d3d9.dll", "1.2.0", hash, signature, false},
+		{"digest in upper case", "winerosetta/d3d9.dll", "1.2.0", "9F86D081884C7D659A2FEAA0C55AD015A3BF4F1B2B0B822CD15D6C15B0F00A08", signature + "\n", false},
+		{"older version", "winerosetta/d3d9.dll", "1.1.0", hash, signature, true},
+		{"other component", "winerosetta/winerosetta.dll", "1.2.0", hash, signature, true},
+		{"other file", "winerosetta/d3d9.dll", "1.2.0", "0" + hash[1:], signature, true},
+		{"not base64", "winerosetta/d3d9.dll", "1.2.0", hash, "not a signature", true},
+	}
+
+	for _, tt := range tests {
+		err := verifySignature(publicKey, tt.component, tt.version, tt.sha256, []byte(tt.signature))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: verifySignature() = %v, want error %v", tt.name, err, tt.wantErr)
+		}
+		if err != nil && !errors.Is(err, ErrBadSignature) {
+			t.Errorf("%s: verifySignature() = %v, want %v", tt.name, err, ErrBadSignature)
+		}
+	}
+}
+
+func TestSigningKey(t *testing.T) {
+	original := SigningKey
+	defer func() { SigningKey = original }()
+
+	SigningKey = ""
+	if _, err := signingKey(); !errors.Is(err, ErrNoSigningKey) {
+		t.Errorf("signingKey() without a key = %v, want %v", err, ErrNoSigningKey)
+	}
+	SigningKey = base64.StdEncoding.EncodeToString(make([]byte, ed25519.PublicKeySize))
+	if _, err := signingKey(); err != nil {
+		t.Errorf("signingKey() = %v, want no error", err)
+	}
+	SigningKey = "c2hvcnQ="
+	if _, err := signingKey(); err == nil {
+		t.Error("signingKey() with a short key succeeded")
+	}
+}
@@ -0,0 +1,114 @@
+package components
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// maxHistory is how many installed versions are remembered per component and game version
+const maxHistory = 10
+
+// installHistory records which component versions were installed into each game version, most
+// recent last. It is what rollback goes back through.
+type installHistory map[string]map[string][]string // version ID -> component -> versions
+
+func historyPath() (string, error) {
+	dir, err := cacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "history.json"), nil
+}
+
+func loadHistory() installHistory {
+	history := make(installHistory)
+	path, err := historyPath()
+	if err != nil {
+		return history
+	}
+	if data, err := os.ReadFile(path); err == nil {
+		json.Unmarshal(data, &history)
+	}
+	return history
+}
+
+func (h installHistory) save() error {
+	path, err := historyPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create component cache: %v", err)
+	}
+	data, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode component history: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to save component history: %v", err)
+	}
+	return nil
+}
+
+// RecordInstalled remembers the component versions a successful patch installed into a game version
+func RecordInstalled(versionID string, installed map[string]string) error {
+	history := loadHistory()
+	if history[versionID] == nil {
+		history[versionID] = make(map[string][]string)
+	}
+
+	changed := false
+	for name, version := range installed {
+		versions := history[versionID][name]
+		if len(versions) > 0 && versions[len(versions)-1] == version {
+			continue
+		}
+		versions = append(versions, version)
+		if len(versions) > maxHistory {
+			versions = versions[len(versions)-maxHistory:]
+		}
+		history[versionID][name] = versions
+		changed = true
+	}
+
+	if !changed {
+		return nil
+	}
+	return history.save()
+}
+
+// Installed returns the component versions last installed into a game version
+func Installed(versionID string) map[string]string {
+	installed := make(map[string]string)
+	for name, versions := range loadHistory()[versionID] {
+		if len(versions) > 0 {
+			installed[name] = versions[len(versions)-1]
+		}
+	}
+	return installed
+}
+
+// Rollback returns the version a component used before the current one for a game version and
+// drops the current one from the history. The caller pins the returned version and patches again.
+func Rollback(versionID, name string) (string, error) {
+	history := loadHistory()
+	versions := history[versionID][name]
+	if len(versions) < 2 {
+		return "", fmt.Errorf("no earlier version of %s was installed for %s", name, versionID)
+	}
+
+	previous := versions[len(versions)-2]
+	if previous != VersionBundled {
+		if _, err := loadCached(name, previous); err != nil {
+			return "", err
+		}
+	}
+
+	history[versionID][name] = versions[:len(versions)-1]
+	if err := history.save(); err != nil {
+		return "", err
+	}
+	return previous, nil
+}
@@ -0,0 +1,172 @@
+package components
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"turtlesilicon/pkg/assets"
+)
+
+// DefaultIndexURL is the release index checked for newer compatibility component builds
+const DefaultIndexURL = "https://raw.githubusercontent.com/tairasu/TurtleSilicon/main/components/index.json"
+
+var (
+	ErrUnknownComponent = errors.New("unknown component")
+	ErrInvalidVersion   = errors.New("invalid component version")
+	ErrChecksumMismatch = errors.New("checksum mismatch")
+	ErrBadSignature     = errors.New("signature verification failed")
+	ErrNoSigningKey     = errors.New("this build has no component signing key, component updates are disabled")
+	ErrNotCached        = errors.New("component version is not in the local cache")
+)
+
+// validVersion keeps release versions usable as a directory name
+var validVersion = regexp.MustCompile(`^[0-9A-Za-z][0-9A-Za-z._-]*$`)
+
+// ReleaseIndex lists the published builds of every updatable component
+type ReleaseIndex struct {
+	Components map[string][]Release `json:"components"` // Keyed by bundled asset name, e.g. "winerosetta/d3d9.dll"
+}
+
+// Release is a single published build of a component
+type Release struct {
+	Version      string    `json:"version"`
+	URL          string    `json:"url"`
+	SignatureURL string    `json:"signature_url"` // Detached base64 ed25519 signature of SignedMessage
+	SHA256       string    `json:"sha256"`
+	Size         int64     `json:"size"`
+	Published    time.Time `json:"published,omitempty"`
+	Notes        string    `json:"notes,omitempty"`
+}
+
+// ComponentUpdate is a component with a release newer than anything installed or cached
+type ComponentUpdate struct {
+	Name    string
+	Current string // Newest cached version, or VersionBundled
+	Release Release
+}
+
+// FetchIndex downloads and parses the release index
+func FetchIndex(url string) (*ReleaseIndex, error) {
+	if url == "" {
+		url = DefaultIndexURL
+	}
+
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download component index: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("server returned status %d for %s", resp.StatusCode, url)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read component index: %v", err)
+	}
+
+	var index ReleaseIndex
+	if err := json.Unmarshal(body, &index); err != nil {
+		return nil, fmt.Errorf("failed to parse component index: %v", err)
+	}
+
+	// Drop anything this app could not install so callers only see usable releases
+	for name, releases := range index.Components {
+		if _, ok := assets.Lookup(name); !ok {
+			delete(index.Components, name)
+			continue
+		}
+		var valid []Release
+		for _, release := range releases {
+			if validVersion.MatchString(release.Version) && release.URL != "" && release.SignatureURL != "" && release.SHA256 != "" {
+				valid = append(valid, release)
+			}
+		}
+		index.Components[name] = valid
+	}
+	return &index, nil
+}
+
+// Release returns a specific release of a component
+func (idx *ReleaseIndex) Release(name, version string) (Release, bool) {
+	for _, release := range idx.Components[name] {
+		if release.Version == version {
+			return release, true
+		}
+	}
+	return Release{}, false
+}
+
+// Latest returns the newest release of a component
+func (idx *ReleaseIndex) Latest(name string) (Release, bool) {
+	var latest Release
+	found := false
+	for _, release := range idx.Components[name] {
+		if !found || CompareVersions(release.Version, latest.Version) > 0 {
+			latest = release
+			found = true
+		}
+	}
+	return latest, found
+}
+
+// Updates returns the components whose newest release is not in the cache yet
+func (idx *ReleaseIndex) Updates() []ComponentUpdate {
+	var updates []ComponentUpdate
+	for _, name := range assets.Names() {
+		latest, ok := idx.Latest(name)
+		if !ok {
+			continue
+		}
+
+		current := VersionBundled
+		if cached := CachedVersions(name); len(cached) > 0 {
+			current = cached[0].Version
+			if CompareVersions(latest.Version, current) <= 0 {
+				continue
+			}
+		}
+		updates = append(updates, ComponentUpdate{Name: name, Current: current, Release: latest})
+	}
+	return updates
+}
+
+// CompareVersions compares dotted version strings numerically where possible and returns
+// -1, 0 or 1. Non numeric parts are compared as text.
+func CompareVersions(a, b string) int {
+	aParts := strings.Split(strings.TrimPrefix(a, "v"), ".")
+	bParts := strings.Split(strings.TrimPrefix(b, "v"), ".")
+	for i := 0; i < len(aParts) || i < len(bParts); i++ {
+		aPart, bPart := "0", "0"
+		if i < len(aParts) {
+			aPart = aParts[i]
+		}
+		if i < len(bParts) {
+			bPart = bParts[i]
+		}
+
+		aNum, aErr := strconv.Atoi(aPart)
+		bNum, bErr := strconv.Atoi(bPart)
+		if aErr == nil && bErr == nil {
+			if aNum != bNum {
+				if aNum < bNum {
+					return -1
+				}
+				return 1
+			}
+			continue
+		}
+		if cmp := strings.Compare(aPart, bPart); cmp != 0 {
+			return cmp
+		}
+	}
+	return 0
+}
@@ -0,0 +1,86 @@
+//go:build ignore
+
+// sign_release signs a compatibility component build for the release index. It writes the
+// detached signature next to the file and prints the index entry to publish.
+//
+//	go run sign_release.go -generate
+//	go run sign_release.go -key private.key -name winerosetta/d3d9.dll -version 1.2.0 \
+//		-url https://example.com/d3d9.dll d3d9.dll
+//
+// -generate prints a new key pair. The public key goes into COMPONENT_SIGNING_KEY of release
+// builds, the private key stays with the maintainers.
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"turtlesilicon/pkg/components"
+)
+
+func main() {
+	generate := flag.Bool("generate", false, "print a new signing key pair")
+	keyPath := flag.String("key", "", "file with the base64 ed25519 private key")
+	name := flag.String("name", "", "component name, e.g. winerosetta/d3d9.dll")
+	version := flag.String("version", "", "release version")
+	url := flag.String("url", "", "download URL of the release")
+	flag.Parse()
+
+	if *generate {
+		publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			log.Fatalf("failed to generate key: %v", err)
+		}
+		fmt.Printf("public:  %s\nprivate: %s\n", base64.StdEncoding.EncodeToString(publicKey), base64.StdEncoding.EncodeToString(privateKey))
+		return
+	}
+	if *keyPath == "" || *name == "" || *version == "" || *url == "" || flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	encodedKey, err := os.ReadFile(*keyPath)
+	if err != nil {
+		log.Fatalf("failed to read key: %v", err)
+	}
+	privateKey, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(encodedKey)))
+	if err != nil || len(privateKey) != ed25519.PrivateKeySize {
+		log.Fatalf("invalid private key in %s", *keyPath)
+	}
+
+	path := flag.Arg(0)
+	content, err := os.ReadFile(path)
+	if err != nil {
+		log.Fatalf("failed to read %s: %v", path, err)
+	}
+	sum := sha256.Sum256(content)
+	hash := hex.EncodeToString(sum[:])
+
+	signature := ed25519.Sign(ed25519.PrivateKey(privateKey), components.SignedMessage(*name, *version, hash))
+	if err := os.WriteFile(path+".sig", []byte(base64.StdEncoding.EncodeToString(signature)+"\n"), 0644); err != nil {
+		log.Fatalf("failed to write signature: %v", err)
+	}
+
+	entry, err := json.MarshalIndent(components.Release{
+		Version:      *version,
+		URL:          *url,
+		SignatureURL: *url + ".sig",
+		SHA256:       hash,
+		Size:         int64(len(content)),
+		Published:    time.Now().UTC(),
+	}, "", "  ")
+	if err != nil {
+		log.Fatalf("failed to encode index entry: %v", err)
+	}
+	fmt.Println(string(entry))
+}
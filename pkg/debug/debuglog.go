@@ -19,6 +19,7 @@ type DebugInfo struct {
 	PatchesAppliedCrossOver  bool
 	RosettaX87ServiceRunning bool
 	ServiceStarting          bool
+	PatchStatusReport        string // Per-component patch status, one component per line
 }
 
 // GameVersionInfo contains version-specific information
@@ -236,6 +237,12 @@ func GenerateDebugLog(debugInfo *DebugInfo, currentVersion *GameVersionInfo) str
 	log.WriteString(fmt.Sprintf("Patches Applied (CrossOver): %v\n", debugInfo.PatchesAppliedCrossOver))
 	log.WriteString(fmt.Sprintf("Rosetta x87 Service Running: %v\n", debugInfo.RosettaX87ServiceRunning))
 	log.WriteString(fmt.Sprintf("Service Starting: %v\n", debugInfo.ServiceStarting))
+	if debugInfo.PatchStatusReport != "" {
+		log.WriteString("Patch Components:\n")
+		for _, line := range strings.Split(debugInfo.PatchStatusReport, "\n") {
+			log.WriteString(fmt.Sprintf("  %s\n", line))
+		}
+	}
 
 	// === Launch Command Information ===
 	log.WriteString("\n=== Launch Command Information ===\n")
@@ -0,0 +1,277 @@
+// Package dllstxt reads and edits the dlls.txt file the game's DLL loader reads. The file lists one
+// DLL per line in load order. A line starting with # disables the DLL listed after it, any other
+// # line is a comment. Parsing and writing a file back is lossless: blank lines, comments, the
+// order of entries and the line ending are kept, and only the lines that were changed are
+// rewritten.
+package dllstxt
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// FileName is the name of the file in the game directory
+const FileName = "dlls.txt"
+
+// Line is one line of dlls.txt. Name is empty for blank lines and comments.
+type Line struct {
+	Text    string // Line as it is written to the file
+	Name    string // DLL path relative to the game directory
+	Enabled bool
+}
+
+// IsEntry returns true if the line lists a DLL, enabled or not
+func (l Line) IsEntry() bool {
+	return l.Name != ""
+}
+
+// File is the parsed content of a dlls.txt file
+type File struct {
+	Lines []Line
+
+	noFinalNewline bool
+	crlf           bool // Lines end with \r\n, as written by Windows editors
+}
+
+// ParseEntry splits the text of an entry into the DLL path and whether it is enabled. Name is ""
+// if the text is blank or a comment.
+func ParseEntry(text string) (name string, enabled bool) {
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" {
+		return "", false
+	}
+	if !strings.HasPrefix(trimmed, "#") {
+		return trimmed, true
+	}
+	rest := strings.TrimSpace(strings.TrimLeft(trimmed, "#"))
+	if strings.HasSuffix(strings.ToLower(rest), ".dll") && !strings.ContainsAny(rest, " \t") {
+		return rest, false
+	}
+	return "", false
+}
+
+// EntryText returns the line written for an entry
+func EntryText(name string, enabled bool) string {
+	if enabled {
+		return name
+	}
+	return "#" + name
+}
+
+// SameDLL returns true if two entries refer to the same file. Windows paths are matched without
+// regard to case or the kind of slash.
+func SameDLL(a, b string) bool {
+	normalize := func(name string) string {
+		return strings.ToLower(strings.ReplaceAll(name, "\\", "/"))
+	}
+	return normalize(a) == normalize(b)
+}
+
+// Parse parses the content of a dlls.txt file
+func Parse(content string) *File {
+	f := &File{}
+	if content == "" {
+		return f
+	}
+	f.noFinalNewline = !strings.HasSuffix(content, "\n")
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	f.crlf = strings.HasSuffix(lines[0], "\r")
+	for _, text := range lines {
+		if f.crlf {
+			text = strings.TrimSuffix(text, "\r")
+		}
+		name, enabled := ParseEntry(text)
+		f.Lines = append(f.Lines, Line{Text: text, Name: name, Enabled: enabled})
+	}
+	return f
+}
+
+// OnSave is called with the path of every file Save wrote, so the mod state auto-heal restores
+// follows the changes TurtleSilicon makes itself
+var OnSave func(path string)
+
+// Load reads a dlls.txt file. A missing file is returned as an empty file.
+func Load(path string) (*File, error) {
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &File{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", FileName, err)
+	}
+	return Parse(string(content)), nil
+}
+
+// Save writes the file to path. The content is written to a temporary file first and renamed
+// into place, so the game never reads a half written dlls.txt.
+func (f *File) Save(path string) error {
+	tempPath := path + ".tmp"
+	if err := os.WriteFile(tempPath, f.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %v", FileName, err)
+	}
+	if err := os.Rename(tempPath, path); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("failed to write %s: %v", FileName, err)
+	}
+	if OnSave != nil {
+		OnSave(path)
+	}
+	return nil
+}
+
+// String returns the content of the file
+func (f *File) String() string {
+	if len(f.Lines) == 0 {
+		return ""
+	}
+	newline := "\n"
+	if f.crlf {
+		newline = "\r\n"
+	}
+	var b strings.Builder
+	for i, line := range f.Lines {
+		b.WriteString(line.Text)
+		if i < len(f.Lines)-1 || !f.noFinalNewline {
+			b.WriteString(newline)
+		}
+	}
+	return b.String()
+}
+
+// Bytes returns the content of the file
+func (f *File) Bytes() []byte {
+	return []byte(f.String())
+}
+
+// Entries returns the lines that list a DLL, in load order
+func (f *File) Entries() []Line {
+	var entries []Line
+	for _, line := range f.Lines {
+		if line.IsEntry() {
+			entries = append(entries, line)
+		}
+	}
+	return entries
+}
+
+// Find returns the index in Lines of the first entry for name, or -1
+func (f *File) Find(name string) int {
+	for i, line := range f.Lines {
+		if line.IsEntry() && SameDLL(line.Name, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// State reports whether the DLL is listed and whether any of its entries is enabled
+func (f *File) State(name string) (listed bool, enabled bool) {
+	for _, line := range f.Lines {
+		if line.IsEntry() && SameDLL(line.Name, name) {
+			listed = true
+			enabled = enabled || line.Enabled
+		}
+	}
+	return listed, enabled
+}
+
+// IsEnabled returns true if the DLL is listed and enabled
+func (f *File) IsEnabled(name string) bool {
+	_, enabled := f.State(name)
+	return enabled
+}
+
+// Set enables or disables every entry for name, appending an entry if the DLL is not listed. It
+// returns true if the file changed.
+func (f *File) Set(name string, enabled bool) bool {
+	changed := false
+	listed := false
+	for i, line := range f.Lines {
+		if !line.IsEntry() || !SameDLL(line.Name, name) {
+			continue
+		}
+		listed = true
+		if line.Enabled != enabled {
+			f.Lines[i] = Line{Text: EntryText(line.Name, enabled), Name: line.Name, Enabled: enabled}
+			changed = true
+		}
+	}
+	if !listed {
+		f.append(Line{Text: EntryText(name, enabled), Name: name, Enabled: enabled})
+		changed = true
+	}
+	return changed
+}
+
+// Remove removes every entry for name, enabled or not. It returns true if the file changed.
+func (f *File) Remove(name string) bool {
+	kept := f.Lines[:0]
+	for _, line := range f.Lines {
+		if line.IsEntry() && SameDLL(line.Name, name) {
+			continue
+		}
+		kept = append(kept, line)
+	}
+	changed := len(kept) != len(f.Lines)
+	f.Lines = kept
+	return changed
+}
+
+// Reorder puts the entries for names in the given order. The reordered entries take the places
+// the entries for these names had before, so comments, blank lines and other entries stay where
+// they are. Names that are not listed are ignored.
+func (f *File) Reorder(names []string) {
+	var slots []int
+	for i, line := range f.Lines {
+		if line.IsEntry() && indexOfDLL(names, line.Name) >= 0 {
+			slots = append(slots, i)
+		}
+	}
+
+	ordered := make([]Line, 0, len(slots))
+	for _, name := range names {
+		for _, slot := range slots {
+			if SameDLL(f.Lines[slot].Name, name) {
+				ordered = append(ordered, f.Lines[slot])
+			}
+		}
+	}
+	if len(ordered) != len(slots) {
+		// names lists a DLL twice, leave the file alone
+		return
+	}
+	for i, slot := range slots {
+		f.Lines[slot] = ordered[i]
+	}
+}
+
+// Duplicates returns the DLLs that are listed more than once, in the order of their first entry
+func (f *File) Duplicates() []string {
+	var seen, duplicates []string
+	for _, line := range f.Lines {
+		if !line.IsEntry() {
+			continue
+		}
+		if indexOfDLL(seen, line.Name) < 0 {
+			seen = append(seen, line.Name)
+		} else if indexOfDLL(duplicates, line.Name) < 0 {
+			duplicates = append(duplicates, line.Name)
+		}
+	}
+	return duplicates
+}
+
+func (f *File) append(line Line) {
+	f.Lines = append(f.Lines, line)
+	f.noFinalNewline = false
+}
+
+func indexOfDLL(names []string, name string) int {
+	for i, existing := range names {
+		if SameDLL(existing, name) {
+			return i
+		}
+	}
+	return -1
+}
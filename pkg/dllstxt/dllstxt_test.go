@@ -0,0 +1,158 @@
+package dllstxt
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseEntry(t *testing.T) {
+	tests := []struct {
+		text        string
+		wantName    string
+		wantEnabled bool
+	}{
+		{"mods/foo.dll", "mods/foo.dll", true},
+		{"  mods/foo.dll  ", "mods/foo.dll", true},
+		{"#mods/foo.dll", "mods/foo.dll", false},
+		{"## mods/foo.DLL", "mods/foo.DLL", false},
+		{"# load these first", "", false},
+		{"# see readme.dll", "", false},
+		{"", "", false},
+		{"   ", "", false},
+	}
+
+	for _, tt := range tests {
+		name, enabled := ParseEntry(tt.text)
+		if name != tt.wantName || enabled != tt.wantEnabled {
+			t.Errorf("ParseEntry(%q) = %q, %v, want %q, %v", tt.text, name, enabled, tt.wantName, tt.wantEnabled)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	tests := []string{
+		"",
+		"winerosetta.dll\n",
+		"winerosetta.dll",
+		"# comment\n\nwinerosetta.dll\n#mods/foo.dll\n",
+		"# comment\r\n\r\nwinerosetta.dll\r\n#mods/foo.dll\r\n",
+		"winerosetta.dll\r\nmods/foo.dll",
+	}
+
+	for _, content := range tests {
+		if got := Parse(content).String(); got != content {
+			t.Errorf("Parse(%q).String() = %q, want it unchanged", content, got)
+		}
+	}
+}
+
+func TestSet(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		dll         string
+		enabled     bool
+		want        string
+		wantChanged bool
+	}{
+		{"enable listed", "#mods/foo.dll\n", "mods/foo.dll", true, "mods/foo.dll\n", true},
+		{"disable every entry", "mods/foo.dll\n# keep\nMODS\\FOO.dll\n", "mods/foo.dll", false, "#mods/foo.dll\n# keep\n#MODS\\FOO.dll\n", true},
+		{"unchanged", "mods/foo.dll\n", "mods/foo.dll", true, "mods/foo.dll\n", false},
+		{"append", "winerosetta.dll\n", "mods/foo.dll", true, "winerosetta.dll\nmods/foo.dll\n", true},
+		{"append without final newline", "winerosetta.dll", "mods/foo.dll", false, "winerosetta.dll\n#mods/foo.dll\n", true},
+		{"append to empty file", "", "mods/foo.dll", true, "mods/foo.dll\n", true},
+		{"CRLF toggle", "winerosetta.dll\r\n#mods/foo.dll\r\n", "mods/foo.dll", true, "winerosetta.dll\r\nmods/foo.dll\r\n", true},
+		{"CRLF append", "winerosetta.dll\r\n", "mods/foo.dll", true, "winerosetta.dll\r\nmods/foo.dll\r\n", true},
+		{"single line without newline appends LF", "winerosetta.dll", "mods/foo.dll", true, "winerosetta.dll\nmods/foo.dll\n", true},
+	}
+
+	for _, tt := range tests {
+		f := Parse(tt.content)
+		changed := f.Set(tt.dll, tt.enabled)
+		if got := f.String(); got != tt.want || changed != tt.wantChanged {
+			t.Errorf("%s: Set() = %q, changed %v, want %q, changed %v", tt.name, got, changed, tt.want, tt.wantChanged)
+		}
+		if f.IsEnabled(tt.dll) != tt.enabled {
+			t.Errorf("%s: IsEnabled() = %v after Set(%v)", tt.name, !tt.enabled, tt.enabled)
+		}
+	}
+}
+
+func TestRemove(t *testing.T) {
+	f := Parse("# mods\r\nmods/foo.dll\r\n#mods/FOO.dll\r\nmods/bar.dll\r\n")
+	if !f.Remove("mods/foo.dll") {
+		t.Errorf("Remove() = false, want true")
+	}
+	if f.Remove("mods/foo.dll") {
+		t.Errorf("Remove() of a DLL that is not listed = true, want false")
+	}
+	if got, want := f.String(), "# mods\r\nmods/bar.dll\r\n"; got != want {
+		t.Errorf("String() after Remove() = %q, want %q", got, want)
+	}
+}
+
+func TestReorder(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		order   []string
+		want    string
+	}{
+		{
+			"keeps comments in place",
+			"a.dll\n# comment\nb.dll\nc.dll\n",
+			[]string{"c.dll", "a.dll", "b.dll"},
+			"c.dll\n# comment\na.dll\nb.dll\n",
+		},
+		{
+			"other entries stay",
+			"a.dll\nwinerosetta.dll\n#b.dll\n",
+			[]string{"b.dll", "a.dll"},
+			"#b.dll\nwinerosetta.dll\na.dll\n",
+		},
+		{
+			"unlisted names are ignored",
+			"a.dll\nb.dll\n",
+			[]string{"missing.dll", "b.dll", "a.dll"},
+			"b.dll\na.dll\n",
+		},
+		{
+			"names listed twice leave the file alone",
+			"a.dll\nb.dll\n",
+			[]string{"b.dll", "a.dll", "B.DLL"},
+			"a.dll\nb.dll\n",
+		},
+		{
+			"CRLF",
+			"a.dll\r\nb.dll\r\n",
+			[]string{"b.dll", "a.dll"},
+			"b.dll\r\na.dll\r\n",
+		},
+	}
+
+	for _, tt := range tests {
+		f := Parse(tt.content)
+		f.Reorder(tt.order)
+		if got := f.String(); got != tt.want {
+			t.Errorf("%s: Reorder() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDuplicates(t *testing.T) {
+	tests := []struct {
+		content string
+		want    []string
+	}{
+		{"a.dll\nb.dll\n", nil},
+		{"a.dll\n#A.DLL\nb.dll\nb.dll\nb.dll\n", []string{"A.DLL", "b.dll"}},
+		{"mods\\c.dll\r\n# mods/c.dll is here twice\r\nmods/c.dll\r\n", []string{"mods/c.dll"}},
+	}
+
+	for _, tt := range tests {
+		got := Parse(tt.content).Duplicates()
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("Duplicates() of %q = %v, want %v", tt.content, got, tt.want)
+		}
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	"turtlesilicon/pkg/backup"
 	"turtlesilicon/pkg/debug"
 )
 
@@ -28,6 +29,24 @@ func (pw *ProgressWriter) Write(p []byte) (n int, err error) {
 	return
 }
 
+// backupBeforeDownload keeps the file being replaced in the backup store. Data archives are left
+// out, they are several GB each and can be downloaded again.
+func backupBeforeDownload(gamePath string, file RequiredFile) error {
+	if isDataArchive(file.RelativePath) {
+		debug.Printf("Not backing up data archive %s", file.RelativePath)
+		return nil
+	}
+	if err := backup.SaveFile(gamePath, filepath.Join(gamePath, file.RelativePath), backup.OpEpochUpdate); err != nil {
+		return fmt.Errorf("failed to back up %s: %v", file.RelativePath, err)
+	}
+	return nil
+}
+
+// isDataArchive returns true for the MPQ archives of the game data
+func isDataArchive(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".mpq")
+}
+
 // downloadFile downloads a single file to the correct location (legacy method)
 func downloadFile(gamePath string, file RequiredFile) error {
 	fullPath := filepath.Join(gamePath, file.RelativePath)
@@ -38,6 +57,10 @@ func downloadFile(gamePath string, file RequiredFile) error {
 		return fmt.Errorf("failed to create directory %s: %v", dir, err)
 	}
 
+	if err := backupBeforeDownload(gamePath, file); err != nil {
+		return err
+	}
+
 	// Download file
 	resp, err := http.Get(file.DownloadURL)
 	if err != nil {
@@ -76,6 +99,10 @@ func downloadFileWithProgress(gamePath string, file RequiredFile, onProgress fun
 		return fmt.Errorf("failed to create directory %s: %v", dir, err)
 	}
 
+	if err := backupBeforeDownload(gamePath, file); err != nil {
+		return err
+	}
+
 	// Download file
 	resp, err := http.Get(file.DownloadURL)
 	if err != nil {
@@ -168,6 +195,10 @@ func downloadFileWithVerification(gamePath string, file RequiredFile, onProgress
 		return fmt.Errorf("failed to create directory %s: %v", dir, err)
 	}
 
+	if err := backupBeforeDownload(gamePath, file); err != nil {
+		return err
+	}
+
 	// Try CDNs in order of preference until one succeeds
 	cdnPriority := strings.Split(CDNPriority, ",")
 	var lastErr error
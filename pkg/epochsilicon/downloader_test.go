@@ -0,0 +1,22 @@
+package epochsilicon
+
+import "testing"
+
+func TestIsDataArchive(t *testing.T) {
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"Data/patch-A.MPQ", true},
+		{"Data/enUS/patch-enUS-3.mpq", true},
+		{"Project-Epoch.exe", false},
+		{"Data/realmlist.wtf", false},
+		{"mpq", false},
+	}
+
+	for _, tt := range tests {
+		if got := isDataArchive(tt.path); got != tt.want {
+			t.Errorf("isDataArchive(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,282 @@
+// Package fingerprint identifies game executables against a bundled database of known builds,
+// so patching can tell a supported client apart from an unknown or wrong one. Patched
+// executables generated by TurtleSilicon are added to a local database as they are created.
+// Digests of verified builds are added to known_executables.json from the output of
+// "TurtleSilicon identify".
+package fingerprint
+
+import (
+	"bytes"
+	"crypto/sha256"
+	_ "embed"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"turtlesilicon/pkg/debug"
+)
+
+//go:embed known_executables.json
+var bundledDatabase []byte
+
+// ErrNotExecutable is returned for files that are not Windows executables
+var ErrNotExecutable = errors.New("not a Windows executable")
+
+// Kinds of known builds
+const (
+	KindStock     = "stock"     // Unmodified Blizzard client
+	KindTurtle    = "turtle"    // Turtle WoW client
+	KindAscension = "ascension" // Ascension client
+	KindPatched   = "patched"   // Output of the libDllLdr patch
+)
+
+// Build is a known executable. Only the variants with the SHA256 digests are known, an executable
+// that merely has the file version of a build resembles it and counts as unknown.
+type Build struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Kind        string   `json:"kind"`
+	VersionIDs  []string `json:"version_ids"` // Game versions the build is a client for
+	Executable  string   `json:"executable"`
+	FileVersion string   `json:"file_version"`
+	SHA256      []string `json:"sha256,omitempty"`
+}
+
+// ForVersion returns true if the build is a client for the game version
+func (b *Build) ForVersion(versionID string) bool {
+	for _, id := range b.VersionIDs {
+		if id == versionID {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *Build) hasDigest(hash string) bool {
+	for _, digest := range b.SHA256 {
+		if strings.EqualFold(digest, hash) {
+			return true
+		}
+	}
+	return false
+}
+
+type database struct {
+	Builds []Build `json:"builds"`
+}
+
+// MatchState describes how an executable relates to the known builds
+type MatchState int
+
+const (
+	MatchKnown        MatchState = iota // A known build of the game version
+	MatchResembles                      // Unknown, but has the file version of a build of the game version
+	MatchOtherVersion                   // A known build of a different game version
+	MatchUnknown                        // Nothing known matches
+)
+
+// Identification is the fingerprint of an executable and the known build it matches
+type Identification struct {
+	Path        string
+	Size        int64
+	SHA256      string
+	FileVersion string // From the version resource, e.g. "3.3.5.12340"; empty if there is none
+	State       MatchState
+	Build       *Build // Matched or resembled build, nil for MatchUnknown
+}
+
+// Describe returns a one line explanation of the match for warnings and errors
+func (id *Identification) Describe() string {
+	name := filepath.Base(id.Path)
+	switch id.State {
+	case MatchKnown:
+		return fmt.Sprintf("%s is a known build: %s", name, id.Build.Name)
+	case MatchResembles:
+		return fmt.Sprintf("%s is not a known build, it has the file version of %s but its contents differ", name, id.Build.Name)
+	case MatchOtherVersion:
+		return fmt.Sprintf("%s is %s, which is a client for %s", name, id.Build.Name, strings.Join(id.Build.VersionIDs, ", "))
+	}
+	if id.FileVersion == "" {
+		return fmt.Sprintf("%s is not a known build and has no version information", name)
+	}
+	return fmt.Sprintf("%s is not a known build (file version %s)", name, id.FileVersion)
+}
+
+// Identify fingerprints an executable and looks it up for a game version
+func Identify(path string, versionID string) (*Identification, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(content) < 2 || content[0] != 'M' || content[1] != 'Z' {
+		return nil, fmt.Errorf("%w: %s", ErrNotExecutable, filepath.Base(path))
+	}
+
+	sum := sha256.Sum256(content)
+	id := &Identification{
+		Path:        path,
+		Size:        int64(len(content)),
+		SHA256:      hex.EncodeToString(sum[:]),
+		FileVersion: fileVersion(content),
+	}
+	id.State, id.Build = lookup(knownBuilds(), id, versionID)
+	debug.Printf("Fingerprint of %s: sha256 %s, file version %q: %s", path, id.SHA256, id.FileVersion, id.Describe())
+	return id, nil
+}
+
+// lookup matches exact digests, preferring builds of the game version. The file version is only
+// used to name what an unknown executable resembles.
+func lookup(builds []Build, id *Identification, versionID string) (MatchState, *Build) {
+	var other *Build
+	for i := range builds {
+		build := &builds[i]
+		if !build.hasDigest(id.SHA256) {
+			continue
+		}
+		if build.ForVersion(versionID) {
+			return MatchKnown, build
+		}
+		if other == nil {
+			other = build
+		}
+	}
+	if other != nil {
+		return MatchOtherVersion, other
+	}
+	if id.FileVersion == "" {
+		return MatchUnknown, nil
+	}
+
+	for i := range builds {
+		build := &builds[i]
+		if build.FileVersion != id.FileVersion {
+			continue
+		}
+		if build.ForVersion(versionID) {
+			return MatchResembles, build
+		}
+		if other == nil {
+			other = build
+		}
+	}
+	if other != nil {
+		return MatchOtherVersion, other
+	}
+	return MatchUnknown, nil
+}
+
+// fileVersion reads the file version from the VS_FIXEDFILEINFO of the version resource
+func fileVersion(content []byte) string {
+	signature := []byte{0xBD, 0x04, 0xEF, 0xFE}
+	offset := bytes.Index(content, signature)
+	if offset < 0 || offset+16 > len(content) {
+		return ""
+	}
+	ms := binary.LittleEndian.Uint32(content[offset+8:])
+	ls := binary.LittleEndian.Uint32(content[offset+12:])
+	return fmt.Sprintf("%d.%d.%d.%d", ms>>16, ms&0xFFFF, ls>>16, ls&0xFFFF)
+}
+
+// HasDigests returns true if the bundled database has digests of a client for the game version.
+// Without them every executable of the version is unknown, so it is not worth a warning.
+func HasDigests(versionID string) bool {
+	return hasDigests(bundledBuilds(), versionID)
+}
+
+func hasDigests(builds []Build, versionID string) bool {
+	for _, build := range builds {
+		if build.ForVersion(versionID) && build.Kind != KindPatched && len(build.SHA256) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// knownBuilds returns the bundled builds followed by the locally recorded ones
+func knownBuilds() []Build {
+	return append(bundledBuilds(), loadLocal().Builds...)
+}
+
+func bundledBuilds() []Build {
+	var bundled database
+	if err := json.Unmarshal(bundledDatabase, &bundled); err != nil {
+		debug.Printf("Failed to parse the bundled executable database: %v", err)
+	}
+	return bundled.Builds
+}
+
+func localDatabasePath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "TurtleSilicon", "known_executables.json"), nil
+}
+
+func loadLocal() database {
+	var local database
+	path, err := localDatabasePath()
+	if err != nil {
+		return local
+	}
+	if data, err := os.ReadFile(path); err == nil {
+		if err := json.Unmarshal(data, &local); err != nil {
+			debug.Printf("Failed to parse %s: %v", path, err)
+		}
+	}
+	return local
+}
+
+// RecordPatched adds a patched executable generated from source to the local database, so it is
+// recognized as a patched output later
+func RecordPatched(versionID string, patchedPath string, source *Identification) error {
+	content, err := os.ReadFile(patchedPath)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %v", filepath.Base(patchedPath), err)
+	}
+	sum := sha256.Sum256(content)
+	hash := hex.EncodeToString(sum[:])
+
+	local := loadLocal()
+	for _, build := range local.Builds {
+		if build.hasDigest(hash) {
+			return nil
+		}
+	}
+
+	name := "Patched " + filepath.Base(source.Path)
+	if source.Build != nil {
+		name = "Patched " + source.Build.Name
+	}
+	local.Builds = append(local.Builds, Build{
+		ID:          "patched-" + hash[:12],
+		Name:        name,
+		Kind:        KindPatched,
+		VersionIDs:  []string{versionID},
+		Executable:  filepath.Base(patchedPath),
+		FileVersion: source.FileVersion,
+		SHA256:      []string{hash},
+	})
+
+	path, err := localDatabasePath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %v", err)
+	}
+	data, err := json.MarshalIndent(local, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode executable database: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to save executable database: %v", err)
+	}
+	debug.Printf("Recorded patched executable %s (sha256 %s)", patchedPath, hash)
+	return nil
+}
@@ -0,0 +1,97 @@
+package fingerprint
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+)
+
+func TestLookup(t *testing.T) {
+	builds := []Build{
+		{ID: "vanilla", VersionIDs: []string{"vanillasilicon"}, FileVersion: "1.12.1.5875", SHA256: []string{"aa11"}},
+		{ID: "turtle", VersionIDs: []string{"turtlesilicon"}, FileVersion: "1.12.1.5875", SHA256: []string{"bb22"}},
+		{ID: "tbc", VersionIDs: []string{"burningsilicon"}, FileVersion: "2.4.3.8606"},
+		{ID: "patched", Kind: KindPatched, VersionIDs: []string{"turtlesilicon"}, FileVersion: "1.12.1.5875", SHA256: []string{"cc33"}},
+	}
+
+	tests := []struct {
+		name        string
+		sha256      string
+		fileVersion string
+		versionID   string
+		wantState   MatchState
+		wantBuild   string
+	}{
+		{"known digest", "bb22", "1.12.1.5875", "turtlesilicon", MatchKnown, "turtle"},
+		{"digest ignores case", "BB22", "", "turtlesilicon", MatchKnown, "turtle"},
+		{"patched output", "cc33", "1.12.1.5875", "turtlesilicon", MatchKnown, "patched"},
+		{"digest of another version", "aa11", "1.12.1.5875", "turtlesilicon", MatchOtherVersion, "vanilla"},
+		{"file version only", "ffff", "1.12.1.5875", "turtlesilicon", MatchResembles, "turtle"},
+		{"file version of a build without digests", "ffff", "2.4.3.8606", "burningsilicon", MatchResembles, "tbc"},
+		{"file version of another version", "ffff", "2.4.3.8606", "turtlesilicon", MatchOtherVersion, "tbc"},
+		{"unknown file version", "ffff", "1.0.0.1", "turtlesilicon", MatchUnknown, ""},
+		{"no version information", "ffff", "", "turtlesilicon", MatchUnknown, ""},
+	}
+
+	for _, tt := range tests {
+		id := &Identification{SHA256: tt.sha256, FileVersion: tt.fileVersion}
+		state, build := lookup(builds, id, tt.versionID)
+		buildID := ""
+		if build != nil {
+			buildID = build.ID
+		}
+		if state != tt.wantState || buildID != tt.wantBuild {
+			t.Errorf("%s: lookup() = %v, %q, want %v, %q", tt.name, state, buildID, tt.wantState, tt.wantBuild)
+		}
+	}
+}
+
+func TestHasDigests(t *testing.T) {
+	builds := []Build{
+		{ID: "turtle", VersionIDs: []string{"turtlesilicon"}, SHA256: []string{"bb22"}},
+		{ID: "tbc", VersionIDs: []string{"burningsilicon"}},
+		{ID: "patched", Kind: KindPatched, VersionIDs: []string{"vanillasilicon"}, SHA256: []string{"cc33"}},
+	}
+
+	tests := []struct {
+		versionID string
+		want      bool
+	}{
+		{"turtlesilicon", true},
+		{"burningsilicon", false},
+		{"vanillasilicon", false}, // Patched outputs do not identify clients
+		{"wrathsilicon", false},
+	}
+
+	for _, tt := range tests {
+		if got := hasDigests(builds, tt.versionID); got != tt.want {
+			t.Errorf("hasDigests(%s) = %v, want %v", tt.versionID, got, tt.want)
+		}
+	}
+}
+
+func TestFileVersion(t *testing.T) {
+	content := make([]byte, 64)
+	copy(content[20:], []byte{0xBD, 0x04, 0xEF, 0xFE})
+	binary.LittleEndian.PutUint32(content[28:], 3<<16|3)
+	binary.LittleEndian.PutUint32(content[32:], 5<<16|12340)
+
+	if got := fileVersion(content); got != "3.3.5.12340" {
+		t.Errorf("fileVersion() = %q, want %q", got, "3.3.5.12340")
+	}
+	if got := fileVersion(make([]byte, 64)); got != "" {
+		t.Errorf("fileVersion() without a version resource = %q, want empty", got)
+	}
+}
+
+func TestBundledDatabase(t *testing.T) {
+	var bundled database
+	if err := json.Unmarshal(bundledDatabase, &bundled); err != nil {
+		t.Fatalf("Failed to parse the bundled database: %v", err)
+	}
+	for _, build := range bundled.Builds {
+		if build.ID == "" || build.FileVersion == "" || len(build.VersionIDs) == 0 {
+			t.Errorf("Incomplete bundled build %+v", build)
+		}
+	}
+}
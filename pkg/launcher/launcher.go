@@ -14,6 +14,7 @@ import (
 	"turtlesilicon/pkg/paths" // Corrected import path
 	"turtlesilicon/pkg/utils" // Corrected import path
 	"turtlesilicon/pkg/version"
+	"turtlesilicon/pkg/wine"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/dialog"
@@ -24,6 +25,34 @@ var CustomEnvVars = ""          // Custom environment variables
 var EnableVanillaTweaks = false // Default to disabled
 var AutoDeleteWdb = true        // Default to enabled
 
+// GameRuntime is the Wine runtime LaunchGame uses, nil means CrossOver at paths.CrossoverPath
+var GameRuntime wine.Runtime
+
+func gameRuntime() wine.Runtime {
+	if GameRuntime != nil {
+		return GameRuntime
+	}
+	return wine.NewCrossOver(paths.CrossoverPath)
+}
+
+// launchEnv returns the environment variables of a launch command line: the custom ones, those
+// of the Wine runtime and the ones the graphics setup needs
+func launchEnv(runtime wine.Runtime, customEnvVars string, enableMetalHud bool) string {
+	mtlHudValue := "0"
+	if enableMetalHud {
+		mtlHudValue = "1"
+	}
+
+	envVars := fmt.Sprintf(`WINEDLLOVERRIDES="d3d9=n,b" MTL_HUD_ENABLED=%s MVK_CONFIG_SYNCHRONOUS_QUEUE_SUBMITS=1 DXVK_ASYNC=1`, mtlHudValue)
+	if runtimeEnv := wine.EnvString(runtime); runtimeEnv != "" {
+		envVars = runtimeEnv + " " + envVars
+	}
+	if customEnvVars != "" {
+		envVars = customEnvVars + " " + envVars
+	}
+	return envVars
+}
+
 // UI update callback for triggering status updates from launcher
 var uiUpdateCallback func()
 
@@ -115,8 +144,8 @@ func runGameIntegrated(parentWindow fyne.Window, shellCmd string) error {
 func LaunchGame(myWindow fyne.Window) {
 	debug.Println("Launch Game button clicked")
 
-	if paths.CrossoverPath == "" {
-		dialog.ShowError(fmt.Errorf("CrossOver path not set. Please set it in the patcher."), myWindow)
+	if runtime := gameRuntime(); runtime.Path() == "" {
+		dialog.ShowError(fmt.Errorf("%s path not set. Please set it in the patcher.", runtime.Name()), myWindow)
 		return
 	}
 	if paths.TurtlewowPath == "" {
@@ -152,25 +181,19 @@ func LaunchGame(myWindow fyne.Window) {
 	debug.Println("Preparing to launch TurtleSilicon...")
 
 	// Determine which WoW executable to use based on vanilla-tweaks preference
-	var wowExePath string
 	if EnableVanillaTweaks {
-		if !CheckForWoWTweakedExecutable() {
-			// Show dialog asking if user wants us to apply vanilla-tweaks
-			HandleVanillaTweaksRequest(myWindow, func() {
-				// After successful patching, continue with launch using the tweaked executable
-				wowTweakedExePath := GetWoWTweakedExecutablePath()
-				if wowTweakedExePath != "" {
-					continueLaunch(myWindow, wowTweakedExePath)
-				} else {
-					dialog.ShowError(fmt.Errorf("failed to find WoW-tweaked.exe after patching"), myWindow)
-				}
-			})
-			return // Exit early since dialog will handle the continuation
-		}
-		wowExePath = GetWoWTweakedExecutablePath()
-	} else {
-		wowExePath = filepath.Join(paths.TurtlewowPath, "WoW.exe")
+		// Regenerate WoW_tweaked.exe if the tweak selection or WoW.exe changed
+		HandleVanillaTweaksRequest(myWindow, func() {
+			wowTweakedExePath := GetWoWTweakedExecutablePath()
+			if wowTweakedExePath != "" {
+				continueLaunch(myWindow, wowTweakedExePath)
+			} else {
+				dialog.ShowError(fmt.Errorf("failed to find WoW_tweaked.exe after patching"), myWindow)
+			}
+		})
+		return
 	}
+	wowExePath := filepath.Join(paths.TurtlewowPath, "WoW.exe")
 
 	// Continue with normal launch process
 	continueLaunch(myWindow, wowExePath)
@@ -180,14 +203,14 @@ func LaunchGame(myWindow fyne.Window) {
 func continueLaunch(myWindow fyne.Window, wowExePath string) {
 	rosettaInTurtlePath := filepath.Join(paths.TurtlewowPath, "rosettax87")
 	rosettaExecutable := filepath.Join(rosettaInTurtlePath, "rosettax87")
-	wineloader2Path := filepath.Join(paths.CrossoverPath, "Contents", "SharedSupport", "CrossOver", "CrossOver-Hosted Application", "wineloader2")
+	runtime := gameRuntime()
 
 	if !utils.PathExists(rosettaExecutable) {
 		dialog.ShowError(fmt.Errorf("rosetta executable not found at %s. Ensure TurtleWoW patching was successful", rosettaExecutable), myWindow)
 		return
 	}
-	if !utils.PathExists(wineloader2Path) {
-		dialog.ShowError(fmt.Errorf("patched wineloader2 not found at %s. Ensure CrossOver patching was successful", wineloader2Path), myWindow)
+	if err := wine.Ready(runtime); err != nil {
+		dialog.ShowError(fmt.Errorf("%s cannot launch the game: %v", runtime.Name(), err), myWindow)
 		return
 	}
 	if !utils.PathExists(wowExePath) {
@@ -203,27 +226,19 @@ func continueLaunch(myWindow fyne.Window, wowExePath string) {
 	// Launch WoW using direct rosettax87 execution (no service required)
 	debug.Println("Launching WoW with direct rosettax87 execution.")
 
-	if paths.CrossoverPath == "" || paths.TurtlewowPath == "" {
-		dialog.ShowError(fmt.Errorf("CrossOver path or game path is not set. Cannot launch WoW."), myWindow)
+	if runtime.Path() == "" || paths.TurtlewowPath == "" {
+		dialog.ShowError(fmt.Errorf("%s path or game path is not set. Cannot launch WoW.", runtime.Name()), myWindow)
 		return
 	}
 
-	mtlHudValue := "0"
-	if EnableMetalHud {
-		mtlHudValue = "1"
-	}
-
 	// Prepare environment variables
-	envVars := fmt.Sprintf(`WINEDLLOVERRIDES="d3d9=n,b" MTL_HUD_ENABLED=%s MVK_CONFIG_SYNCHRONOUS_QUEUE_SUBMITS=1 DXVK_ASYNC=1`, mtlHudValue)
-	if CustomEnvVars != "" {
-		envVars = CustomEnvVars + " " + envVars
-	}
+	envVars := launchEnv(runtime, CustomEnvVars, EnableMetalHud)
 
 	shellCmd := fmt.Sprintf(`cd %s && %s %s %s %s`,
 		utils.QuotePathForShell(paths.TurtlewowPath),
 		envVars,
 		utils.QuotePathForShell(rosettaExecutable),
-		utils.QuotePathForShell(wineloader2Path),
+		utils.QuotePathForShell(runtime.LoaderPath()),
 		utils.QuotePathForShell(wowExePath))
 
 	// Check user preference for terminal display
@@ -235,7 +250,8 @@ func continueLaunch(myWindow fyne.Window, wowExePath string) {
 		cmd2Script := fmt.Sprintf("tell application \"Terminal\" to do script \"%s\"", escapedShellCmd)
 
 		debug.Println("Executing WoW launch command via AppleScript...")
-		if !utils.RunOsascript(cmd2Script, myWindow) {
+		if err := utils.RunOsascript(cmd2Script); err != nil {
+			dialog.ShowError(err, myWindow)
 			return
 		}
 
@@ -338,6 +354,14 @@ func verifyPatchStatusBeforeLaunch(myWindow fyne.Window) bool {
 	// Check if patches are still applied
 	patchesStillValid := patching.CheckVersionPatchingStatus(currentVer.GamePath, currentVer.UsesRosettaPatching, currentVer.UsesDivxDecoderPatch, currentVer.ID)
 
+	if !patchesStillValid && currentVer.Settings.AutoRepatchAfterUpdate {
+		debug.Println("⚠️ Pre-launch check: Patches are no longer valid, repairing automatically")
+		patchesStillValid = autoRepatch(currentVer)
+		if uiUpdateCallback != nil {
+			uiUpdateCallback()
+		}
+	}
+
 	if !patchesStillValid {
 		debug.Println("⚠️ Pre-launch check: Patches are no longer valid!")
 
@@ -357,6 +381,11 @@ func verifyPatchStatusBeforeLaunch(myWindow fyne.Window) bool {
 		return false // Don't launch
 	}
 
+	// Remember the mod enable states in case the client rewrites dlls.txt while updating
+	if err := patching.SaveModState(currentVer.GamePath, currentVer.ID); err != nil {
+		debug.Printf("Warning: failed to save mod state: %v", err)
+	}
+
 	debug.Println("✓ Pre-launch verification passed")
 	return true // Launch is OK
 }
@@ -390,6 +419,11 @@ func verifyPatchStatusAfterGameClose() {
 	// Check if patches are still applied
 	patchesStillValid := patching.CheckVersionPatchingStatus(currentVer.GamePath, currentVer.UsesRosettaPatching, currentVer.UsesDivxDecoderPatch, currentVer.ID)
 
+	if !patchesStillValid && currentVer.Settings.AutoRepatchAfterUpdate {
+		debug.Println("⚠️ Patches are no longer valid after game close, repairing automatically")
+		patchesStillValid = autoRepatch(currentVer)
+	}
+
 	if !patchesStillValid {
 		debug.Println("⚠️ Patches are no longer valid! TurtleWoW client may have updated and reset dlls.txt")
 
@@ -408,5 +442,28 @@ func verifyPatchStatusAfterGameClose() {
 		}
 	} else {
 		debug.Println("✓ Patches verified successfully - still valid")
+		if uiUpdateCallback != nil {
+			uiUpdateCallback()
+		}
+	}
+}
+
+// autoRepatch reapplies only the patch components the game client removed or replaced and
+// returns true if the game is fully patched afterwards
+func autoRepatch(currentVer *version.GameVersion) bool {
+	opts := patching.GameOptionsForVersion(currentVer)
+	if opts.CrossOverPath == "" {
+		opts.CrossOverPath = paths.CrossoverPath
 	}
+
+	result, err := patching.AutoHeal(currentVer.GamePath, opts)
+	if err != nil {
+		debug.Printf("Automatic repatch failed: %v", err)
+		return false
+	}
+	if len(result.Unhealable) > 0 {
+		debug.Printf("Automatic repatch could not restore every component:\n%s", result.Summary())
+	}
+
+	return patching.CheckVersionPatchingStatus(currentVer.GamePath, currentVer.UsesRosettaPatching, currentVer.UsesDivxDecoderPatch, currentVer.ID)
 }
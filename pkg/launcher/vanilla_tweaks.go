@@ -1,10 +1,13 @@
 package launcher
 
 import (
+	"bytes"
+	"encoding/binary"
 	"fmt"
+	"math"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"strings"
 
 	"turtlesilicon/pkg/debug"
 	"turtlesilicon/pkg/paths"
@@ -14,155 +17,253 @@ import (
 	"fyne.io/fyne/v2/dialog"
 )
 
-// ApplyVanillaTweaks applies vanilla-tweaks to WoW.exe to create WoW-tweaked.exe
-func ApplyVanillaTweaks(myWindow fyne.Window) error {
-	if paths.TurtlewowPath == "" {
-		return fmt.Errorf("TurtleWoW path not set")
-	}
-	if paths.CrossoverPath == "" {
-		return fmt.Errorf("CrossOver path not set")
-	}
+// VanillaTweak identifies a single binary patch of the 1.12.1 client
+type VanillaTweak string
 
-	// Get the current working directory (where the app executable is located)
-	execPath, err := os.Executable()
-	if err != nil {
-		return fmt.Errorf("failed to get executable path: %v", err)
-	}
-	appDir := filepath.Dir(execPath)
-
-	// Check if we're in development mode
-	vanillaTweaksPath := filepath.Join(appDir, "winerosetta", "vanilla-tweaks.exe")
-	if !utils.PathExists(vanillaTweaksPath) {
-		// Try relative path from current working directory (for development)
-		workingDir, _ := os.Getwd()
-		vanillaTweaksPath = filepath.Join(workingDir, "winerosetta", "vanilla-tweaks.exe")
-		if !utils.PathExists(vanillaTweaksPath) {
-			return fmt.Errorf("vanilla-tweaks.exe not found")
+const (
+	TweakFoV            VanillaTweak = "fov"
+	TweakFarclip        VanillaTweak = "farclip"
+	TweakFrillDistance  VanillaTweak = "frill_distance"
+	TweakNameplateRange VanillaTweak = "nameplate_range"
+	TweakSoundChannels  VanillaTweak = "sound_channels"
+)
+
+// VanillaTweakInfo describes a tweak and the bytes it changes in WoW.exe
+type VanillaTweakInfo struct {
+	Tweak       VanillaTweak
+	Name        string
+	Description string
+	Default     float64
+	Min         float64
+	Max         float64
+
+	offset   int64
+	original []byte
+	encode   func(value float64) []byte
+}
+
+func float32Bytes(value float64) []byte {
+	b := make([]byte, 4)
+	binary.LittleEndian.PutUint32(b, math.Float32bits(float32(value)))
+	return b
+}
+
+func uint32Bytes(value float64) []byte {
+	b := make([]byte, 4)
+	binary.LittleEndian.PutUint32(b, uint32(math.Round(value)))
+	return b
+}
+
+// VanillaTweaks lists the supported tweaks in display order. Offsets are file offsets in the
+// 1.12.1 (5875) WoW.exe; every one is checked against the original bytes before it is written.
+var VanillaTweaks = []VanillaTweakInfo{
+	{
+		Tweak:       TweakFoV,
+		Name:        "Widescreen field of view",
+		Description: "Vertical field of view in radians, the client uses 1.5708",
+		Default:     1.925,
+		Min:         0.1,
+		Max:         3.14,
+		offset:      0x4089B4,
+		original:    float32Bytes(1.5707964),
+		encode:      float32Bytes,
+	},
+	{
+		Tweak:       TweakFarclip,
+		Name:        "Maximum farclip",
+		Description: "Upper limit of the view distance slider, the client uses 777",
+		Default:     1000,
+		Min:         177,
+		Max:         10000,
+		offset:      0x40FED8,
+		original:    float32Bytes(777),
+		encode:      float32Bytes,
+	},
+	{
+		Tweak:       TweakFrillDistance,
+		Name:        "Grass draw distance",
+		Description: "Distance grass and other frills are drawn at, the client uses 70",
+		Default:     300,
+		Min:         70,
+		Max:         1000,
+		offset:      0x467958,
+		original:    float32Bytes(70),
+		encode:      float32Bytes,
+	},
+	{
+		Tweak:       TweakNameplateRange,
+		Name:        "Nameplate range",
+		Description: "Distance in yards nameplates are shown at, the client uses 20",
+		Default:     41,
+		Min:         20,
+		Max:         80,
+		offset:      0x40C448,
+		original:    float32Bytes(20),
+		encode:      float32Bytes,
+	},
+	{
+		Tweak:       TweakSoundChannels,
+		Name:        "Sound channels",
+		Description: "Maximum number of sounds played at once, the client uses 12",
+		Default:     64,
+		Min:         12,
+		Max:         999,
+		offset:      0x3BE838,
+		original:    uint32Bytes(12),
+		encode:      uint32Bytes,
+	},
+}
+
+// DefaultVanillaTweakSelection matches what the bundled vanilla-tweaks.exe used to apply
+func DefaultVanillaTweakSelection() map[string]float64 {
+	selection := make(map[string]float64)
+	for _, info := range VanillaTweaks {
+		switch info.Tweak {
+		case TweakFoV, TweakNameplateRange, TweakSoundChannels:
+			selection[string(info.Tweak)] = info.Default
 		}
 	}
+	return selection
+}
 
-	wowExePath := filepath.Join(paths.TurtlewowPath, "WoW.exe")
-	wineloader2Path := filepath.Join(paths.CrossoverPath, "Contents", "SharedSupport", "CrossOver", "CrossOver-Hosted Application", "wineloader2")
+// VanillaTweakSelection holds the enabled tweaks and their values, keyed by VanillaTweak. Nil
+// means DefaultVanillaTweakSelection.
+var VanillaTweakSelection map[string]float64
 
-	if !utils.PathExists(wowExePath) {
-		return fmt.Errorf("WoW.exe not found at %s", wowExePath)
+func currentVanillaTweakSelection() map[string]float64 {
+	if VanillaTweakSelection == nil {
+		return DefaultVanillaTweakSelection()
 	}
-	if !utils.PathExists(wineloader2Path) {
-		return fmt.Errorf("wineloader2 not found at %s", wineloader2Path)
+	return VanillaTweakSelection
+}
+
+// vanillaTweakInfo returns the tweak with the given identifier
+func vanillaTweakInfo(tweak VanillaTweak) (VanillaTweakInfo, bool) {
+	for _, info := range VanillaTweaks {
+		if info.Tweak == tweak {
+			return info, true
+		}
 	}
+	return VanillaTweakInfo{}, false
+}
 
-	// First, copy vanilla-tweaks.exe to the TurtleWoW directory temporarily
-	tempVanillaTweaksPath := filepath.Join(paths.TurtlewowPath, "vanilla-tweaks.exe")
+// selectedTweakBytes returns the bytes each selected tweak writes, keyed by tweak
+func selectedTweakBytes(selection map[string]float64) (map[VanillaTweak][]byte, error) {
+	selected := make(map[VanillaTweak][]byte)
+	for key, value := range selection {
+		info, ok := vanillaTweakInfo(VanillaTweak(key))
+		if !ok {
+			return nil, fmt.Errorf("unknown vanilla tweak %q", key)
+		}
+		if value < info.Min || value > info.Max {
+			return nil, fmt.Errorf("%s must be between %g and %g", info.Name, info.Min, info.Max)
+		}
+		selected[info.Tweak] = info.encode(value)
+	}
+	return selected, nil
+}
 
-	// Copy vanilla-tweaks.exe to TurtleWoW directory
-	debug.Printf("Copying vanilla-tweaks.exe from %s to %s", vanillaTweaksPath, tempVanillaTweaksPath)
-	sourceFile, err := os.Open(vanillaTweaksPath)
-	if err != nil {
-		return fmt.Errorf("failed to open vanilla-tweaks.exe: %v", err)
+// bytesAt returns the bytes of content at offset, or nil if the file is too short
+func bytesAt(content []byte, offset int64, length int) []byte {
+	if offset < 0 || offset+int64(length) > int64(len(content)) {
+		return nil
 	}
-	defer sourceFile.Close()
+	return content[offset : offset+int64(length)]
+}
 
-	destFile, err := os.Create(tempVanillaTweaksPath)
-	if err != nil {
-		return fmt.Errorf("failed to create temporary vanilla-tweaks.exe: %v", err)
+// ApplyVanillaTweaks writes WoW_tweaked.exe with the selected tweaks applied to WoW.exe. WoW.exe
+// itself is never modified. Nothing is written unless the offset of every selected tweak still
+// holds the bytes of the original 1.12.1 client.
+func ApplyVanillaTweaks(gamePath string, selection map[string]float64) error {
+	if gamePath == "" {
+		return fmt.Errorf("game path not set")
 	}
-	defer destFile.Close()
 
-	_, err = destFile.ReadFrom(sourceFile)
+	selected, err := selectedTweakBytes(selection)
 	if err != nil {
-		return fmt.Errorf("failed to copy vanilla-tweaks.exe: %v", err)
+		return err
 	}
 
-	// Ensure the copied file is executable
-	if err := os.Chmod(tempVanillaTweaksPath, 0755); err != nil {
-		debug.Printf("Warning: failed to set executable permission on vanilla-tweaks.exe: %v", err)
+	wowExePath := filepath.Join(gamePath, "WoW.exe")
+	content, err := os.ReadFile(wowExePath)
+	if err != nil {
+		return fmt.Errorf("failed to read WoW.exe: %v", err)
 	}
 
-	// Build the command to apply vanilla-tweaks using the correct format:
-	// cd "path" && "wineloader2" ./vanilla-tweaks.exe --no-frilldistance -no-farclip ./WoW.exe
-	shellCmd := fmt.Sprintf(`cd %s && %s ./vanilla-tweaks.exe --no-frilldistance --no-farclip ./WoW.exe`,
-		utils.QuotePathForShell(paths.TurtlewowPath),
-		utils.QuotePathForShell(wineloader2Path))
-
-	debug.Printf("Applying vanilla-tweaks with command: %s", shellCmd)
-
-	// Execute the command
-	cmd := exec.Command("sh", "-c", shellCmd)
-	output, err := cmd.CombinedOutput()
-
-	debug.Printf("vanilla-tweaks command output: %s", string(output))
-
-	// Clean up the temporary vanilla-tweaks.exe file
-	if cleanupErr := os.Remove(tempVanillaTweaksPath); cleanupErr != nil {
-		debug.Printf("Warning: failed to clean up temporary vanilla-tweaks.exe: %v", cleanupErr)
+	var mismatched []string
+	for _, info := range VanillaTweaks {
+		if _, ok := selected[info.Tweak]; !ok {
+			continue
+		}
+		current := bytesAt(content, info.offset, len(info.original))
+		if !bytes.Equal(current, info.original) {
+			debug.Printf("vanilla-tweaks: %s at 0x%X is % X, expected % X", info.Name, info.offset, current, info.original)
+			mismatched = append(mismatched, info.Name)
+		}
+	}
+	if len(mismatched) > 0 {
+		return fmt.Errorf("WoW.exe does not contain the original 1.12.1 bytes for: %s. Deselect these tweaks or restore the original WoW.exe", strings.Join(mismatched, ", "))
 	}
 
-	// Always check if the output file was created, regardless of exit code
-	// Some Wine programs report error exit codes even when they succeed
-	foundPath := GetWoWTweakedExecutablePath()
-	if foundPath == "" {
-		// Only report error if no output file was created
-		if err != nil {
-			debug.Printf("vanilla-tweaks command failed: %v", err)
-			return fmt.Errorf("failed to apply vanilla-tweaks: %v\nOutput: %s", err, string(output))
-		} else {
-			return fmt.Errorf("vanilla-tweaks completed but WoW-tweaked.exe was not created\nOutput: %s", string(output))
+	for _, info := range VanillaTweaks {
+		if value, ok := selected[info.Tweak]; ok {
+			copy(content[info.offset:], value)
 		}
 	}
 
-	// If we found the file but there was an error code, log it as a warning
-	if err != nil {
-		debug.Printf("vanilla-tweaks reported error but output file was created: %v", err)
+	tweakedPath := filepath.Join(gamePath, "WoW_tweaked.exe")
+	tempPath := tweakedPath + ".tmp"
+	if err := os.WriteFile(tempPath, content, 0755); err != nil {
+		return fmt.Errorf("failed to write WoW_tweaked.exe: %v", err)
+	}
+	if err := os.Rename(tempPath, tweakedPath); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("failed to write WoW_tweaked.exe: %v", err)
 	}
 
-	debug.Println("vanilla-tweaks applied successfully")
+	debug.Printf("vanilla-tweaks applied to %s: %v", tweakedPath, selection)
 	return nil
 }
 
-// CheckForVanillaTweaksExecutable checks if vanilla-tweaks.exe exists and is accessible
-func CheckForVanillaTweaksExecutable() bool {
-	// Get the current working directory (where the app executable is located)
-	execPath, err := os.Executable()
+// VanillaTweaksUpToDate returns true if WoW_tweaked.exe exists, is newer than WoW.exe and holds
+// exactly the selected tweaks
+func VanillaTweaksUpToDate(gamePath string, selection map[string]float64) bool {
+	selected, err := selectedTweakBytes(selection)
 	if err != nil {
 		return false
 	}
-	appDir := filepath.Dir(execPath)
 
-	// Check if we're in development mode (running from VSCode)
-	vanillaTweaksPath := filepath.Join(appDir, "winerosetta", "vanilla-tweaks.exe")
-	if utils.PathExists(vanillaTweaksPath) {
-		return true
+	wowExePath := filepath.Join(gamePath, "WoW.exe")
+	tweakedPath := filepath.Join(gamePath, "WoW_tweaked.exe")
+	wowInfo, err := os.Stat(wowExePath)
+	if err != nil {
+		return false
+	}
+	tweakedInfo, err := os.Stat(tweakedPath)
+	if err != nil || tweakedInfo.Size() != wowInfo.Size() || tweakedInfo.ModTime().Before(wowInfo.ModTime()) {
+		return false
 	}
 
-	// Try relative path from current working directory (for development)
-	workingDir, _ := os.Getwd()
-	vanillaTweaksPath = filepath.Join(workingDir, "winerosetta", "vanilla-tweaks.exe")
-	return utils.PathExists(vanillaTweaksPath)
-}
-
-// GetVanillaTweaksExecutablePath returns the path to vanilla-tweaks.exe if it exists
-func GetVanillaTweaksExecutablePath() (string, error) {
-	// Get the current working directory (where the app executable is located)
-	execPath, err := os.Executable()
+	original, err := os.ReadFile(wowExePath)
 	if err != nil {
-		return "", fmt.Errorf("failed to get executable path: %v", err)
+		return false
 	}
-	appDir := filepath.Dir(execPath)
-
-	// Check if we're in development mode (running from VSCode)
-	vanillaTweaksPath := filepath.Join(appDir, "winerosetta", "vanilla-tweaks.exe")
-	if utils.PathExists(vanillaTweaksPath) {
-		return vanillaTweaksPath, nil
+	tweaked, err := os.ReadFile(tweakedPath)
+	if err != nil {
+		return false
 	}
 
-	// Try relative path from current working directory (for development)
-	workingDir, _ := os.Getwd()
-	vanillaTweaksPath = filepath.Join(workingDir, "winerosetta", "vanilla-tweaks.exe")
-	if utils.PathExists(vanillaTweaksPath) {
-		return vanillaTweaksPath, nil
+	for _, info := range VanillaTweaks {
+		expected, ok := selected[info.Tweak]
+		if !ok {
+			// Tweaks that are not selected must be left as they are in WoW.exe
+			expected = bytesAt(original, info.offset, len(info.original))
+		}
+		if expected == nil || !bytes.Equal(bytesAt(tweaked, info.offset, len(expected)), expected) {
+			return false
+		}
 	}
-
-	return "", fmt.Errorf("vanilla-tweaks.exe not found")
+	return true
 }
 
 // CheckForWoWTweakedExecutable checks if WoW_tweaked.exe exists in the TurtleWoW directory
@@ -188,18 +289,18 @@ func GetWoWTweakedExecutablePath() string {
 	return ""
 }
 
-// HandleVanillaTweaksRequest handles the case when vanilla-tweaks is enabled but WoW-tweaked.exe doesn't exist
+// HandleVanillaTweaksRequest makes sure WoW_tweaked.exe holds the selected tweaks before callback
+// launches it. It is regenerated without asking when the selection or WoW.exe changed.
 func HandleVanillaTweaksRequest(myWindow fyne.Window, callback func()) {
-	dialog.ShowConfirm("Vanilla-tweaks not found",
-		"WoW-tweaked.exe was not found in your TurtleWoW directory.\n\nWould you like TurtleSilicon to automatically apply vanilla-tweaks for you?",
-		func(confirmed bool) {
-			if confirmed {
-				if err := ApplyVanillaTweaks(myWindow); err != nil {
-					dialog.ShowError(fmt.Errorf("failed to apply vanilla-tweaks: %v", err), myWindow)
-					return
-				}
-				// After successful patching, execute the callback
-				callback()
-			}
-		}, myWindow)
+	selection := currentVanillaTweakSelection()
+	if VanillaTweaksUpToDate(paths.TurtlewowPath, selection) {
+		callback()
+		return
+	}
+
+	if err := ApplyVanillaTweaks(paths.TurtlewowPath, selection); err != nil {
+		dialog.ShowError(fmt.Errorf("failed to apply vanilla-tweaks: %v", err), myWindow)
+		return
+	}
+	callback()
 }
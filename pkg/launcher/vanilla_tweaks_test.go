@@ -0,0 +1,169 @@
+package launcher
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testWoWExe writes a WoW.exe that holds the original 1.12.1 bytes at the offset of every tweak
+func testWoWExe(t *testing.T) (string, []byte) {
+	t.Helper()
+	var size int64
+	for _, info := range VanillaTweaks {
+		if end := info.offset + int64(len(info.original)); end > size {
+			size = end
+		}
+	}
+	content := make([]byte, size+16)
+	for i := range content {
+		content[i] = byte(i*7 + 3)
+	}
+	for _, info := range VanillaTweaks {
+		copy(content[info.offset:], info.original)
+	}
+
+	gamePath := t.TempDir()
+	if err := os.WriteFile(filepath.Join(gamePath, "WoW.exe"), content, 0755); err != nil {
+		t.Fatal(err)
+	}
+	return gamePath, content
+}
+
+func TestSelectedTweakBytes(t *testing.T) {
+	tests := []struct {
+		name      string
+		selection map[string]float64
+		wantErr   bool
+	}{
+		{"defaults", DefaultVanillaTweakSelection(), false},
+		{"nothing selected", map[string]float64{}, false},
+		{"minimum", map[string]float64{string(TweakFarclip): 177}, false},
+		{"maximum", map[string]float64{string(TweakSoundChannels): 999}, false},
+		{"below the minimum", map[string]float64{string(TweakNameplateRange): 19.9}, true},
+		{"above the maximum", map[string]float64{string(TweakFoV): 3.15}, true},
+		{"unknown tweak", map[string]float64{"gamma": 1}, true},
+	}
+
+	for _, tt := range tests {
+		selected, err := selectedTweakBytes(tt.selection)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: selectedTweakBytes() error = %v, want error %v", tt.name, err, tt.wantErr)
+		}
+		if err == nil && len(selected) != len(tt.selection) {
+			t.Errorf("%s: selectedTweakBytes() returned %d tweaks, want %d", tt.name, len(selected), len(tt.selection))
+		}
+	}
+}
+
+func TestApplyVanillaTweaks(t *testing.T) {
+	gamePath, original := testWoWExe(t)
+	selection := map[string]float64{string(TweakFoV): 1.925, string(TweakSoundChannels): 64}
+
+	if err := ApplyVanillaTweaks(gamePath, selection); err != nil {
+		t.Fatalf("ApplyVanillaTweaks() error = %v", err)
+	}
+	tweaked, err := os.ReadFile(filepath.Join(gamePath, "WoW_tweaked.exe"))
+	if err != nil {
+		t.Fatalf("WoW_tweaked.exe not written: %v", err)
+	}
+	for _, info := range VanillaTweaks {
+		want := info.original
+		if value, ok := selection[string(info.Tweak)]; ok {
+			want = info.encode(value)
+		}
+		if got := bytesAt(tweaked, info.offset, len(want)); !bytes.Equal(got, want) {
+			t.Errorf("WoW_tweaked.exe %s at 0x%X = % X, want % X", info.Name, info.offset, got, want)
+		}
+	}
+	if content, _ := os.ReadFile(filepath.Join(gamePath, "WoW.exe")); !bytes.Equal(content, original) {
+		t.Errorf("ApplyVanillaTweaks() modified WoW.exe")
+	}
+
+	if err := ApplyVanillaTweaks(gamePath, map[string]float64{string(TweakFoV): 5}); err == nil {
+		t.Errorf("ApplyVanillaTweaks() with a value out of range = nil, want error")
+	}
+	if err := ApplyVanillaTweaks("", selection); err == nil {
+		t.Errorf("ApplyVanillaTweaks() without a game path = nil, want error")
+	}
+}
+
+func TestApplyVanillaTweaksOriginalBytes(t *testing.T) {
+	gamePath, content := testWoWExe(t)
+	fov, _ := vanillaTweakInfo(TweakFoV)
+	copy(content[fov.offset:], fov.encode(2))
+	if err := os.WriteFile(filepath.Join(gamePath, "WoW.exe"), content, 0755); err != nil {
+		t.Fatal(err)
+	}
+	tweakedPath := filepath.Join(gamePath, "WoW_tweaked.exe")
+
+	// A tweak whose offset was changed is refused and nothing is written
+	err := ApplyVanillaTweaks(gamePath, map[string]float64{string(TweakFoV): 1.925, string(TweakFarclip): 1000})
+	if err == nil || !strings.Contains(err.Error(), fov.Name) {
+		t.Errorf("ApplyVanillaTweaks() error = %v, want the %s mismatch", err, fov.Name)
+	}
+	if _, err := os.Stat(tweakedPath); err == nil {
+		t.Errorf("ApplyVanillaTweaks() wrote WoW_tweaked.exe despite the mismatch")
+	}
+
+	// Offsets of tweaks that are not selected are not checked
+	if err := ApplyVanillaTweaks(gamePath, map[string]float64{string(TweakFarclip): 1000}); err != nil {
+		t.Errorf("ApplyVanillaTweaks() without the changed tweak error = %v", err)
+	}
+
+	// A file too short for an offset does not hold the original bytes either
+	if err := os.WriteFile(filepath.Join(gamePath, "WoW.exe"), content[:fov.offset+2], 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ApplyVanillaTweaks(gamePath, map[string]float64{string(TweakFoV): 1.925}); err == nil {
+		t.Errorf("ApplyVanillaTweaks() on a truncated WoW.exe = nil, want error")
+	}
+}
+
+func TestVanillaTweaksUpToDate(t *testing.T) {
+	gamePath, _ := testWoWExe(t)
+	selection := DefaultVanillaTweakSelection()
+	tweakedPath := filepath.Join(gamePath, "WoW_tweaked.exe")
+
+	if VanillaTweaksUpToDate(gamePath, selection) {
+		t.Errorf("VanillaTweaksUpToDate() = true without WoW_tweaked.exe")
+	}
+	if err := ApplyVanillaTweaks(gamePath, selection); err != nil {
+		t.Fatalf("ApplyVanillaTweaks() error = %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		selection map[string]float64
+		want      bool
+	}{
+		{"same selection", selection, true},
+		{"changed value", map[string]float64{string(TweakFoV): 2, string(TweakNameplateRange): 41, string(TweakSoundChannels): 64}, false},
+		{"tweak deselected", map[string]float64{string(TweakFoV): 1.925, string(TweakNameplateRange): 41}, false},
+		{"tweak added", map[string]float64{string(TweakFoV): 1.925, string(TweakNameplateRange): 41, string(TweakSoundChannels): 64, string(TweakFarclip): 1000}, false},
+		{"invalid selection", map[string]float64{"gamma": 1}, false},
+	}
+	for _, tt := range tests {
+		if got := VanillaTweaksUpToDate(gamePath, tt.selection); got != tt.want {
+			t.Errorf("%s: VanillaTweaksUpToDate() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	// A WoW.exe replaced after WoW_tweaked.exe was written needs new tweaks
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(filepath.Join(gamePath, "WoW.exe"), later, later); err != nil {
+		t.Fatal(err)
+	}
+	if VanillaTweaksUpToDate(gamePath, selection) {
+		t.Errorf("VanillaTweaksUpToDate() = true for a WoW_tweaked.exe older than WoW.exe")
+	}
+	if err := os.Chtimes(tweakedPath, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if !VanillaTweaksUpToDate(gamePath, selection) {
+		t.Errorf("VanillaTweaksUpToDate() = false after restoring the modification time")
+	}
+}
@@ -9,9 +9,11 @@ import (
 	"sync"
 
 	"turtlesilicon/pkg/debug"
+	"turtlesilicon/pkg/mods"
 	"turtlesilicon/pkg/paths"
 	"turtlesilicon/pkg/utils"
 	"turtlesilicon/pkg/version"
+	"turtlesilicon/pkg/wine"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/dialog"
@@ -41,12 +43,46 @@ func getCurrentVersionFromManager(versionID string) *version.GameVersion {
 	return ver
 }
 
+// applyLaunchModProfile applies the version's launch mod profile, if it has one. Failing to apply
+// it is reported but does not stop the launch.
+func applyLaunchModProfile(myWindow fyne.Window, versionID string) {
+	vm, err := version.LoadVersionManager()
+	if err != nil {
+		debug.Printf("Failed to load version manager: %v", err)
+		return
+	}
+	ver, err := vm.GetVersion(versionID)
+	if err != nil || !ver.SupportsDLLLoading || ver.Settings.LaunchModProfile == "" {
+		return
+	}
+
+	// A running crash bisect decides which mods are enabled
+	if mods.LoadBisect(ver.GamePath) != nil {
+		debug.Printf("Crash bisect running, not applying launch mod profile")
+		return
+	}
+
+	profile := ver.Settings.LaunchModProfile
+	skipped, warnings, err := mods.ApplyModProfile(vm, ver, ver.GamePath, profile)
+	if err != nil {
+		debug.Printf("Failed to apply launch mod profile %q: %v", profile, err)
+		dialog.ShowError(fmt.Errorf("failed to apply mod profile %s: %v", profile, err), myWindow)
+		return
+	}
+	for _, reason := range skipped {
+		debug.Printf("Launch mod profile %q: skipped %s", profile, reason)
+	}
+	for _, warning := range warnings {
+		debug.Printf("Launch mod profile %q: possible conflict of %s", profile, warning)
+	}
+}
+
 // LaunchVersionGame launches a specific version of the game
-func LaunchVersionGame(myWindow fyne.Window, versionID string, gamePath string, crossoverPath string, executableName string, enableMetalHud bool, customEnvVars string, autoDeleteWdb bool) {
+func LaunchVersionGame(myWindow fyne.Window, versionID string, gamePath string, runtime wine.Runtime, executableName string, enableMetalHud bool, customEnvVars string, autoDeleteWdb bool) {
 	debug.Printf("Launch Game button clicked for version: %s", versionID)
 
-	if crossoverPath == "" {
-		dialog.ShowError(fmt.Errorf("CrossOver path not set for version %s. Please set it in the patcher.", versionID), myWindow)
+	if runtime.Path() == "" {
+		dialog.ShowError(fmt.Errorf("%s path not set for version %s. Please set it in the patcher.", runtime.Name(), versionID), myWindow)
 		return
 	}
 	if gamePath == "" {
@@ -77,18 +113,21 @@ func LaunchVersionGame(myWindow fyne.Window, versionID string, gamePath string,
 		deleteWDBDirectories(gamePath, versionID)
 	}
 
+	// Switch dlls.txt to the mod profile chosen for launching
+	applyLaunchModProfile(myWindow, versionID)
+
 	// For non-TurtleSilicon versions, we launch differently
 	if versionID == "turtlesilicon" {
 		// Use existing TurtleSilicon launch logic
-		launchTurtleSiliconVersion(myWindow, gamePath, crossoverPath, gameExePath, enableMetalHud, customEnvVars)
+		launchTurtleSiliconVersion(myWindow, gamePath, runtime, gameExePath, enableMetalHud, customEnvVars)
 	} else {
 		// Use new launch method for other versions
-		launchOtherVersion(myWindow, versionID, gamePath, crossoverPath, gameExePath, enableMetalHud, customEnvVars)
+		launchOtherVersion(myWindow, versionID, gamePath, runtime, gameExePath, enableMetalHud, customEnvVars)
 	}
 }
 
 // launchTurtleSiliconVersion launches using the existing TurtleSilicon method
-func launchTurtleSiliconVersion(myWindow fyne.Window, gamePath string, crossoverPath string, gameExePath string, enableMetalHud bool, customEnvVars string) {
+func launchTurtleSiliconVersion(myWindow fyne.Window, gamePath string, runtime wine.Runtime, gameExePath string, enableMetalHud bool, customEnvVars string) {
 	debug.Println("Using TurtleSilicon launch method")
 
 	// Get the current TurtleSilicon version settings
@@ -97,6 +136,7 @@ func launchTurtleSiliconVersion(myWindow fyne.Window, gamePath string, crossover
 	// Temporarily set the legacy paths and settings for the existing launch function
 	originalTurtlewowPath := paths.TurtlewowPath
 	originalCrossoverPath := paths.CrossoverPath
+	originalGameRuntime := GameRuntime
 	originalEnableMetalHud := EnableMetalHud
 	originalCustomEnvVars := CustomEnvVars
 	originalPatchesAppliedTurtleWoW := paths.PatchesAppliedTurtleWoW
@@ -112,20 +152,23 @@ func launchTurtleSiliconVersion(myWindow fyne.Window, gamePath string, crossover
 
 	// Set the paths and settings for this version
 	paths.TurtlewowPath = gamePath
-	paths.CrossoverPath = crossoverPath
+	if runtime.Kind() == wine.KindCrossOver {
+		paths.CrossoverPath = runtime.Path()
+	}
+	GameRuntime = runtime
 	EnableMetalHud = enableMetalHud
 	CustomEnvVars = customEnvVars
 
 	// Set patch status based on version-aware checking
 	paths.PatchesAppliedTurtleWoW = true // We know patches are applied if we got this far
-	// Check CrossOver patch status
-	wineloader2Path := filepath.Join(crossoverPath, "Contents", "SharedSupport", "CrossOver", "CrossOver-Hosted Application", "wineloader2")
-	paths.PatchesAppliedCrossOver = utils.PathExists(wineloader2Path)
+	// Check whether the Wine runtime is ready, for CrossOver that wineloader2 exists
+	paths.PatchesAppliedCrossOver = wine.Ready(runtime) == nil
 
 	// Restore original values after launch
 	defer func() {
 		paths.TurtlewowPath = originalTurtlewowPath
 		paths.CrossoverPath = originalCrossoverPath
+		GameRuntime = originalGameRuntime
 		EnableMetalHud = originalEnableMetalHud
 		CustomEnvVars = originalCustomEnvVars
 		paths.PatchesAppliedTurtleWoW = originalPatchesAppliedTurtleWoW
@@ -140,14 +183,13 @@ func launchTurtleSiliconVersion(myWindow fyne.Window, gamePath string, crossover
 }
 
 // launchOtherVersion launches other versions using rosettax87 direct execution
-func launchOtherVersion(myWindow fyne.Window, versionID string, gamePath string, crossoverPath string, gameExePath string, enableMetalHud bool, customEnvVars string) {
-	debug.Printf("Launching %s using rosettax87 direct execution", versionID)
+func launchOtherVersion(myWindow fyne.Window, versionID string, gamePath string, runtime wine.Runtime, gameExePath string, enableMetalHud bool, customEnvVars string) {
+	debug.Printf("Launching %s using rosettax87 direct execution with %s", versionID, runtime.Name())
 
-	wineloader2Path := filepath.Join(crossoverPath, "Contents", "SharedSupport", "CrossOver", "CrossOver-Hosted Application", "wineloader2")
 	rosettaX87ExePath := filepath.Join(gamePath, "rosettax87", "rosettax87")
 
-	if !utils.PathExists(wineloader2Path) {
-		dialog.ShowError(fmt.Errorf("patched wineloader2 not found at %s. Ensure CrossOver patching was successful", wineloader2Path), myWindow)
+	if err := wine.Ready(runtime); err != nil {
+		dialog.ShowError(fmt.Errorf("%s cannot launch the game: %v", runtime.Name(), err), myWindow)
 		return
 	}
 
@@ -156,23 +198,15 @@ func launchOtherVersion(myWindow fyne.Window, versionID string, gamePath string,
 		return
 	}
 
-	mtlHudValue := "0"
-	if enableMetalHud {
-		mtlHudValue = "1"
-	}
-
 	// Prepare environment variables for other versions (include WINEDLLOVERRIDES for d3d9.dll graphics)
-	envVars := fmt.Sprintf(`WINEDLLOVERRIDES="d3d9=n,b" MTL_HUD_ENABLED=%s MVK_CONFIG_SYNCHRONOUS_QUEUE_SUBMITS=1 DXVK_ASYNC=1`, mtlHudValue)
-	if customEnvVars != "" {
-		envVars = customEnvVars + " " + envVars
-	}
+	envVars := launchEnv(runtime, customEnvVars, enableMetalHud)
 
 	// Direct execution without service dependency
 	shellCmd := fmt.Sprintf(`cd %s && %s %s %s %s`,
 		utils.QuotePathForShell(gamePath),
 		envVars,
 		utils.QuotePathForShell(rosettaX87ExePath),
-		utils.QuotePathForShell(wineloader2Path),
+		utils.QuotePathForShell(runtime.LoaderPath()),
 		utils.QuotePathForShell(gameExePath))
 
 	// Check version-specific preference for terminal display
@@ -189,7 +223,8 @@ func launchOtherVersion(myWindow fyne.Window, versionID string, gamePath string,
 		cmd2Script := fmt.Sprintf("tell application \"Terminal\" to do script \"%s\"", escapedShellCmd)
 
 		debug.Printf("Executing %s launch command via AppleScript...", versionID)
-		if !utils.RunOsascript(cmd2Script, myWindow) {
+		if err := utils.RunOsascript(cmd2Script); err != nil {
+			dialog.ShowError(err, myWindow)
 			return
 		}
 
@@ -0,0 +1,289 @@
+package mods
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"turtlesilicon/pkg/backup"
+	"turtlesilicon/pkg/debug"
+	"turtlesilicon/pkg/dllstxt"
+)
+
+// bisectFileName keeps the running bisect in the mods directory, so it survives a restart of the
+// app and the original dlls.txt can always be restored
+const bisectFileName = "bisect_session.json"
+
+var (
+	ErrBisectRunning   = errors.New("a crash bisect is already running")
+	ErrNoBisect        = errors.New("no crash bisect is running")
+	ErrNothingToBisect = errors.New("no optional mods are enabled")
+)
+
+// BisectSession narrows down which enabled mod makes the game crash. The first step launches the
+// game with every optional mod disabled to rule out a crash without mods. Each following step
+// enables half of the remaining suspects, together with the mods they require, and keeps the half
+// that matches the result.
+type BisectSession struct {
+	VersionID     string    `json:"version_id"`
+	Original      string    `json:"original"`   // dlls.txt before the bisect, restored at the end
+	Candidates    []string  `json:"candidates"` // Optional mods that were enabled when the bisect started
+	Suspects      []string  `json:"suspects"`   // Mods that may still cause the crash
+	Testing       []string  `json:"testing"`    // Mods enabled in the current step
+	Step          int       `json:"step"`
+	StepStartedAt time.Time `json:"step_started_at,omitempty"` // When the game was launched for the step
+	Warnings      []string  `json:"warnings,omitempty"`        // Enabled mods of the step that patch the same code
+
+	Culprit    string `json:"culprit,omitempty"`    // Mod found to cause the crash
+	Conclusion string `json:"conclusion,omitempty"` // Set once the bisect is done
+}
+
+func bisectPath(gamePath string) string {
+	return filepath.Join(gamePath, "mods", bisectFileName)
+}
+
+// LoadBisect returns the running bisect of a game directory, or nil if there is none
+func LoadBisect(gamePath string) *BisectSession {
+	data, err := os.ReadFile(bisectPath(gamePath))
+	if err != nil {
+		return nil
+	}
+	var session BisectSession
+	if err := json.Unmarshal(data, &session); err != nil {
+		debug.Printf("Failed to parse %s: %v", bisectFileName, err)
+		return nil
+	}
+	return &session
+}
+
+func (s *BisectSession) save(gamePath string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode crash bisect: %v", err)
+	}
+	if err := os.WriteFile(bisectPath(gamePath), data, 0644); err != nil {
+		return fmt.Errorf("failed to save crash bisect: %v", err)
+	}
+	return nil
+}
+
+// StartBisect remembers dlls.txt and sets up the first step, which disables every optional mod
+func StartBisect(gamePath string, versionID string) (*BisectSession, error) {
+	if LoadBisect(gamePath) != nil {
+		return nil, ErrBisectRunning
+	}
+
+	dllsPath := filepath.Join(gamePath, dllstxt.FileName)
+	content, err := os.ReadFile(dllsPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dlls.txt: %v", err)
+	}
+
+	session := &BisectSession{VersionID: versionID, Original: string(content)}
+	for _, entry := range dllstxt.Parse(string(content)).Entries() {
+		if entry.Enabled && isModEntry(entry.Name) && !dllstxt.SameDLL(entry.Name, requiredModEntry) {
+			session.Candidates = append(session.Candidates, entry.Name)
+		}
+	}
+	if len(session.Candidates) == 0 {
+		return nil, ErrNothingToBisect
+	}
+	session.Suspects = session.Candidates
+
+	if err := backup.SaveFile(gamePath, dllsPath, backup.OpModManager); err != nil {
+		return nil, fmt.Errorf("failed to back up dlls.txt: %v", err)
+	}
+	if err := session.applyStep(gamePath, nil); err != nil {
+		return nil, err
+	}
+	debug.Printf("Started crash bisect for %s with %d mods", versionID, len(session.Candidates))
+	return session, nil
+}
+
+// IsBaseline returns true while the step without any optional mod is tested
+func (s *BisectSession) IsBaseline() bool {
+	return s.Step == 1
+}
+
+// Done returns true once the bisect reached a conclusion
+func (s *BisectSession) Done() bool {
+	return s.Conclusion != ""
+}
+
+// applyStep enables the mods to test, disables the other candidates and saves the session
+func (s *BisectSession) applyStep(gamePath string, testing []string) error {
+	dllsPath := filepath.Join(gamePath, dllstxt.FileName)
+	dlls, err := dllstxt.Load(dllsPath)
+	if err != nil {
+		return err
+	}
+	for _, entry := range s.Candidates {
+		dlls.Set(entry, indexOfEntry(testing, entry) >= 0)
+	}
+	if err := dlls.Save(dllsPath); err != nil {
+		return err
+	}
+
+	s.Step++
+	s.Testing = testing
+	s.StepStartedAt = time.Time{}
+	s.Warnings = enabledOverlapWarnings(modsInDirectory(gamePath, dlls))
+	return s.save(gamePath)
+}
+
+// MarkLaunched records when the game was launched for the current step, see DetectCrash
+func (s *BisectSession) MarkLaunched(gamePath string) error {
+	s.StepStartedAt = time.Now()
+	return s.save(gamePath)
+}
+
+// Report records whether the game crashed in the current step and sets up the next one. Once a
+// single suspect is left, or the crash happens without any optional mod, the bisect is done.
+func (s *BisectSession) Report(gamePath string, crashed bool) error {
+	if s.Done() {
+		return nil
+	}
+
+	if s.IsBaseline() {
+		if crashed {
+			s.Conclusion = "The game also crashes with every optional mod disabled, so the crash is not caused by a mod."
+			return s.save(gamePath)
+		}
+	} else if crashed {
+		s.Suspects = s.Testing
+	} else {
+		var remaining []string
+		for _, entry := range s.Suspects {
+			if indexOfEntry(s.Testing, entry) < 0 {
+				remaining = append(remaining, entry)
+			}
+		}
+		s.Suspects = remaining
+	}
+
+	switch len(s.Suspects) {
+	case 0:
+		s.Conclusion = "The crash could not be reproduced with any subset of the mods. It may need several mods together or happen only sometimes."
+		return s.save(gamePath)
+	case 1:
+		s.Culprit = s.Suspects[0]
+		s.Conclusion = fmt.Sprintf("%s causes the crash.", strings.TrimPrefix(s.Culprit, "mods/"))
+		debug.Printf("Crash bisect found %s after %d steps", s.Culprit, s.Step)
+		return s.save(gamePath)
+	}
+
+	half := splitSuspects(s.Suspects, s.requires(gamePath))
+	if len(half) == 0 {
+		s.Conclusion = fmt.Sprintf("One of %s causes the crash. They require each other, so they cannot be tested separately.", strings.Join(bisectNames(s.Suspects), ", "))
+		return s.save(gamePath)
+	}
+	return s.applyStep(gamePath, half)
+}
+
+// requires returns the candidates each candidate requires according to its metadata
+func (s *BisectSession) requires(gamePath string) map[string][]string {
+	requires := make(map[string][]string)
+	for _, entry := range s.Candidates {
+		metadata := LoadModMetadata(filepath.Join(gamePath, filepath.FromSlash(strings.ReplaceAll(entry, "\\", "/"))))
+		for _, required := range metadata.Requires {
+			for _, other := range s.Candidates {
+				if other != entry && strings.EqualFold(modFileName(required), modFileName(other)) {
+					requires[entry] = append(requires[entry], other)
+				}
+			}
+		}
+	}
+	return requires
+}
+
+// splitSuspects returns about half of the suspects to test next. A mod does not load without the
+// mods it requires, so mods that require each other, directly or through other suspects, stay in
+// the same half. It returns nil if the suspects cannot be split.
+func splitSuspects(suspects []string, requires map[string][]string) []string {
+	// Group the suspects by following requires in both directions
+	group := make(map[string]int)
+	var groups [][]string
+	for _, suspect := range suspects {
+		if _, ok := group[suspect]; ok {
+			continue
+		}
+		id := len(groups)
+		groups = append(groups, nil)
+		pending := []string{suspect}
+		group[suspect] = id
+		for len(pending) > 0 {
+			entry := pending[0]
+			pending = pending[1:]
+			groups[id] = append(groups[id], entry)
+			for _, other := range suspects {
+				if _, ok := group[other]; ok || !(requiresEntry(requires, entry, other) || requiresEntry(requires, other, entry)) {
+					continue
+				}
+				group[other] = id
+				pending = append(pending, other)
+			}
+		}
+	}
+
+	var half []string
+	for _, members := range groups[:len(groups)-1] {
+		half = append(half, members...)
+		if len(half) >= len(suspects)/2 {
+			break
+		}
+	}
+	return half
+}
+
+func requiresEntry(requires map[string][]string, entry string, other string) bool {
+	return indexOfEntry(requires[entry], other) >= 0
+}
+
+// FinishBisect restores dlls.txt as it was before the bisect and ends it
+func FinishBisect(gamePath string) error {
+	session := LoadBisect(gamePath)
+	if session == nil {
+		return ErrNoBisect
+	}
+
+	dllsPath := filepath.Join(gamePath, dllstxt.FileName)
+	if err := dllstxt.Parse(session.Original).Save(dllsPath); err != nil {
+		return err
+	}
+	if err := os.Remove(bisectPath(gamePath)); err != nil {
+		return fmt.Errorf("failed to end crash bisect: %v", err)
+	}
+	debug.Printf("Crash bisect ended, restored dlls.txt")
+	return nil
+}
+
+// DetectCrash looks for a crash report the client wrote to its Errors directory since a time
+func DetectCrash(gamePath string, since time.Time) (string, bool) {
+	if since.IsZero() {
+		return "", false
+	}
+	entries, err := os.ReadDir(filepath.Join(gamePath, "Errors"))
+	if err != nil {
+		return "", false
+	}
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err == nil && !entry.IsDir() && info.ModTime().After(since) {
+			return filepath.Join(gamePath, "Errors", entry.Name()), true
+		}
+	}
+	return "", false
+}
+
+func indexOfEntry(entries []string, entry string) int {
+	for i, existing := range entries {
+		if dllstxt.SameDLL(existing, entry) {
+			return i
+		}
+	}
+	return -1
+}
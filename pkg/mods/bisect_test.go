@@ -0,0 +1,181 @@
+package mods
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"turtlesilicon/pkg/dllstxt"
+)
+
+func TestBisectReport(t *testing.T) {
+	const original = "mods/winerosetta.dll\nmods/a.dll\nmods/b.dll\n#mods/off.dll\nmods/c.dll\nmods/d.dll\n"
+
+	tests := []struct {
+		name        string
+		crashes     []bool     // Reported results, the first one for the step without mods
+		wantTesting [][]string // Mods enabled in each step after the baseline
+		wantCulprit string
+		wantBlame   bool // Conclusion blames a mod
+	}{
+		{
+			name:        "crash in the second half",
+			crashes:     []bool{false, false, true},
+			wantTesting: [][]string{{"mods/a.dll", "mods/b.dll"}, {"mods/c.dll"}},
+			wantCulprit: "mods/c.dll",
+			wantBlame:   true,
+		},
+		{
+			name:        "crash in the first half",
+			crashes:     []bool{false, true, false},
+			wantTesting: [][]string{{"mods/a.dll", "mods/b.dll"}, {"mods/a.dll"}},
+			wantCulprit: "mods/b.dll",
+			wantBlame:   true,
+		},
+		{
+			name:    "crash without mods",
+			crashes: []bool{true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+		t.Setenv("HOME", t.TempDir())
+		gamePath := t.TempDir()
+		dllsPath := filepath.Join(gamePath, dllstxt.FileName)
+		if err := os.MkdirAll(filepath.Join(gamePath, "mods"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(dllsPath, []byte(original), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		session, err := StartBisect(gamePath, "turtlesilicon")
+		if err != nil {
+			t.Fatalf("%s: StartBisect() error = %v", tt.name, err)
+		}
+		if _, err := StartBisect(gamePath, "turtlesilicon"); !errors.Is(err, ErrBisectRunning) {
+			t.Errorf("%s: second StartBisect() error = %v, want ErrBisectRunning", tt.name, err)
+		}
+		if got := strings.Join(session.Candidates, ","); got != "mods/a.dll,mods/b.dll,mods/c.dll,mods/d.dll" {
+			t.Errorf("%s: Candidates = %s, want the enabled optional mods", tt.name, got)
+		}
+
+		for i, crashed := range tt.crashes {
+			if session.Done() {
+				t.Fatalf("%s: bisect done after %d reports, want %d", tt.name, i, len(tt.crashes))
+			}
+			if session.IsBaseline() != (i == 0) {
+				t.Errorf("%s: step %d IsBaseline() = %v", tt.name, session.Step, session.IsBaseline())
+			}
+
+			dlls, _ := dllstxt.Load(dllsPath)
+			for _, candidate := range session.Candidates {
+				if want := indexOfEntry(session.Testing, candidate) >= 0; dlls.IsEnabled(candidate) != want {
+					t.Errorf("%s: step %d %s enabled = %v, want %v", tt.name, session.Step, candidate, !want, want)
+				}
+			}
+			if !dlls.IsEnabled(requiredModEntry) {
+				t.Errorf("%s: step %d disabled %s", tt.name, session.Step, requiredModEntry)
+			}
+
+			if err := session.Report(gamePath, crashed); err != nil {
+				t.Fatalf("%s: Report() error = %v", tt.name, err)
+			}
+			if i < len(tt.wantTesting) && !session.Done() {
+				if got, want := strings.Join(session.Testing, ","), strings.Join(tt.wantTesting[i], ","); got != want {
+					t.Errorf("%s: after report %d Testing = %s, want %s", tt.name, i+1, got, want)
+				}
+			}
+		}
+
+		if !session.Done() {
+			t.Fatalf("%s: bisect not done after every report", tt.name)
+		}
+		if session.Culprit != tt.wantCulprit {
+			t.Errorf("%s: Culprit = %q, want %q", tt.name, session.Culprit, tt.wantCulprit)
+		}
+		if blames := strings.Contains(session.Conclusion, "causes the crash"); blames != tt.wantBlame {
+			t.Errorf("%s: Conclusion = %q", tt.name, session.Conclusion)
+		}
+
+		// The session survives a restart of the app
+		if loaded := LoadBisect(gamePath); loaded == nil || loaded.Conclusion != session.Conclusion {
+			t.Errorf("%s: LoadBisect() = %+v, want the saved session", tt.name, loaded)
+		}
+
+		if err := FinishBisect(gamePath); err != nil {
+			t.Fatalf("%s: FinishBisect() error = %v", tt.name, err)
+		}
+		if content, _ := os.ReadFile(dllsPath); string(content) != original {
+			t.Errorf("%s: dlls.txt after FinishBisect() = %q, want the original", tt.name, content)
+		}
+		if err := FinishBisect(gamePath); !errors.Is(err, ErrNoBisect) {
+			t.Errorf("%s: second FinishBisect() error = %v, want ErrNoBisect", tt.name, err)
+		}
+	}
+}
+
+func TestStartBisectWithoutMods(t *testing.T) {
+	gamePath := t.TempDir()
+	if err := os.WriteFile(filepath.Join(gamePath, dllstxt.FileName), []byte("mods/winerosetta.dll\n#mods/a.dll\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := StartBisect(gamePath, "turtlesilicon"); !errors.Is(err, ErrNothingToBisect) {
+		t.Errorf("StartBisect() error = %v, want ErrNothingToBisect", err)
+	}
+}
+
+func TestSplitSuspects(t *testing.T) {
+	suspects := []string{"mods/a.dll", "mods/b.dll", "mods/c.dll", "mods/d.dll"}
+
+	tests := []struct {
+		name     string
+		requires map[string][]string
+		want     []string
+	}{
+		{"independent mods", nil, []string{"mods/a.dll", "mods/b.dll"}},
+		{"requirement across the halves", map[string][]string{"mods/c.dll": {"mods/a.dll"}}, []string{"mods/a.dll", "mods/c.dll"}},
+		{"chain of requirements", map[string][]string{"mods/d.dll": {"mods/b.dll"}, "mods/b.dll": {"mods/a.dll"}}, []string{"mods/a.dll", "mods/b.dll", "mods/d.dll"}},
+		{"everything required together", map[string][]string{"mods/a.dll": {"mods/b.dll", "mods/c.dll", "mods/d.dll"}}, nil},
+	}
+
+	for _, tt := range tests {
+		if got := splitSuspects(suspects, tt.requires); strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("%s: splitSuspects() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBisectKeepsRequirements(t *testing.T) {
+	gamePath := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(gamePath, "mods"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(gamePath, dllstxt.FileName), []byte("mods/a.dll\nmods/b.dll\nmods/c.dll\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveModMetadata(filepath.Join(gamePath, "mods", "c.dll"), ModMetadata{Requires: []string{"a"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	session, err := StartBisect(gamePath, "turtlesilicon")
+	if err != nil {
+		t.Fatalf("StartBisect() error = %v", err)
+	}
+	if err := session.Report(gamePath, false); err != nil {
+		t.Fatalf("Report() error = %v", err)
+	}
+	if got := strings.Join(session.Testing, ","); got != "mods/a.dll,mods/c.dll" {
+		t.Errorf("Testing = %s, want c.dll together with the a.dll it requires", got)
+	}
+
+	if err := session.Report(gamePath, true); err != nil {
+		t.Fatalf("Report() error = %v", err)
+	}
+	if !session.Done() || session.Culprit != "" || !strings.Contains(session.Conclusion, "a.dll, c.dll") {
+		t.Errorf("after a crash with mods that require each other Culprit = %q, Conclusion = %q", session.Culprit, session.Conclusion)
+	}
+}
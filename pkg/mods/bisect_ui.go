@@ -0,0 +1,168 @@
+package mods
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"turtlesilicon/pkg/debug"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// showBisectPopup starts a crash bisect or shows the step of the running one
+func (mm *ModManager) showBisectPopup() {
+	currentVer, err := mm.versionManager.GetCurrentVersion()
+	if err != nil || currentVer == nil {
+		dialog.ShowError(fmt.Errorf("failed to get current version: %v", err), mm.window)
+		return
+	}
+	gamePath := currentVer.GamePath
+
+	session := LoadBisect(gamePath)
+	if session == nil {
+		message := "Find the mod that makes the game crash by launching it with fewer and fewer mods enabled.\n\n" +
+			"The game is first launched without any optional mod, then with half of the remaining suspects " +
+			"each time. After every launch, try to reproduce the crash and tell TurtleSilicon whether it happened.\n\n" +
+			"Your enabled mods are restored when the bisect ends."
+		dialog.ShowConfirm("Find Crashing Mod", message, func(confirmed bool) {
+			if !confirmed {
+				return
+			}
+			session, err := StartBisect(gamePath, currentVer.ID)
+			if err != nil {
+				if errors.Is(err, ErrNothingToBisect) {
+					dialog.ShowInformation("Find Crashing Mod", "No optional mods are enabled, so no mod can cause the crash.", mm.window)
+					return
+				}
+				dialog.ShowError(err, mm.window)
+				return
+			}
+			mm.refreshModManager()
+			mm.showBisectStep(session, gamePath)
+		}, mm.window)
+		return
+	}
+
+	if session.VersionID != currentVer.ID {
+		debug.Printf("Crash bisect of %s found in the game directory of %s", session.VersionID, currentVer.ID)
+	}
+	mm.showBisectStep(session, gamePath)
+}
+
+// showBisectStep shows the current step of a bisect, or its result once it is done
+func (mm *ModManager) showBisectStep(session *BisectSession, gamePath string) {
+	if session.Done() {
+		mm.finishBisect(session, gamePath)
+		return
+	}
+
+	var stepText string
+	if session.IsBaseline() {
+		stepText = fmt.Sprintf("Step 1: every optional mod is disabled (%d mods).", len(session.Candidates))
+	} else {
+		stepText = fmt.Sprintf("Step %d: %d of %d suspects are enabled:\n\n- %s",
+			session.Step, len(session.Testing), len(session.Suspects), strings.Join(bisectNames(session.Testing), "\n- "))
+	}
+	if len(session.Warnings) > 0 {
+		stepText += "\n\nThese enabled mods patch the same client code and may crash together:\n\n- " + strings.Join(session.Warnings, "\n- ")
+	}
+	stepText += "\n\nLaunch the game and try to make it crash, then close it and report what happened."
+
+	stepLabel := widget.NewLabel(stepText)
+	stepLabel.Wrapping = fyne.TextWrapWord
+
+	var stepDialog dialog.Dialog
+	report := func(crashed bool) {
+		stepDialog.Hide()
+		if err := session.Report(gamePath, crashed); err != nil {
+			dialog.ShowError(err, mm.window)
+			return
+		}
+		mm.refreshModManager()
+		mm.showBisectStep(session, gamePath)
+	}
+
+	launchButton := widget.NewButton("Launch Game", func() {
+		if mm.launchGame == nil {
+			dialog.ShowInformation("Launch Game", "Launch the game from the main window.", mm.window)
+			return
+		}
+		if err := session.MarkLaunched(gamePath); err != nil {
+			debug.Printf("Failed to record crash bisect launch: %v", err)
+		}
+		mm.launchGame()
+	})
+	launchButton.Importance = widget.HighImportance
+
+	crashedButton := widget.NewButton("It Crashed", func() {
+		report(true)
+	})
+	crashedButton.Importance = widget.DangerImportance
+
+	workedButton := widget.NewButton("No Crash", func() {
+		report(false)
+	})
+
+	detectButton := widget.NewButton("Check for Crash Log", func() {
+		if crashLog, found := DetectCrash(gamePath, session.StepStartedAt); found {
+			dialog.ShowConfirm("Crash Detected", fmt.Sprintf("The game wrote a crash report:\n%s\n\nCount this step as a crash?", filepath.Base(crashLog)), func(confirmed bool) {
+				if confirmed {
+					report(true)
+				}
+			}, mm.window)
+			return
+		}
+		dialog.ShowConfirm("No Crash Log", "The game wrote no crash report since it was launched. Did it crash anyway?", func(crashed bool) {
+			report(crashed)
+		}, mm.window)
+	})
+
+	stopButton := widget.NewButton("Stop and Restore", func() {
+		stepDialog.Hide()
+		mm.finishBisect(nil, gamePath)
+	})
+	stopButton.Importance = widget.LowImportance
+
+	content := container.NewVBox(
+		stepLabel,
+		container.NewHBox(launchButton, detectButton),
+		container.NewHBox(crashedButton, workedButton, stopButton),
+	)
+
+	stepDialog = dialog.NewCustomWithoutButtons("Find Crashing Mod", content, mm.window)
+	stepDialog.Resize(fyne.NewSize(500, 350))
+	stepDialog.Show()
+}
+
+// finishBisect restores dlls.txt and shows the result of the bisect, if it found one
+func (mm *ModManager) finishBisect(session *BisectSession, gamePath string) {
+	if err := FinishBisect(gamePath); err != nil {
+		dialog.ShowError(fmt.Errorf("failed to restore dlls.txt: %v", err), mm.window)
+		return
+	}
+	mm.refreshModManager()
+
+	if session == nil {
+		dialog.ShowInformation("Find Crashing Mod", "Stopped. Your enabled mods were restored.", mm.window)
+		return
+	}
+	message := session.Conclusion + "\n\nYour enabled mods were restored."
+	if session.Culprit != "" {
+		message += " Disable the mod, or check for an update of it, to stop the crash."
+	}
+	dialog.ShowInformation("Find Crashing Mod", message, mm.window)
+}
+
+// bisectNames returns the file names of dlls.txt entries
+func bisectNames(entries []string) []string {
+	names := make([]string, len(entries))
+	for i, entry := range entries {
+		names[i] = filepath.Base(strings.ReplaceAll(entry, "\\", "/"))
+	}
+	return names
+}
@@ -0,0 +1,433 @@
+package mods
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"turtlesilicon/pkg/backup"
+	"turtlesilicon/pkg/components"
+	"turtlesilicon/pkg/debug"
+	"turtlesilicon/pkg/dllstxt"
+	"turtlesilicon/pkg/utils"
+)
+
+// installedFileName records which catalog mods were installed into the mods directory
+const installedFileName = "installed_mods.json"
+
+var (
+	ErrNoCatalog        = errors.New("no mod catalog URL is set")
+	ErrChecksumMismatch = errors.New("checksum mismatch")
+	ErrNotInstalled     = errors.New("mod was not installed from the catalog")
+)
+
+// Catalog lists the DLL mods that can be installed from the catalog index
+type Catalog struct {
+	Mods []CatalogMod `json:"mods"`
+}
+
+// CatalogMod is one mod of the catalog
+type CatalogMod struct {
+	ID           string           `json:"id"`
+	Name         string           `json:"name"`
+	Description  string           `json:"description"`
+	Author       string           `json:"author"`
+	Homepage     string           `json:"homepage,omitempty"`
+	FileName     string           `json:"file"`          // Name of the DLL in mods/
+	GameVersions []string         `json:"game_versions"` // Compatible game version IDs, all if empty
+	Requires     []string         `json:"requires,omitempty"`
+	Conflicts    []string         `json:"conflicts,omitempty"`
+	Hooks        []string         `json:"hooks,omitempty"`
+	Versions     []CatalogRelease `json:"versions"`
+}
+
+// CatalogRelease is a single published build of a catalog mod
+type CatalogRelease struct {
+	Version string `json:"version"`
+	URL     string `json:"url"`
+	SHA256  string `json:"sha256"`
+	Size    int64  `json:"size,omitempty"`
+	Notes   string `json:"notes,omitempty"`
+}
+
+// InstalledCatalogMod records a mod installed from the catalog
+type InstalledCatalogMod struct {
+	ID          string    `json:"id"`
+	FileName    string    `json:"file"`
+	Version     string    `json:"version"`
+	SHA256      string    `json:"sha256"`
+	InstalledAt time.Time `json:"installed_at"`
+}
+
+// CatalogURL returns the configured catalog URL, "" if none is set. There is no default catalog,
+// the mod manager only offers the catalog once a URL is set.
+func CatalogURL() string {
+	if prefs, err := utils.LoadPrefs(); err == nil {
+		return prefs.ModCatalogURL
+	}
+	return ""
+}
+
+// SetCatalogURL stores the catalog URL. An empty URL removes the catalog.
+func SetCatalogURL(url string) error {
+	prefs, err := utils.LoadPrefs()
+	if err != nil {
+		return err
+	}
+	prefs.ModCatalogURL = strings.TrimSpace(url)
+	return utils.SavePrefs(prefs)
+}
+
+// validCatalogFileName keeps catalog mods inside mods/ and limited to DLLs
+func validCatalogFileName(name string) bool {
+	return name != "" && name == filepath.Base(name) && !strings.ContainsAny(name, "/\\") &&
+		strings.HasSuffix(strings.ToLower(name), ".dll")
+}
+
+// FetchCatalog downloads and parses the mod catalog
+func FetchCatalog(url string) (*Catalog, error) {
+	if url == "" {
+		return nil, ErrNoCatalog
+	}
+
+	body, err := fetch(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download mod catalog: %v", err)
+	}
+
+	var catalog Catalog
+	if err := json.Unmarshal(body, &catalog); err != nil {
+		return nil, fmt.Errorf("failed to parse mod catalog: %v", err)
+	}
+
+	// Drop anything that could not be installed safely so callers only see usable releases
+	var valid []CatalogMod
+	for _, mod := range catalog.Mods {
+		if mod.ID == "" || !validCatalogFileName(mod.FileName) {
+			debug.Printf("Skipping catalog mod %q: invalid id or file name %q", mod.ID, mod.FileName)
+			continue
+		}
+		var releases []CatalogRelease
+		for _, release := range mod.Versions {
+			if release.Version != "" && release.URL != "" && release.SHA256 != "" {
+				releases = append(releases, release)
+			}
+		}
+		if len(releases) == 0 {
+			continue
+		}
+		if mod.Name == "" {
+			mod.Name = mod.FileName
+		}
+		mod.Versions = releases
+		valid = append(valid, mod)
+	}
+	catalog.Mods = valid
+	return &catalog, nil
+}
+
+// ForVersion returns the mods compatible with a game version
+func (c *Catalog) ForVersion(versionID string) []CatalogMod {
+	var compatible []CatalogMod
+	for _, mod := range c.Mods {
+		if mod.SupportsVersion(versionID) {
+			compatible = append(compatible, mod)
+		}
+	}
+	return compatible
+}
+
+// Find returns the catalog mod with the given ID
+func (c *Catalog) Find(id string) (CatalogMod, bool) {
+	for _, mod := range c.Mods {
+		if mod.ID == id {
+			return mod, true
+		}
+	}
+	return CatalogMod{}, false
+}
+
+// SupportsVersion returns true if the mod can be used with a game version
+func (m CatalogMod) SupportsVersion(versionID string) bool {
+	if len(m.GameVersions) == 0 {
+		return true
+	}
+	for _, id := range m.GameVersions {
+		if id == versionID {
+			return true
+		}
+	}
+	return false
+}
+
+// Latest returns the newest release of the mod
+func (m CatalogMod) Latest() (CatalogRelease, bool) {
+	var latest CatalogRelease
+	found := false
+	for _, release := range m.Versions {
+		if !found || components.CompareVersions(release.Version, latest.Version) > 0 {
+			latest = release
+			found = true
+		}
+	}
+	return latest, found
+}
+
+// HasUpdate returns true if the catalog has a newer release than the installed one
+func (m CatalogMod) HasUpdate(installed InstalledCatalogMod) bool {
+	latest, ok := m.Latest()
+	return ok && components.CompareVersions(latest.Version, installed.Version) > 0
+}
+
+func installedRecordsPath(gamePath string) string {
+	return filepath.Join(gamePath, "mods", installedFileName)
+}
+
+// LoadInstalledCatalogMods returns the catalog mods installed in a game directory keyed by ID
+func LoadInstalledCatalogMods(gamePath string) map[string]InstalledCatalogMod {
+	installed := make(map[string]InstalledCatalogMod)
+	data, err := os.ReadFile(installedRecordsPath(gamePath))
+	if err != nil {
+		return installed
+	}
+	var records []InstalledCatalogMod
+	if err := json.Unmarshal(data, &records); err != nil {
+		debug.Printf("Failed to parse %s: %v", installedFileName, err)
+		return installed
+	}
+	for _, record := range records {
+		installed[record.ID] = record
+	}
+	return installed
+}
+
+func saveInstalledCatalogMods(gamePath string, installed map[string]InstalledCatalogMod) error {
+	records := make([]InstalledCatalogMod, 0, len(installed))
+	for _, record := range installed {
+		records = append(records, record)
+	}
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode installed mods: %v", err)
+	}
+	if err := os.WriteFile(installedRecordsPath(gamePath), data, 0644); err != nil {
+		return fmt.Errorf("failed to save installed mods: %v", err)
+	}
+	return nil
+}
+
+// InstallCatalogMod downloads a release of a catalog mod into mods/, verifies its SHA-256 and
+// that it is a loadable i386 DLL, and only then registers it in dlls.txt. A new mod is only
+// installed if its requires and conflicts rules allow enabling it. An existing file of the same
+// name is kept in the backup store. Updating a mod keeps its enabled state and load order. It
+// returns warnings about enabled mods that patch the same code as the installed one.
+func InstallCatalogMod(gamePath string, mod CatalogMod, release CatalogRelease) ([]string, error) {
+	if gamePath == "" {
+		return nil, fmt.Errorf("game path not set")
+	}
+	if !validCatalogFileName(mod.FileName) {
+		return nil, fmt.Errorf("invalid mod file name %q", mod.FileName)
+	}
+
+	metadata := ModMetadata{
+		Name:         mod.Name,
+		Version:      release.Version,
+		Author:       mod.Author,
+		Homepage:     mod.Homepage,
+		Description:  mod.Description,
+		GameVersions: mod.GameVersions,
+		Requires:     mod.Requires,
+		Conflicts:    mod.Conflicts,
+		Hooks:        mod.Hooks,
+	}
+
+	dllsPath := filepath.Join(gamePath, dllstxt.FileName)
+	dlls, err := dllstxt.Load(dllsPath)
+	if err != nil {
+		return nil, err
+	}
+	listed, _ := dlls.State(modEntry(mod.FileName))
+	if !listed {
+		// Same checks as enabling the mod in the mod manager, before anything is downloaded
+		candidate := &Mod{Name: mod.FileName, Metadata: metadata}
+		if err := checkEnableRules(candidate, modsInDirectory(gamePath, dlls)); err != nil {
+			return nil, fmt.Errorf("cannot install %s: %v", mod.Name, err)
+		}
+	}
+
+	content, err := fetch(release.URL)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(content)
+	hash := hex.EncodeToString(sum[:])
+	if !strings.EqualFold(hash, release.SHA256) {
+		return nil, fmt.Errorf("%w for %s %s: expected %s, got %s", ErrChecksumMismatch, mod.Name, release.Version, release.SHA256, hash)
+	}
+
+	modsPath := filepath.Join(gamePath, "mods")
+	if err := os.MkdirAll(modsPath, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create mods directory: %v", err)
+	}
+	modPath := filepath.Join(modsPath, mod.FileName)
+
+	// Write next to the target and rename so an interrupted install never leaves half a DLL
+	tempPath := modPath + ".download"
+	if err := os.WriteFile(tempPath, content, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %v", mod.FileName, err)
+	}
+	if err := ValidateModDLL(tempPath, gamePath); err != nil {
+		os.Remove(tempPath)
+		return nil, fmt.Errorf("%s %s: %v", mod.Name, release.Version, err)
+	}
+	if err := backup.SaveFile(gamePath, modPath, backup.OpModManager); err != nil {
+		os.Remove(tempPath)
+		return nil, fmt.Errorf("failed to back up %s: %v", mod.FileName, err)
+	}
+	if err := os.Rename(tempPath, modPath); err != nil {
+		os.Remove(tempPath)
+		return nil, fmt.Errorf("failed to install %s: %v", mod.FileName, err)
+	}
+
+	if err := SaveModMetadata(modPath, metadata); err != nil {
+		debug.Printf("Warning: %v", err)
+	}
+
+	if !listed {
+		dlls.Set(modEntry(mod.FileName), true)
+		if err := backup.SaveFile(gamePath, dllsPath, backup.OpModManager); err != nil {
+			return nil, fmt.Errorf("failed to back up dlls.txt: %v", err)
+		}
+		if err := dlls.Save(dllsPath); err != nil {
+			return nil, err
+		}
+	}
+
+	installed := LoadInstalledCatalogMods(gamePath)
+	installed[mod.ID] = InstalledCatalogMod{
+		ID:          mod.ID,
+		FileName:    mod.FileName,
+		Version:     release.Version,
+		SHA256:      hash,
+		InstalledAt: time.Now(),
+	}
+	if err := saveInstalledCatalogMods(gamePath, installed); err != nil {
+		return nil, err
+	}
+
+	debug.Printf("Installed catalog mod %s %s as %s (sha256 %s)", mod.ID, release.Version, modPath, hash)
+
+	// Like ticking the mod in the mod manager, warn about enabled mods that patch the same code
+	if !dlls.IsEnabled(modEntry(mod.FileName)) {
+		return nil, nil
+	}
+	installedMod := &Mod{Name: mod.FileName, Path: modPath, Enabled: true, Metadata: metadata, Analysis: AnalyzeModDLL(modPath, metadata)}
+	return modOverlapWarnings(installedMod, modsInDirectory(gamePath, dlls)), nil
+}
+
+// modsInDirectory returns the DLLs in mods/ with their dlls.txt state, metadata and analysis,
+// enough to check the rules and overlaps of mods outside the mod manager
+func modsInDirectory(gamePath string, dlls *dllstxt.File) []Mod {
+	modsPath := filepath.Join(gamePath, "mods")
+	entries, err := os.ReadDir(modsPath)
+	if err != nil {
+		return nil
+	}
+
+	var mods []Mod
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(strings.ToLower(entry.Name()), ".dll") {
+			continue
+		}
+		modPath := filepath.Join(modsPath, entry.Name())
+		metadata := LoadModMetadata(modPath)
+		mods = append(mods, Mod{
+			Name:     entry.Name(),
+			Path:     modPath,
+			Enabled:  dlls.IsEnabled(modEntry(entry.Name())),
+			Metadata: metadata,
+			Analysis: AnalyzeModDLL(modPath, metadata),
+		})
+	}
+	return mods
+}
+
+// UninstallCatalogMod removes a mod installed from the catalog and its dlls.txt entry. The DLL
+// is kept in the backup store.
+func UninstallCatalogMod(gamePath string, id string) error {
+	installed := LoadInstalledCatalogMods(gamePath)
+	record, ok := installed[id]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrNotInstalled, id)
+	}
+
+	modPath := filepath.Join(gamePath, "mods", record.FileName)
+	if err := backup.SaveFile(gamePath, modPath, backup.OpModManager); err != nil {
+		return fmt.Errorf("failed to back up %s: %v", record.FileName, err)
+	}
+	if err := os.Remove(modPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete %s: %v", record.FileName, err)
+	}
+	os.Remove(MetadataPath(modPath))
+
+	dllsPath := filepath.Join(gamePath, dllstxt.FileName)
+	dlls, err := dllstxt.Load(dllsPath)
+	if err != nil {
+		return err
+	}
+	if dlls.Remove(modEntry(record.FileName)) {
+		if err := backup.SaveFile(gamePath, dllsPath, backup.OpModManager); err != nil {
+			return fmt.Errorf("failed to back up dlls.txt: %v", err)
+		}
+		if err := dlls.Save(dllsPath); err != nil {
+			return err
+		}
+	}
+
+	delete(installed, id)
+	if err := saveInstalledCatalogMods(gamePath, installed); err != nil {
+		return err
+	}
+
+	debug.Printf("Uninstalled catalog mod %s (%s)", id, record.FileName)
+	return nil
+}
+
+// forgetCatalogMod drops the install record of a mod file that was deleted outside the catalog
+func forgetCatalogMod(gamePath string, fileName string) error {
+	installed := LoadInstalledCatalogMods(gamePath)
+	for id, record := range installed {
+		if strings.EqualFold(record.FileName, fileName) {
+			delete(installed, id)
+			return saveInstalledCatalogMods(gamePath, installed)
+		}
+	}
+	return nil
+}
+
+// fetch downloads a small file into memory
+func fetch(url string) ([]byte, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download from %s: %v", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("server returned status %d for %s", resp.StatusCode, url)
+	}
+
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download from %s: %v", url, err)
+	}
+	return content, nil
+}
@@ -0,0 +1,67 @@
+package mods
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"turtlesilicon/pkg/dllstxt"
+)
+
+func TestCheckEnableRules(t *testing.T) {
+	installed := []Mod{
+		{Name: "base.dll", Enabled: true},
+		{Name: "off.dll", Enabled: false},
+		{Name: "rival.dll", Enabled: true, Metadata: ModMetadata{Conflicts: []string{"fresh"}}},
+	}
+
+	tests := []struct {
+		name     string
+		metadata ModMetadata
+		wantErr  bool
+	}{
+		{"no rules", ModMetadata{}, false},
+		{"requires enabled mod", ModMetadata{Requires: []string{"mods/base.dll"}}, false},
+		{"requires disabled mod", ModMetadata{Requires: []string{"off.dll"}}, true},
+		{"requires missing mod", ModMetadata{Requires: []string{"missing"}}, true},
+		{"conflicts with enabled mod", ModMetadata{Conflicts: []string{"base.dll"}}, true},
+		{"conflicts with disabled mod", ModMetadata{Conflicts: []string{"off.dll"}}, false},
+	}
+
+	for _, tt := range tests {
+		mod := &Mod{Name: "new.dll", Metadata: tt.metadata}
+		if err := checkEnableRules(mod, installed); (err != nil) != tt.wantErr {
+			t.Errorf("%s: checkEnableRules() error = %v, want error %v", tt.name, err, tt.wantErr)
+		}
+	}
+
+	// A conflict listed by the enabled mod counts as well
+	if err := checkEnableRules(&Mod{Name: "fresh.dll"}, installed); err == nil {
+		t.Errorf("checkEnableRules() = nil for a mod an enabled mod conflicts with, want error")
+	}
+}
+
+func TestModsInDirectory(t *testing.T) {
+	gamePath := t.TempDir()
+	modsPath := filepath.Join(gamePath, "mods")
+	if err := os.MkdirAll(filepath.Join(modsPath, "folder.dll"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"one.dll", "two.DLL", "notes.txt"} {
+		if err := os.WriteFile(filepath.Join(modsPath, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dlls := dllstxt.Parse("mods/one.dll\n#mods/two.DLL\n")
+	mods := modsInDirectory(gamePath, dlls)
+	if len(mods) != 2 {
+		t.Fatalf("modsInDirectory() returned %d mods, want 2", len(mods))
+	}
+	want := map[string]bool{"one.dll": true, "two.DLL": false}
+	for _, mod := range mods {
+		if enabled, ok := want[mod.Name]; !ok || mod.Enabled != enabled {
+			t.Errorf("modsInDirectory() mod %s enabled = %v, want %v", mod.Name, mod.Enabled, enabled)
+		}
+	}
+}
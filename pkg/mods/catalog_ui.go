@@ -0,0 +1,201 @@
+package mods
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"turtlesilicon/pkg/debug"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// showCatalogPopup lets the user browse the mod catalog and install, update or uninstall mods
+func (mm *ModManager) showCatalogPopup() {
+	currentVer, err := mm.versionManager.GetCurrentVersion()
+	if err != nil || currentVer == nil || currentVer.GamePath == "" {
+		dialog.ShowError(fmt.Errorf("game path not set"), mm.window)
+		return
+	}
+	gamePath := currentVer.GamePath
+	versionID := currentVer.ID
+
+	titleLabel := widget.NewLabel("Mod Catalog")
+	titleLabel.TextStyle = fyne.TextStyle{Bold: true}
+
+	urlEntry := widget.NewEntry()
+	urlEntry.SetText(CatalogURL())
+
+	statusLabel := widget.NewLabel("")
+	statusLabel.TextStyle = fyne.TextStyle{Italic: true}
+
+	rows := container.NewVBox()
+	var catalog *Catalog
+	var busy bool
+	var showRows func()
+
+	// run performs a catalog operation in the background and redraws the list afterwards
+	run := func(message string, operation func() error) {
+		if busy {
+			return
+		}
+		busy = true
+		statusLabel.SetText(message)
+		go func() {
+			err := operation()
+			fyne.Do(func() {
+				busy = false
+				statusLabel.SetText("")
+				if err != nil {
+					debug.Printf("Catalog operation failed: %v", err)
+					dialog.ShowError(err, mm.window)
+				}
+				showRows()
+			})
+		}()
+	}
+
+	showRows = func() {
+		mm.showCatalogRows(rows, catalog, gamePath, versionID, run)
+	}
+
+	loadCatalog := func() {
+		url := urlEntry.Text
+		run("Loading catalog...", func() error {
+			if err := SetCatalogURL(url); err != nil {
+				debug.Printf("Failed to save catalog URL: %v", err)
+			}
+			loaded, err := FetchCatalog(url)
+			if err != nil {
+				return err
+			}
+			catalog = loaded
+			return nil
+		})
+	}
+
+	loadButton := widget.NewButton("Load", func() {
+		loadCatalog()
+	})
+	loadButton.Importance = widget.MediumImportance
+
+	closeButton := widget.NewButton("Close", func() {})
+
+	content := container.NewBorder(
+		container.NewVBox(
+			titleLabel,
+			container.NewBorder(nil, nil, widget.NewLabel("Catalog URL:"), loadButton, urlEntry),
+			statusLabel,
+			widget.NewSeparator(),
+		),
+		container.NewHBox(closeButton),
+		nil,
+		nil,
+		container.NewVScroll(rows),
+	)
+
+	popup := widget.NewModalPopUp(container.NewPadded(content), mm.window.Canvas())
+	closeButton.OnTapped = func() {
+		popup.Hide()
+		mm.refreshModManager()
+	}
+
+	canvasSize := mm.window.Canvas().Size()
+	popup.Resize(fyne.NewSize(canvasSize.Width*0.8, canvasSize.Height*0.8))
+	popup.Show()
+
+	showRows()
+	loadCatalog()
+}
+
+// showCatalogRows fills the catalog list with one row per mod compatible with the game version
+func (mm *ModManager) showCatalogRows(rows *fyne.Container, catalog *Catalog, gamePath, versionID string, run func(string, func() error)) {
+	rows.RemoveAll()
+	if catalog == nil {
+		rows.Refresh()
+		return
+	}
+
+	compatible := catalog.ForVersion(versionID)
+	if len(compatible) == 0 {
+		rows.Add(widget.NewLabel("The catalog has no mods for this game version."))
+		rows.Refresh()
+		return
+	}
+
+	installed := LoadInstalledCatalogMods(gamePath)
+	for _, mod := range compatible {
+		mod := mod
+		latest, _ := mod.Latest()
+		record, isInstalled := installed[mod.ID]
+
+		title := fmt.Sprintf("%s %s", mod.Name, latest.Version)
+		if mod.Author != "" {
+			title += " by " + mod.Author
+		}
+		nameLabel := widget.NewLabel(title)
+		nameLabel.TextStyle = fyne.TextStyle{Bold: true}
+
+		stateText := "Not installed"
+		if isInstalled {
+			stateText = "Installed: " + record.Version
+			if mod.HasUpdate(record) {
+				stateText += " (update available)"
+			}
+		}
+		stateLabel := widget.NewLabel(fmt.Sprintf("%s | %s", filepath.Join("mods", mod.FileName), stateText))
+		stateLabel.TextStyle = fyne.TextStyle{Italic: true}
+
+		descriptionLabel := widget.NewLabel(mod.Description)
+		descriptionLabel.Wrapping = fyne.TextWrapWord
+
+		install := func() error {
+			warnings, err := InstallCatalogMod(gamePath, mod, latest)
+			fyne.Do(func() {
+				mm.showOverlapWarnings(fmt.Sprintf("%s may conflict with enabled mods:", mod.Name), warnings)
+			})
+			return err
+		}
+
+		var buttons []fyne.CanvasObject
+		switch {
+		case !isInstalled:
+			installButton := widget.NewButton("Install", func() {
+				run(fmt.Sprintf("Installing %s...", mod.Name), install)
+			})
+			installButton.Importance = widget.HighImportance
+			buttons = append(buttons, installButton)
+		case mod.HasUpdate(record):
+			updateButton := widget.NewButton("Update", func() {
+				run(fmt.Sprintf("Updating %s...", mod.Name), install)
+			})
+			updateButton.Importance = widget.HighImportance
+			buttons = append(buttons, updateButton)
+		}
+		if isInstalled {
+			uninstallButton := widget.NewButton("Uninstall", func() {
+				dialog.ShowConfirm("Uninstall Mod", fmt.Sprintf("Uninstall %s and remove it from dlls.txt?", mod.Name), func(confirmed bool) {
+					if confirmed {
+						run(fmt.Sprintf("Uninstalling %s...", mod.Name), func() error {
+							return UninstallCatalogMod(gamePath, mod.ID)
+						})
+					}
+				}, mm.window)
+			})
+			uninstallButton.Importance = widget.MediumImportance
+			buttons = append(buttons, uninstallButton)
+		}
+
+		rows.Add(container.NewBorder(
+			nil,
+			nil,
+			nil,
+			container.NewHBox(buttons...),
+			container.NewVBox(nameLabel, stateLabel, descriptionLabel),
+		))
+		rows.Add(widget.NewSeparator())
+	}
+	rows.Refresh()
+}
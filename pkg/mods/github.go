@@ -0,0 +1,239 @@
+package mods
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"turtlesilicon/pkg/backup"
+	"turtlesilicon/pkg/components"
+	"turtlesilicon/pkg/debug"
+)
+
+// gitHubAPIURL is the GitHub REST API the release checks use
+var gitHubAPIURL = "https://api.github.com"
+
+var (
+	ErrNotLinked       = errors.New("mod is not linked to a GitHub repository")
+	ErrNoMatchingAsset = errors.New("release has no matching asset")
+)
+
+// GitHubRelease is a release of a GitHub repository as returned by the releases API
+type GitHubRelease struct {
+	TagName string        `json:"tag_name"`
+	Name    string        `json:"name"`
+	Body    string        `json:"body"` // Release notes
+	HTMLURL string        `json:"html_url"`
+	Assets  []GitHubAsset `json:"assets"`
+}
+
+// GitHubAsset is a file attached to a GitHub release
+type GitHubAsset struct {
+	Name               string `json:"name"`
+	BrowserDownloadURL string `json:"browser_download_url"`
+	Size               int64  `json:"size"`
+	Digest             string `json:"digest,omitempty"` // "sha256:<hex>", missing for older releases
+}
+
+// FindAsset returns the first asset whose name matches the glob pattern, ignoring case
+func (r *GitHubRelease) FindAsset(pattern string) (GitHubAsset, bool) {
+	pattern = strings.ToLower(pattern)
+	for _, asset := range r.Assets {
+		if matched, err := path.Match(pattern, strings.ToLower(asset.Name)); err == nil && matched {
+			return asset, true
+		}
+	}
+	return GitHubAsset{}, false
+}
+
+// ParseRepository accepts "owner/name" or a github.com URL and returns "owner/name"
+func ParseRepository(repository string) (string, error) {
+	repo := strings.TrimSpace(repository)
+	repo = strings.TrimPrefix(repo, "https://")
+	repo = strings.TrimPrefix(repo, "http://")
+	repo = strings.TrimPrefix(repo, "www.")
+	repo = strings.TrimPrefix(repo, "github.com/")
+	repo = strings.TrimSuffix(strings.TrimSuffix(repo, "/"), ".git")
+
+	// GitHub owners cannot contain dots, so a dot means the URL is of another host
+	parts := strings.Split(repo, "/")
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" || strings.Contains(parts[0], ".") {
+		return "", fmt.Errorf("invalid GitHub repository %q, expected owner/name", repository)
+	}
+	return parts[0] + "/" + parts[1], nil
+}
+
+// LinkModToGitHub stores the repository and asset pattern of a mod in its metadata file. An empty
+// repository removes the link.
+func LinkModToGitHub(gamePath string, fileName string, repository string, assetPattern string) error {
+	modPath := filepath.Join(gamePath, "mods", fileName)
+	metadata := LoadModMetadata(modPath)
+
+	if strings.TrimSpace(repository) == "" {
+		metadata.Repository = ""
+		metadata.AssetPattern = ""
+		return SaveModMetadata(modPath, metadata)
+	}
+
+	repo, err := ParseRepository(repository)
+	if err != nil {
+		return err
+	}
+	assetPattern = strings.TrimSpace(assetPattern)
+	if assetPattern == "" {
+		assetPattern = fileName
+	}
+	if _, err := path.Match(assetPattern, ""); err != nil {
+		return fmt.Errorf("invalid asset pattern %q: %v", assetPattern, err)
+	}
+
+	metadata.Repository = repo
+	metadata.AssetPattern = assetPattern
+	if err := SaveModMetadata(modPath, metadata); err != nil {
+		return err
+	}
+	debug.Printf("Linked %s to github.com/%s (asset %s)", fileName, repo, assetPattern)
+	return nil
+}
+
+// FetchLatestGitHubRelease returns the latest release of a repository
+func FetchLatestGitHubRelease(repository string) (*GitHubRelease, error) {
+	body, err := fetch(fmt.Sprintf("%s/repos/%s/releases/latest", gitHubAPIURL, repository))
+	if err != nil {
+		return nil, fmt.Errorf("failed to check releases of %s: %v", repository, err)
+	}
+	var release GitHubRelease
+	if err := json.Unmarshal(body, &release); err != nil {
+		return nil, fmt.Errorf("failed to parse release of %s: %v", repository, err)
+	}
+	if release.TagName == "" {
+		return nil, fmt.Errorf("latest release of %s has no tag", repository)
+	}
+	return &release, nil
+}
+
+// linkedMetadata returns the path and metadata of a mod linked to a GitHub repository. Without
+// an asset pattern the asset must be named like the DLL.
+func linkedMetadata(gamePath string, fileName string) (string, ModMetadata, error) {
+	modPath := filepath.Join(gamePath, "mods", fileName)
+	metadata := LoadModMetadata(modPath)
+	if metadata.Repository == "" {
+		return "", metadata, fmt.Errorf("%w: %s", ErrNotLinked, fileName)
+	}
+	if metadata.AssetPattern == "" {
+		metadata.AssetPattern = fileName
+	}
+	return modPath, metadata, nil
+}
+
+// CheckGitHubUpdate fetches the latest release of a linked mod. It reports an update if the
+// release tag is newer than the installed version, or the installed version is unknown.
+func CheckGitHubUpdate(gamePath string, fileName string) (*GitHubRelease, bool, error) {
+	_, metadata, err := linkedMetadata(gamePath, fileName)
+	if err != nil {
+		return nil, false, err
+	}
+	release, err := FetchLatestGitHubRelease(metadata.Repository)
+	if err != nil {
+		return nil, false, err
+	}
+	if _, ok := release.FindAsset(metadata.AssetPattern); !ok {
+		return release, false, fmt.Errorf("%w: %s has no asset matching %s", ErrNoMatchingAsset, release.TagName, metadata.AssetPattern)
+	}
+	newer := metadata.Version == "" || components.CompareVersions(release.TagName, metadata.Version) > 0
+	return release, newer, nil
+}
+
+// UpdateModFromGitHub replaces a linked mod's DLL with the one of a release. The asset is checked
+// against the size and digest GitHub reports and must be a valid mod DLL before the old DLL is
+// moved to the backup store. dlls.txt is not touched, so the mod keeps its state and load order.
+func UpdateModFromGitHub(gamePath string, fileName string, release *GitHubRelease) error {
+	modPath, metadata, err := linkedMetadata(gamePath, fileName)
+	if err != nil {
+		return err
+	}
+	asset, ok := release.FindAsset(metadata.AssetPattern)
+	if !ok {
+		return fmt.Errorf("%w: %s has no asset matching %s", ErrNoMatchingAsset, release.TagName, metadata.AssetPattern)
+	}
+
+	content, err := fetch(asset.BrowserDownloadURL)
+	if err != nil {
+		return err
+	}
+	if asset.Size > 0 && int64(len(content)) != asset.Size {
+		return fmt.Errorf("download of %s is incomplete: expected %d bytes, got %d", asset.Name, asset.Size, len(content))
+	}
+	if expected, ok := strings.CutPrefix(asset.Digest, "sha256:"); ok {
+		sum := sha256.Sum256(content)
+		if hash := hex.EncodeToString(sum[:]); !strings.EqualFold(hash, expected) {
+			return fmt.Errorf("%w for %s: expected %s, got %s", ErrChecksumMismatch, asset.Name, expected, hash)
+		}
+	}
+
+	// Release assets are often zip files with the DLL inside
+	if strings.HasSuffix(strings.ToLower(asset.Name), ".zip") {
+		content, err = extractDLLFromZip(content, fileName)
+		if err != nil {
+			return fmt.Errorf("failed to extract %s from %s: %v", fileName, asset.Name, err)
+		}
+	}
+
+	// Validate next to the target so imports resolve against the game directory, then rename
+	tempPath := modPath + ".download"
+	if err := os.WriteFile(tempPath, content, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %v", fileName, err)
+	}
+	if err := ValidateModDLL(tempPath, gamePath); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("%s %s: %v", fileName, release.TagName, err)
+	}
+	if err := backup.SaveFile(gamePath, modPath, backup.OpModManager); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("failed to back up %s: %v", fileName, err)
+	}
+	if err := os.Rename(tempPath, modPath); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("failed to install %s: %v", fileName, err)
+	}
+
+	metadata.Version = release.TagName
+	if metadata.Homepage == "" {
+		metadata.Homepage = "https://github.com/" + metadata.Repository
+	}
+	if err := SaveModMetadata(modPath, metadata); err != nil {
+		debug.Printf("Warning: %v", err)
+	}
+
+	debug.Printf("Updated %s to %s from github.com/%s (%s)", fileName, release.TagName, metadata.Repository, asset.Name)
+	return nil
+}
+
+// extractDLLFromZip returns the file named fileName from a zip archive, in any of its folders
+func extractDLLFromZip(content []byte, fileName string) ([]byte, error) {
+	archive, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range archive.File {
+		if file.FileInfo().IsDir() || !strings.EqualFold(path.Base(file.Name), fileName) {
+			continue
+		}
+		reader, err := file.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer reader.Close()
+		return io.ReadAll(reader)
+	}
+	return nil, fmt.Errorf("archive does not contain %s", fileName)
+}
@@ -0,0 +1,103 @@
+package mods
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+)
+
+func TestParseRepository(t *testing.T) {
+	tests := []struct {
+		repository string
+		want       string
+		wantErr    bool
+	}{
+		{"owner/name", "owner/name", false},
+		{" owner/name/ ", "owner/name", false},
+		{"https://github.com/owner/name", "owner/name", false},
+		{"http://github.com/owner/name.git", "owner/name", false},
+		{"https://www.github.com/owner/name/releases", "owner/name", false},
+		{"github.com/owner/name.js", "owner/name.js", false},
+		{"https://gitlab.com/owner/name", "", true},
+		{"owner", "", true},
+		{"https://github.com/owner", "", true},
+		{"/name", "", true},
+		{"", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseRepository(tt.repository)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseRepository(%q) = %q, %v, want %q, error %v", tt.repository, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestFindAsset(t *testing.T) {
+	release := &GitHubRelease{Assets: []GitHubAsset{
+		{Name: "Mod-1.2-win64.zip"},
+		{Name: "Mod-1.2-win32.zip"},
+		{Name: "mod.dll"},
+	}}
+
+	tests := []struct {
+		pattern string
+		want    string
+		wantOK  bool
+	}{
+		{"*.dll", "mod.dll", true},
+		{"MOD.DLL", "mod.dll", true},
+		{"*win32*.zip", "Mod-1.2-win32.zip", true},
+		{"*.zip", "Mod-1.2-win64.zip", true}, // The first match wins
+		{"*.tar.gz", "", false},
+		{"[", "", false},
+	}
+
+	for _, tt := range tests {
+		asset, ok := release.FindAsset(tt.pattern)
+		if asset.Name != tt.want || ok != tt.wantOK {
+			t.Errorf("FindAsset(%q) = %q, %v, want %q, %v", tt.pattern, asset.Name, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestExtractDLLFromZip(t *testing.T) {
+	var buf bytes.Buffer
+	writer := zip.NewWriter(&buf)
+	for name, content := range map[string]string{
+		"Mod/":             "",
+		"Mod/readme.txt":   "readme",
+		"Mod/bin/MOD.dll":  "dll",
+		"Mod/other.dll":    "other",
+		"Mod/mod.dll.sig/": "",
+	} {
+		w, err := writer.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(content))
+	}
+	writer.Close()
+
+	tests := []struct {
+		fileName string
+		want     string
+		wantErr  bool
+	}{
+		{"mod.dll", "dll", false},
+		{"other.dll", "other", false},
+		{"missing.dll", "", true},
+		{"Mod", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := extractDLLFromZip(buf.Bytes(), tt.fileName)
+		if (err != nil) != tt.wantErr || string(got) != tt.want {
+			t.Errorf("extractDLLFromZip(%q) = %q, %v, want %q, error %v", tt.fileName, got, err, tt.want, tt.wantErr)
+		}
+	}
+
+	if _, err := extractDLLFromZip([]byte("not a zip"), "mod.dll"); err == nil {
+		t.Errorf("extractDLLFromZip() of a file that is not a zip = nil, want error")
+	}
+}
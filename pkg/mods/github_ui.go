@@ -0,0 +1,181 @@
+package mods
+
+import (
+	"fmt"
+
+	"turtlesilicon/pkg/debug"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// createGitHubRow returns the buttons of the info popup that link a mod to GitHub releases and
+// update it from them
+func (mm *ModManager) createGitHubRow(mod *Mod) fyne.CanvasObject {
+	linkButton := widget.NewButton("Link to GitHub...", func() {
+		mm.showLinkGitHubDialog(mod)
+	})
+	linkButton.Importance = widget.MediumImportance
+
+	if mod.Metadata.Repository == "" {
+		return container.NewHBox(linkButton)
+	}
+	linkButton.SetText("Change GitHub Link...")
+
+	updateButton := widget.NewButton("Check for Update", func() {
+		mm.checkGitHubUpdates([]*Mod{mod})
+	})
+	updateButton.Importance = widget.HighImportance
+
+	return container.NewHBox(
+		widget.NewLabel("github.com/"+mod.Metadata.Repository),
+		updateButton,
+		linkButton,
+	)
+}
+
+// showLinkGitHubDialog asks for the repository and asset pattern of a mod
+func (mm *ModManager) showLinkGitHubDialog(mod *Mod) {
+	currentVer, err := mm.versionManager.GetCurrentVersion()
+	if err != nil || currentVer == nil || currentVer.GamePath == "" {
+		dialog.ShowError(fmt.Errorf("game path not set"), mm.window)
+		return
+	}
+
+	repoEntry := widget.NewEntry()
+	repoEntry.SetPlaceHolder("owner/name or https://github.com/owner/name")
+	repoEntry.SetText(mod.Metadata.Repository)
+
+	patternEntry := widget.NewEntry()
+	patternEntry.SetPlaceHolder(mod.Name + " or e.g. *.zip")
+	patternEntry.SetText(mod.Metadata.AssetPattern)
+
+	repoItem := widget.NewFormItem("Repository", repoEntry)
+	repoItem.HintText = "Leave empty to remove the link"
+
+	dialog.ShowForm("Link "+mod.DisplayName()+" to GitHub", "Save", "Cancel", []*widget.FormItem{
+		repoItem,
+		widget.NewFormItem("Release asset", patternEntry),
+	}, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+		if err := LinkModToGitHub(currentVer.GamePath, mod.Name, repoEntry.Text, patternEntry.Text); err != nil {
+			dialog.ShowError(err, mm.window)
+			return
+		}
+		mm.refreshModManager()
+	}, mm.window)
+}
+
+// linkedMods returns the mods linked to a GitHub repository
+func (mm *ModManager) linkedMods() []*Mod {
+	var linked []*Mod
+	for i := range mm.mods {
+		if mm.mods[i].Metadata.Repository != "" {
+			linked = append(linked, &mm.mods[i])
+		}
+	}
+	return linked
+}
+
+// checkGitHubUpdates checks the mods for newer GitHub releases and offers to install them
+func (mm *ModManager) checkGitHubUpdates(linked []*Mod) {
+	currentVer, err := mm.versionManager.GetCurrentVersion()
+	if err != nil || currentVer == nil || currentVer.GamePath == "" {
+		dialog.ShowError(fmt.Errorf("game path not set"), mm.window)
+		return
+	}
+	gamePath := currentVer.GamePath
+
+	if len(linked) == 0 {
+		dialog.ShowInformation("Mod Updates", "No mod is linked to a GitHub repository. Use 'Link to GitHub...' in a mod's info to link it.", mm.window)
+		return
+	}
+
+	progress := dialog.NewCustomWithoutButtons("Mod Updates", widget.NewProgressBarInfinite(), mm.window)
+	progress.Show()
+
+	type result struct {
+		mod     *Mod
+		release *GitHubRelease
+		newer   bool
+		err     error
+	}
+
+	go func() {
+		var results []result
+		for _, mod := range linked {
+			release, newer, err := CheckGitHubUpdate(gamePath, mod.Name)
+			if err != nil {
+				debug.Printf("Update check of %s failed: %v", mod.Name, err)
+			}
+			results = append(results, result{mod, release, newer, err})
+		}
+
+		fyne.Do(func() {
+			progress.Hide()
+
+			rows := container.NewVBox()
+			for _, r := range results {
+				r := r
+				name := widget.NewLabel(r.mod.DisplayName())
+				name.TextStyle = fyne.TextStyle{Bold: true}
+
+				switch {
+				case r.err != nil:
+					status := widget.NewLabel(r.err.Error())
+					status.Wrapping = fyne.TextWrapWord
+					status.Importance = widget.DangerImportance
+					rows.Add(container.NewVBox(name, status))
+				case !r.newer:
+					rows.Add(container.NewHBox(name, widget.NewLabel("Up to date ("+r.release.TagName+")")))
+				default:
+					installed := r.mod.Metadata.Version
+					if installed == "" {
+						installed = "unknown version"
+					}
+					notes := widget.NewLabel(r.release.Body)
+					if r.release.Body == "" {
+						notes.SetText("No release notes.")
+					}
+					notes.Wrapping = fyne.TextWrapWord
+
+					updateButton := widget.NewButton("Update to "+r.release.TagName, nil)
+					updateButton.Importance = widget.HighImportance
+					updateButton.OnTapped = func() {
+						updateButton.Disable()
+						updateButton.SetText("Updating...")
+						go func() {
+							err := UpdateModFromGitHub(gamePath, r.mod.Name, r.release)
+							fyne.Do(func() {
+								if err != nil {
+									updateButton.Enable()
+									updateButton.SetText("Update to " + r.release.TagName)
+									dialog.ShowError(err, mm.window)
+									return
+								}
+								updateButton.SetText("Updated to " + r.release.TagName)
+								mm.refreshModManager()
+							})
+						}()
+					}
+
+					rows.Add(container.NewVBox(
+						container.NewHBox(name, widget.NewLabel(installed+" → "+r.release.TagName), updateButton),
+						notes,
+					))
+				}
+				rows.Add(widget.NewSeparator())
+			}
+
+			scroll := container.NewVScroll(rows)
+			updatesDialog := dialog.NewCustom("Mod Updates", "Close", scroll, mm.window)
+			windowSize := mm.window.Canvas().Size()
+			updatesDialog.Resize(fyne.NewSize(windowSize.Width*2/3, windowSize.Height*2/3))
+			updatesDialog.Show()
+		})
+	}()
+}
@@ -0,0 +1,218 @@
+package mods
+
+import (
+	"debug/pe"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DLLAnalysis is what a mod DLL exports and which parts of the client it hooks
+type DLLAnalysis struct {
+	Exports []string // Exported function names
+	Hooks   []string // Hook targets from the metadata and client areas implied by the imports
+}
+
+// ignoredExports are exported by many DLLs without replacing anything
+var ignoredExports = map[string]bool{
+	"dllmain": true, "dllcanunloadnow": true, "dllgetclassobject": true, "dllinstall": true,
+	"dllregisterserver": true, "dllunregisterserver": true,
+}
+
+// runtimeExportPrefixes match the C runtime and pthread functions MinGW builds export next to the
+// mod's own functions
+var runtimeExportPrefixes = []string{"_", "pthread_", "sched_", "sem_", "clock_", "nanosleep", "do_sema_"}
+
+// isRuntimeExport returns true for exports that say nothing about what a mod patches
+func isRuntimeExport(name string) bool {
+	name = strings.ToLower(name)
+	if ignoredExports[name] {
+		return true
+	}
+	for _, prefix := range runtimeExportPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// importHookAreas maps imported functions mods use to hook the client to the area they affect.
+// Keys are function:dll, lower case.
+var importHookAreas = map[string]string{
+	"direct3dcreate9:d3d9.dll":       "Direct3D device",
+	"direct3dcreate9ex:d3d9.dll":     "Direct3D device",
+	"wglswapbuffers:opengl32.dll":    "frame presentation",
+	"swapbuffers:gdi32.dll":          "frame presentation",
+	"send:ws2_32.dll":                "network traffic",
+	"recv:ws2_32.dll":                "network traffic",
+	"wsasend:ws2_32.dll":             "network traffic",
+	"wsarecv:ws2_32.dll":             "network traffic",
+	"send:wsock32.dll":               "network traffic",
+	"recv:wsock32.dll":               "network traffic",
+	"directinput8create:dinput8.dll": "input",
+}
+
+// AnalyzeModDLL reads the exports and imports of a mod DLL and adds the hook targets its metadata
+// declares. A DLL that cannot be read only gets the declared hooks.
+func AnalyzeModDLL(path string, metadata ModMetadata) DLLAnalysis {
+	var analysis DLLAnalysis
+	for _, hook := range metadata.Hooks {
+		analysis.addHook(hook)
+	}
+
+	f, err := pe.Open(path)
+	if err != nil {
+		return analysis
+	}
+	defer f.Close()
+
+	if exports, err := peExports(f); err == nil {
+		for _, name := range exports {
+			if !isRuntimeExport(name) {
+				analysis.Exports = append(analysis.Exports, name)
+			}
+		}
+		sort.Strings(analysis.Exports)
+	}
+	if imports, err := f.ImportedSymbols(); err == nil {
+		for _, symbol := range imports {
+			if area, ok := importHookAreas[strings.ToLower(symbol)]; ok {
+				analysis.addHook(area)
+			}
+		}
+	}
+	return analysis
+}
+
+func (a *DLLAnalysis) addHook(hook string) {
+	hook = strings.TrimSpace(hook)
+	if hook == "" {
+		return
+	}
+	for _, existing := range a.Hooks {
+		if strings.EqualFold(existing, hook) {
+			return
+		}
+	}
+	a.Hooks = append(a.Hooks, hook)
+}
+
+// Overlaps returns why two DLLs may break each other: functions both export, which the game and
+// other mods only get from the one loaded first, and client areas both hook.
+func (a DLLAnalysis) Overlaps(other DLLAnalysis) []string {
+	var overlaps []string
+	if shared := sharedNames(a.Exports, other.Exports); len(shared) > 0 {
+		overlaps = append(overlaps, "both export "+strings.Join(limitNames(shared, 5), ", "))
+	}
+	if shared := sharedNames(a.Hooks, other.Hooks); len(shared) > 0 {
+		overlaps = append(overlaps, "both hook "+strings.Join(shared, ", "))
+	}
+	return overlaps
+}
+
+// sharedNames returns the names of a that b has too, ignoring case
+func sharedNames(a, b []string) []string {
+	var shared []string
+	for _, name := range a {
+		for _, otherName := range b {
+			if strings.EqualFold(name, otherName) {
+				shared = append(shared, name)
+				break
+			}
+		}
+	}
+	return shared
+}
+
+// limitNames shortens a long list of names for a warning
+func limitNames(names []string, max int) []string {
+	if len(names) <= max {
+		return names
+	}
+	return append(names[:max:max], fmt.Sprintf("and %d more", len(names)-max))
+}
+
+// peExports returns the names in the export directory of a PE file. debug/pe only reads imports.
+func peExports(f *pe.File) ([]string, error) {
+	header, ok := f.OptionalHeader.(*pe.OptionalHeader32)
+	if !ok || header.NumberOfRvaAndSizes <= pe.IMAGE_DIRECTORY_ENTRY_EXPORT {
+		return nil, nil
+	}
+	directory := header.DataDirectory[pe.IMAGE_DIRECTORY_ENTRY_EXPORT]
+	if directory.VirtualAddress == 0 || directory.Size == 0 {
+		return nil, nil
+	}
+
+	// IMAGE_EXPORT_DIRECTORY: NumberOfNames at 24, AddressOfNames at 32
+	exportDir, err := readRVA(f, directory.VirtualAddress, 40)
+	if err != nil {
+		return nil, err
+	}
+	count := binary.LittleEndian.Uint32(exportDir[24:28])
+	namesRVA := binary.LittleEndian.Uint32(exportDir[32:36])
+	if count == 0 {
+		return nil, nil
+	}
+	if count > 65536 {
+		return nil, fmt.Errorf("export directory lists %d names", count)
+	}
+
+	nameRVAs, err := readRVA(f, namesRVA, count*4)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, count)
+	for i := uint32(0); i < count; i++ {
+		name, err := readRVAString(f, binary.LittleEndian.Uint32(nameRVAs[i*4:]))
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// sectionFor returns the section that contains an RVA
+func sectionFor(f *pe.File, rva uint32) (*pe.Section, error) {
+	for _, section := range f.Sections {
+		if rva >= section.VirtualAddress && rva < section.VirtualAddress+section.Size {
+			return section, nil
+		}
+	}
+	return nil, fmt.Errorf("address 0x%x is outside every section", rva)
+}
+
+// readRVA reads size bytes at an RVA
+func readRVA(f *pe.File, rva uint32, size uint32) ([]byte, error) {
+	section, err := sectionFor(f, rva)
+	if err != nil {
+		return nil, err
+	}
+	data := make([]byte, size)
+	if _, err := section.ReadAt(data, int64(rva-section.VirtualAddress)); err != nil {
+		return nil, fmt.Errorf("failed to read address 0x%x: %v", rva, err)
+	}
+	return data, nil
+}
+
+// readRVAString reads a zero terminated string at an RVA
+func readRVAString(f *pe.File, rva uint32) (string, error) {
+	section, err := sectionFor(f, rva)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	buf := make([]byte, 1)
+	for offset := int64(rva - section.VirtualAddress); b.Len() < 512; offset++ {
+		if _, err := section.ReadAt(buf, offset); err != nil {
+			return "", fmt.Errorf("failed to read address 0x%x: %v", rva, err)
+		}
+		if buf[0] == 0 {
+			return b.String(), nil
+		}
+		b.WriteByte(buf[0])
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,167 @@
+package mods
+
+import (
+	"bytes"
+	"debug/pe"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"turtlesilicon/pkg/assets"
+)
+
+// openTestDLL parses the bundled winerosetta.dll, which exports Direct3DCreate9
+func openTestDLL(t *testing.T) *pe.File {
+	t.Helper()
+	dll, err := assets.ReadFile("winerosetta/winerosetta.dll")
+	if err != nil {
+		t.Fatalf("failed to read test DLL: %v", err)
+	}
+	f, err := pe.NewFile(bytes.NewReader(dll))
+	if err != nil {
+		t.Fatalf("failed to parse test DLL: %v", err)
+	}
+	return f
+}
+
+func TestPEExports(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(header *pe.OptionalHeader32)
+		want    []string
+		wantErr bool
+	}{
+		{"export directory", func(*pe.OptionalHeader32) {}, []string{"Direct3DCreate9"}, false},
+		{"no export directory", func(h *pe.OptionalHeader32) { h.DataDirectory[pe.IMAGE_DIRECTORY_ENTRY_EXPORT].VirtualAddress = 0 }, nil, false},
+		{"no data directories", func(h *pe.OptionalHeader32) { h.NumberOfRvaAndSizes = 0 }, nil, false},
+		{"directory outside every section", func(h *pe.OptionalHeader32) {
+			h.DataDirectory[pe.IMAGE_DIRECTORY_ENTRY_EXPORT].VirtualAddress = 0x7fff0000
+		}, nil, true},
+	}
+
+	for _, tt := range tests {
+		f := openTestDLL(t)
+		tt.modify(f.OptionalHeader.(*pe.OptionalHeader32))
+		got, err := peExports(f)
+		if (err != nil) != tt.wantErr || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: peExports() = %v, %v, want %v, error %v", tt.name, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestReadRVA(t *testing.T) {
+	f := openTestDLL(t)
+	exportRVA := f.OptionalHeader.(*pe.OptionalHeader32).DataDirectory[pe.IMAGE_DIRECTORY_ENTRY_EXPORT].VirtualAddress
+	last := f.Sections[len(f.Sections)-1]
+
+	tests := []struct {
+		name    string
+		rva     uint32
+		size    uint32
+		wantErr bool
+	}{
+		{"export directory", exportRVA, 40, false},
+		{"before the first section", 0, 4, true},
+		{"after the last section", last.VirtualAddress + last.Size, 4, true},
+		{"past the end of a section", last.VirtualAddress + last.Size - 2, 4, true},
+	}
+
+	for _, tt := range tests {
+		data, err := readRVA(f, tt.rva, tt.size)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: readRVA(0x%x, %d) error = %v, want error %v", tt.name, tt.rva, tt.size, err, tt.wantErr)
+		}
+		if err == nil && uint32(len(data)) != tt.size {
+			t.Errorf("%s: readRVA(0x%x, %d) returned %d bytes", tt.name, tt.rva, tt.size, len(data))
+		}
+	}
+}
+
+func TestReadRVAString(t *testing.T) {
+	f := openTestDLL(t)
+	exportRVA := f.OptionalHeader.(*pe.OptionalHeader32).DataDirectory[pe.IMAGE_DIRECTORY_ENTRY_EXPORT].VirtualAddress
+	exportDir, err := readRVA(f, exportRVA, 40)
+	if err != nil {
+		t.Fatalf("readRVA() error = %v", err)
+	}
+	// IMAGE_EXPORT_DIRECTORY: Name at 12, AddressOfNames at 32
+	nameRVA := binary.LittleEndian.Uint32(exportDir[12:16])
+	namesRVA := binary.LittleEndian.Uint32(exportDir[32:36])
+	firstName, err := readRVA(f, namesRVA, 4)
+	if err != nil {
+		t.Fatalf("readRVA() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		rva     uint32
+		want    string
+		wantErr bool
+	}{
+		{"DLL name", nameRVA, "winerosetta.dll", false},
+		{"first export", binary.LittleEndian.Uint32(firstName), "Direct3DCreate9", false},
+		{"outside every section", 0x7fff0000, "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := readRVAString(f, tt.rva)
+		if (err != nil) != tt.wantErr || !strings.EqualFold(got, tt.want) {
+			t.Errorf("%s: readRVAString(0x%x) = %q, %v, want %q, error %v", tt.name, tt.rva, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestOverlaps(t *testing.T) {
+	many := []string{"a", "b", "c", "d", "e", "f", "g"}
+
+	tests := []struct {
+		name string
+		a, b DLLAnalysis
+		want []string
+	}{
+		{"nothing shared", DLLAnalysis{Exports: []string{"Foo"}, Hooks: []string{"input"}}, DLLAnalysis{Exports: []string{"Bar"}, Hooks: []string{"network traffic"}}, nil},
+		{"shared export", DLLAnalysis{Exports: []string{"Direct3DCreate9", "Foo"}}, DLLAnalysis{Exports: []string{"direct3dcreate9"}}, []string{"both export Direct3DCreate9"}},
+		{"shared hook", DLLAnalysis{Hooks: []string{"CGWorldFrame::Render"}}, DLLAnalysis{Hooks: []string{"cgworldframe::render", "input"}}, []string{"both hook CGWorldFrame::Render"}},
+		{"many shared exports", DLLAnalysis{Exports: many, Hooks: []string{"input"}}, DLLAnalysis{Exports: many, Hooks: []string{"input"}}, []string{"both export a, b, c, d, e, and 2 more", "both hook input"}},
+		{"empty", DLLAnalysis{}, DLLAnalysis{}, nil},
+	}
+
+	for _, tt := range tests {
+		if got := tt.a.Overlaps(tt.b); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Overlaps() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAnalyzeModDLL(t *testing.T) {
+	dll, err := assets.ReadFile("winerosetta/winerosetta.dll")
+	if err != nil {
+		t.Fatalf("failed to read test DLL: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "mod.dll")
+	if err := os.WriteFile(path, dll, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	analysis := AnalyzeModDLL(path, ModMetadata{Hooks: []string{"CGWorldFrame::Render", " cgworldframe::render ", ""}})
+	want := DLLAnalysis{Exports: []string{"Direct3DCreate9"}, Hooks: []string{"CGWorldFrame::Render"}}
+	if !reflect.DeepEqual(analysis, want) {
+		t.Errorf("AnalyzeModDLL() = %+v, want %+v", analysis, want)
+	}
+
+	// Two enabled copies overlap, a disabled one does not count
+	mods := []Mod{
+		{Name: "a.dll", Enabled: true, Analysis: analysis},
+		{Name: "b.dll", Enabled: true, Analysis: analysis},
+		{Name: "c.dll", Enabled: false, Analysis: analysis},
+	}
+	if warnings := enabledOverlapWarnings(mods); len(warnings) != 1 || !strings.HasPrefix(warnings[0], "a.dll and b.dll: ") {
+		t.Errorf("enabledOverlapWarnings() = %q, want one warning for a.dll and b.dll", warnings)
+	}
+	if warnings := modOverlapWarnings(&Mod{Name: "A.DLL", Analysis: analysis}, mods); len(warnings) != 1 || !strings.HasPrefix(warnings[0], "b.dll: ") {
+		t.Errorf("modOverlapWarnings() = %q, want one warning for b.dll", warnings)
+	}
+}
@@ -0,0 +1,112 @@
+package mods
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"turtlesilicon/pkg/debug"
+)
+
+// metadataSuffix is appended to the DLL name to get its metadata file, e.g. mods/foo.dll.json
+const metadataSuffix = ".json"
+
+// ModMetadata describes a mod. It is read from an optional JSON file next to the DLL.
+type ModMetadata struct {
+	Name         string   `json:"name,omitempty"`
+	Version      string   `json:"version,omitempty"`
+	Author       string   `json:"author,omitempty"`
+	Homepage     string   `json:"homepage,omitempty"`
+	Description  string   `json:"description,omitempty"`
+	GameVersions []string `json:"game_versions,omitempty"` // Compatible game version IDs, all if empty
+	Requires     []string `json:"requires,omitempty"`      // DLLs in mods/ that must be enabled too
+	Conflicts    []string `json:"conflicts,omitempty"`     // DLLs in mods/ that cannot be enabled at the same time
+	Hooks        []string `json:"hooks,omitempty"`         // Client functions or areas the mod patches, e.g. CGWorldFrame::Render
+
+	// The GitHub repository the mod is released in, see CheckGitHubUpdate
+	Repository   string `json:"repository,omitempty"`    // owner/name
+	AssetPattern string `json:"asset_pattern,omitempty"` // Release asset to download, e.g. SuperWoW*.zip
+}
+
+// builtinMetadata describes the mods TurtleSilicon ships, used when they have no metadata file
+var builtinMetadata = map[string]ModMetadata{
+	"libsiliconpatch.dll": {
+		Description: "Hooks into the WoW process and replaces slow X87 instructions with SSE2 instructions that Rosetta can translate much quicker, resulting in an increase in FPS (2x or more). This mod is enabled by default for new users as it provides significant performance improvements. May potentially cause rare graphical bugs in some situations.",
+	},
+	"winerosetta.dll": {
+		Description: "Core Wine compatibility layer required for running 32-bit World of Warcraft executables on Apple Silicon. This mod is required and cannot be disabled.",
+	},
+	"d3d9.dll": {
+		Description: "Direct3D 9 graphics wrapper that provides compatibility and performance optimizations for DirectX applications.",
+	},
+}
+
+// MetadataPath returns the metadata file of the DLL at dllPath
+func MetadataPath(dllPath string) string {
+	return dllPath + metadataSuffix
+}
+
+// LoadModMetadata reads the metadata file of a DLL. Without one, mods TurtleSilicon ships get
+// their built-in description and other mods get empty metadata.
+func LoadModMetadata(dllPath string) ModMetadata {
+	data, err := os.ReadFile(MetadataPath(dllPath))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			debug.Printf("Warning: failed to read metadata of %s: %v", dllPath, err)
+		}
+		return builtinMetadata[strings.ToLower(filepath.Base(dllPath))]
+	}
+
+	var metadata ModMetadata
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		debug.Printf("Warning: failed to parse metadata of %s: %v", dllPath, err)
+		return builtinMetadata[strings.ToLower(filepath.Base(dllPath))]
+	}
+	return metadata
+}
+
+// SaveModMetadata writes the metadata file of a DLL
+func SaveModMetadata(dllPath string, metadata ModMetadata) error {
+	data, err := json.MarshalIndent(metadata, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode mod metadata: %v", err)
+	}
+	if err := os.WriteFile(MetadataPath(dllPath), data, 0644); err != nil {
+		return fmt.Errorf("failed to write mod metadata: %v", err)
+	}
+	return nil
+}
+
+// SupportsVersion returns true if the mod can be used with a game version
+func (m ModMetadata) SupportsVersion(versionID string) bool {
+	if len(m.GameVersions) == 0 {
+		return true
+	}
+	for _, id := range m.GameVersions {
+		if id == versionID {
+			return true
+		}
+	}
+	return false
+}
+
+// DescriptionText returns the description shown in the mod manager
+func (m ModMetadata) DescriptionText() string {
+	if m.Description == "" {
+		return "No description available for this mod."
+	}
+	return m.Description
+}
+
+// modFileName turns a reference to another mod into its file name in mods/, accepting
+// "foo.dll", "mods/foo.dll" and "foo"
+func modFileName(reference string) string {
+	name := strings.ReplaceAll(strings.TrimSpace(reference), "\\", "/")
+	name = name[strings.LastIndex(name, "/")+1:]
+	if !strings.HasSuffix(strings.ToLower(name), ".dll") {
+		name += ".dll"
+	}
+	return name
+}
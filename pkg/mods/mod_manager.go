@@ -1,15 +1,18 @@
 package mods
 
 import (
-	"bufio"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
+	"turtlesilicon/pkg/backup"
 	"turtlesilicon/pkg/debug"
+	"turtlesilicon/pkg/dllstxt"
+	"turtlesilicon/pkg/utils"
 	"turtlesilicon/pkg/version"
 
 	"fyne.io/fyne/v2"
@@ -26,6 +29,17 @@ type Mod struct {
 	FileSize    int64
 	LastMod     time.Time
 	Description string
+	Metadata    ModMetadata // From the metadata file next to the DLL, if any
+	Problem     string      // Why the DLL cannot be loaded, empty if it is a valid i386 DLL
+	Analysis    DLLAnalysis // Exports and hook targets, to warn about mods that patch the same code
+}
+
+// DisplayName returns the name from the mod's metadata, or its file name
+func (m *Mod) DisplayName() string {
+	if m.Metadata.Name != "" {
+		return m.Metadata.Name
+	}
+	return m.Name
 }
 
 type ModManager struct {
@@ -35,20 +49,8 @@ type ModManager struct {
 	modsList         *container.Scroll
 	contentContainer *fyne.Container
 	versionManager   *version.VersionManager
-}
-
-// getModDescription returns a description for known mods
-func getModDescription(modName string) string {
-	switch strings.ToLower(modName) {
-	case "libsiliconpatch.dll":
-		return "Hooks into the WoW process and replaces slow X87 instructions with SSE2 instructions that Rosetta can translate much quicker, resulting in an increase in FPS (2x or more). This mod is enabled by default for new users as it provides significant performance improvements. May potentially cause rare graphical bugs in some situations."
-	case "winerosetta.dll":
-		return "Core Wine compatibility layer required for running 32-bit World of Warcraft executables on Apple Silicon. This mod is required and cannot be disabled."
-	case "d3d9.dll":
-		return "Direct3D 9 graphics wrapper that provides compatibility and performance optimizations for DirectX applications."
-	default:
-		return "No description available for this mod."
-	}
+	duplicates       []string // DLLs dlls.txt lists more than once
+	launchGame       func()   // Launches the current version, set by the UI for the crash bisect
 }
 
 func NewModManager(window fyne.Window, vm *version.VersionManager) *ModManager {
@@ -58,6 +60,11 @@ func NewModManager(window fyne.Window, vm *version.VersionManager) *ModManager {
 	}
 }
 
+// SetLaunchGame sets how the mod manager launches the game during a crash bisect
+func (mm *ModManager) SetLaunchGame(launch func()) {
+	mm.launchGame = launch
+}
+
 // IsModsSupported checks if the current version supports mods
 func (mm *ModManager) IsModsSupported() bool {
 	if mm.versionManager == nil {
@@ -101,7 +108,12 @@ func (mm *ModManager) getDllsFilePath() string {
 	if err != nil || currentVer == nil {
 		return ""
 	}
-	return filepath.Join(currentVer.GamePath, "dlls.txt")
+	return filepath.Join(currentVer.GamePath, dllstxt.FileName)
+}
+
+// modEntry returns the dlls.txt entry of a mod
+func modEntry(name string) string {
+	return "mods/" + name
 }
 
 // ScanMods scans for mods in the mods directory and parses dlls.txt
@@ -132,11 +144,15 @@ func (mm *ModManager) ScanModsWithProgress(updateProgress func(string)) error {
 		updateProgress("Reading dlls.txt...")
 	}
 
-	// Parse dlls.txt to see which mods are enabled
-	enabledMods, err := mm.parseDllsFile()
+	// Parse dlls.txt to see which mods are enabled and in which order they load
+	dlls, err := dllstxt.Load(mm.getDllsFilePath())
 	if err != nil {
 		debug.Printf("Warning: failed to parse dlls.txt: %v", err)
-		enabledMods = make(map[string]bool)
+		dlls = &dllstxt.File{}
+	}
+	mm.duplicates = dlls.Duplicates()
+	if len(mm.duplicates) > 0 {
+		debug.Printf("Warning: dlls.txt lists %s more than once", strings.Join(mm.duplicates, ", "))
 	}
 
 	if updateProgress != nil {
@@ -173,14 +189,18 @@ func (mm *ModManager) ScanModsWithProgress(updateProgress func(string)) error {
 			continue
 		}
 
+		metadata := LoadModMetadata(modPath)
 		mod := Mod{
 			Name:        entry.Name(),
 			Path:        modPath,
-			Enabled:     enabledMods["mods/"+entry.Name()],
+			Enabled:     dlls.IsEnabled(modEntry(entry.Name())),
 			Required:    entry.Name() == "winerosetta.dll",
 			FileSize:    info.Size(),
 			LastMod:     info.ModTime(),
-			Description: getModDescription(entry.Name()),
+			Description: metadata.DescriptionText(),
+			Metadata:    metadata,
+			Problem:     modProblem(modPath, currentVer.GamePath),
+			Analysis:    AnalyzeModDLL(modPath, metadata),
 		}
 
 		mm.mods = append(mm.mods, mod)
@@ -190,96 +210,80 @@ func (mm *ModManager) ScanModsWithProgress(updateProgress func(string)) error {
 		updateProgress("Finalizing...")
 	}
 
-	debug.Printf("Found %d mods (%d enabled)", len(mm.mods), mm.countEnabledMods())
-	return nil
-}
-
-// parseDllsFile parses the dlls.txt file and returns enabled mods
-func (mm *ModManager) parseDllsFile() (map[string]bool, error) {
-	dllsPath := mm.getDllsFilePath()
-	enabledMods := make(map[string]bool)
+	// Listed mods come first in load order, the others keep the directory order
+	sort.SliceStable(mm.mods, func(i, j int) bool {
+		return loadOrderIndex(dlls, mm.mods[i].Name) < loadOrderIndex(dlls, mm.mods[j].Name)
+	})
 
-	file, err := os.Open(dllsPath)
-	if err != nil {
-		// If file doesn't exist, that's okay - no mods are enabled
-		if os.IsNotExist(err) {
-			return enabledMods, nil
+	for _, mod := range mm.mods {
+		if mod.Problem != "" {
+			debug.Printf("Warning: mod %s is invalid: %s", mod.Name, mod.Problem)
 		}
-		return nil, err
 	}
-	defer file.Close()
 
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" {
-			continue
-		}
+	debug.Printf("Found %d mods (%d enabled)", len(mm.mods), mm.countEnabledMods())
+	return nil
+}
 
-		// Check if line is commented out (disabled)
-		if strings.HasPrefix(line, "#") {
-			// This mod is disabled
-			dllName := strings.TrimSpace(strings.TrimPrefix(line, "#"))
-			enabledMods[dllName] = false
-		} else {
-			// This mod is enabled
-			enabledMods[line] = true
-		}
+// loadOrderIndex returns the line of the mod's entry in dlls.txt, or a position after every
+// line if the mod is not listed
+func loadOrderIndex(dlls *dllstxt.File, name string) int {
+	if index := dlls.Find(modEntry(name)); index >= 0 {
+		return index
 	}
-
-	return enabledMods, scanner.Err()
+	return len(dlls.Lines)
 }
 
-// updateDllsFile updates the dlls.txt file with current mod states
+// updateDllsFile updates the dlls.txt file with current mod states and load order. Entries of
+// other DLLs, comments and blank lines are kept where they are.
 func (mm *ModManager) updateDllsFile() error {
 	dllsPath := mm.getDllsFilePath()
 
-	// Read existing file content to preserve non-mod entries
-	var existingLines []string
-	if file, err := os.Open(dllsPath); err == nil {
-		scanner := bufio.NewScanner(file)
-		for scanner.Scan() {
-			line := strings.TrimSpace(scanner.Text())
-			// Skip lines that are mod-related (start with "mods/" or "#mods/")
-			cleanLine := strings.TrimPrefix(line, "#")
-			if !strings.HasPrefix(cleanLine, "mods/") && line != "" {
-				existingLines = append(existingLines, line)
-			}
-		}
-		file.Close()
+	dlls, err := dllstxt.Load(dllsPath)
+	if err != nil {
+		return err
 	}
 
-	// Create new file content
-	var lines []string
-	lines = append(lines, existingLines...)
+	// Drop entries of mods that are no longer in the mods directory
+	for _, entry := range dlls.Entries() {
+		if !isModEntry(entry.Name) {
+			continue
+		}
+		if !mm.hasMod(entry.Name) {
+			dlls.Remove(entry.Name)
+		}
+	}
 
-	// Add mod entries
+	var order []string
 	for _, mod := range mm.mods {
-		modEntry := "mods/" + mod.Name
-		if mod.Enabled {
-			lines = append(lines, modEntry)
-		} else {
-			lines = append(lines, "#"+modEntry)
-		}
+		dlls.Set(modEntry(mod.Name), mod.Enabled)
+		order = append(order, modEntry(mod.Name))
 	}
+	dlls.Reorder(order)
 
-	// Write file
-	file, err := os.Create(dllsPath)
-	if err != nil {
-		return fmt.Errorf("failed to create dlls.txt: %v", err)
+	// Keep the previous dlls.txt in the backup store
+	if err := backup.SaveFile(filepath.Dir(dllsPath), dllsPath, backup.OpModManager); err != nil {
+		return fmt.Errorf("failed to back up dlls.txt: %v", err)
 	}
-	defer file.Close()
 
-	for _, line := range lines {
-		if _, err := file.WriteString(line + "\n"); err != nil {
-			return fmt.Errorf("failed to write dlls.txt: %v", err)
-		}
+	if err := dlls.Save(dllsPath); err != nil {
+		return err
 	}
 
 	debug.Printf("Updated dlls.txt with %d mod entries", len(mm.mods))
 	return nil
 }
 
+// hasMod returns true if the dlls.txt entry belongs to a scanned mod
+func (mm *ModManager) hasMod(entry string) bool {
+	for _, mod := range mm.mods {
+		if dllstxt.SameDLL(modEntry(mod.Name), entry) {
+			return true
+		}
+	}
+	return false
+}
+
 func (mm *ModManager) countEnabledMods() int {
 	count := 0
 	for _, mod := range mm.mods {
@@ -302,6 +306,16 @@ func (mm *ModManager) ToggleMod(modIndex int) error {
 	if mod.Required && mod.Enabled {
 		return fmt.Errorf("cannot disable required mod: %s", mod.Name)
 	}
+	if !mod.Enabled {
+		// The file may have been replaced since the last scan
+		mod.Problem = modProblem(mod.Path, filepath.Dir(mm.getDllsFilePath()))
+		if mod.Problem != "" {
+			return fmt.Errorf("cannot enable %s: %s", mod.Name, mod.Problem)
+		}
+	}
+	if err := mm.checkModRules(mod, !mod.Enabled); err != nil {
+		return err
+	}
 
 	mod.Enabled = !mod.Enabled
 	debug.Printf("Toggled mod %s: enabled=%v", mod.Name, mod.Enabled)
@@ -328,6 +342,152 @@ func (mm *ModManager) ToggleMod(modIndex int) error {
 	return mm.updateDllsFile()
 }
 
+// findMod returns the scanned mod a requires or conflicts reference points to, or nil
+func (mm *ModManager) findMod(reference string) *Mod {
+	return findMod(mm.mods, reference)
+}
+
+func findMod(mods []Mod, reference string) *Mod {
+	name := modFileName(reference)
+	for i := range mods {
+		if strings.EqualFold(mods[i].Name, name) {
+			return &mods[i]
+		}
+	}
+	return nil
+}
+
+// checkModRules returns an error if enabling or disabling a mod would break the game version,
+// requires or conflicts rules of the mods' metadata
+func (mm *ModManager) checkModRules(mod *Mod, enable bool) error {
+	if !enable {
+		// Nothing enabled may depend on the mod
+		for i := range mm.mods {
+			other := &mm.mods[i]
+			if other == mod || !other.Enabled {
+				continue
+			}
+			for _, required := range other.Metadata.Requires {
+				if strings.EqualFold(modFileName(required), mod.Name) {
+					return fmt.Errorf("%s is required by %s, disable %s first", mod.DisplayName(), other.DisplayName(), other.DisplayName())
+				}
+			}
+		}
+		return nil
+	}
+
+	if mm.versionManager != nil {
+		if currentVer, err := mm.versionManager.GetCurrentVersion(); err == nil && !mod.Metadata.SupportsVersion(currentVer.ID) {
+			return fmt.Errorf("%s is not compatible with %s", mod.DisplayName(), currentVer.DisplayName)
+		}
+	}
+
+	return checkEnableRules(mod, mm.mods)
+}
+
+// checkEnableRules returns an error if mod requires a mod of mods that is missing or disabled,
+// or conflicts with one that is enabled
+func checkEnableRules(mod *Mod, mods []Mod) error {
+	for _, required := range mod.Metadata.Requires {
+		other := findMod(mods, required)
+		if other == nil {
+			return fmt.Errorf("%s requires %s, which is not in the mods directory", mod.DisplayName(), modFileName(required))
+		}
+		if !other.Enabled {
+			return fmt.Errorf("%s requires %s, enable %s first", mod.DisplayName(), other.DisplayName(), other.DisplayName())
+		}
+	}
+
+	for i := range mods {
+		other := &mods[i]
+		if other == mod || !other.Enabled {
+			continue
+		}
+		if conflictsWith(mod, other) || conflictsWith(other, mod) {
+			return fmt.Errorf("%s conflicts with %s, disable %s first", mod.DisplayName(), other.DisplayName(), other.DisplayName())
+		}
+	}
+	return nil
+}
+
+// conflictsWith returns true if the metadata of mod lists other as a conflict
+func conflictsWith(mod, other *Mod) bool {
+	for _, conflict := range mod.Metadata.Conflicts {
+		if strings.EqualFold(modFileName(conflict), other.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// overlapWarnings returns a warning for every enabled mod that exports the same functions as mod
+// or hooks the same part of the client
+func (mm *ModManager) overlapWarnings(mod *Mod) []string {
+	return modOverlapWarnings(mod, mm.mods)
+}
+
+// modOverlapWarnings returns a warning for every enabled mod of mods, other than mod itself, that
+// exports the same functions as mod or hooks the same part of the client
+func modOverlapWarnings(mod *Mod, mods []Mod) []string {
+	var warnings []string
+	for i := range mods {
+		other := &mods[i]
+		if other == mod || strings.EqualFold(other.Name, mod.Name) || !other.Enabled {
+			continue
+		}
+		if overlaps := mod.Analysis.Overlaps(other.Analysis); len(overlaps) > 0 {
+			warnings = append(warnings, fmt.Sprintf("%s: %s", other.DisplayName(), strings.Join(overlaps, "; ")))
+		}
+	}
+	return warnings
+}
+
+// enabledOverlapWarnings returns a warning for every pair of enabled mods that export the same
+// functions or hook the same part of the client, for changes that enable several mods at once
+func enabledOverlapWarnings(mods []Mod) []string {
+	var warnings []string
+	for i := range mods {
+		if !mods[i].Enabled {
+			continue
+		}
+		for j := i + 1; j < len(mods); j++ {
+			if !mods[j].Enabled {
+				continue
+			}
+			if overlaps := mods[i].Analysis.Overlaps(mods[j].Analysis); len(overlaps) > 0 {
+				warnings = append(warnings, fmt.Sprintf("%s and %s: %s", mods[i].DisplayName(), mods[j].DisplayName(), strings.Join(overlaps, "; ")))
+			}
+		}
+	}
+	return warnings
+}
+
+// showOverlapWarnings tells the user that mods which were just enabled may break each other
+func (mm *ModManager) showOverlapWarnings(intro string, warnings []string) {
+	if len(warnings) == 0 {
+		return
+	}
+	message := fmt.Sprintf("%s\n\n- %s\n\nMods that patch the same client code often crash the game.", intro, strings.Join(warnings, "\n- "))
+	dialog.ShowInformation("Possible Mod Conflict", message, mm.window)
+}
+
+// MoveMod moves a mod up (negative offset) or down (positive offset) in the load order
+func (mm *ModManager) MoveMod(modIndex int, offset int) error {
+	target := modIndex + offset
+	if modIndex < 0 || modIndex >= len(mm.mods) || target < 0 || target >= len(mm.mods) {
+		return fmt.Errorf("invalid mod index")
+	}
+
+	mm.mods[modIndex], mm.mods[target] = mm.mods[target], mm.mods[modIndex]
+	if err := mm.updateDllsFile(); err != nil {
+		mm.mods[modIndex], mm.mods[target] = mm.mods[target], mm.mods[modIndex]
+		return err
+	}
+
+	debug.Printf("Moved mod %s to load order position %d", mm.mods[target].Name, target+1)
+	return nil
+}
+
 // DeleteMod removes a mod file and updates dlls.txt
 func (mm *ModManager) DeleteMod(modIndex int) error {
 	if modIndex < 0 || modIndex >= len(mm.mods) {
@@ -341,13 +501,37 @@ func (mm *ModManager) DeleteMod(modIndex int) error {
 		return fmt.Errorf("cannot delete required mod: %s", mod.Name)
 	}
 
+	if mod.Enabled {
+		if err := mm.checkModRules(mod, false); err != nil {
+			return err
+		}
+	}
+
 	debug.Printf("Deleting mod: %s", mod.Name)
 
+	// Keep a copy in the backup store so the mod can be restored
+	if err := backup.SaveFile(filepath.Dir(mm.getDllsFilePath()), mod.Path, backup.OpModManager); err != nil {
+		return fmt.Errorf("failed to back up mod file: %v", err)
+	}
+
 	// Remove the file
 	if err := os.Remove(mod.Path); err != nil {
 		return fmt.Errorf("failed to delete mod file: %v", err)
 	}
 
+	// The metadata file goes with the DLL
+	if metadataPath := MetadataPath(mod.Path); utils.PathExists(metadataPath) {
+		if err := backup.SaveFile(filepath.Dir(mm.getDllsFilePath()), metadataPath, backup.OpModManager); err != nil {
+			debug.Printf("Warning: failed to back up %s: %v", metadataPath, err)
+		} else if err := os.Remove(metadataPath); err != nil {
+			debug.Printf("Warning: failed to delete %s: %v", metadataPath, err)
+		}
+	}
+
+	if err := forgetCatalogMod(filepath.Dir(mm.getDllsFilePath()), mod.Name); err != nil {
+		debug.Printf("Warning: failed to update installed catalog mods: %v", err)
+	}
+
 	// Remove from our list
 	mm.mods = append(mm.mods[:modIndex], mm.mods[modIndex+1:]...)
 
@@ -450,12 +634,38 @@ func (mm *ModManager) createModManagerPopup() {
 	})
 	addButton.Importance = widget.HighImportance
 
+	catalogButton := widget.NewButton("Catalog", func() {
+		mm.showCatalogPopup()
+	})
+	catalogButton.Importance = widget.MediumImportance
+
 	leftSide := container.NewHBox(summaryText)
-	rightSide := container.NewHBox(addButton, refreshButton)
+	if len(mm.duplicates) > 0 {
+		duplicatesText := widget.NewLabel(fmt.Sprintf("dlls.txt lists %s more than once", strings.Join(mm.duplicates, ", ")))
+		duplicatesText.Importance = widget.WarningImportance
+		leftSide.Add(duplicatesText)
+	}
+	updatesButton := widget.NewButton("Check Updates", func() {
+		mm.checkGitHubUpdates(mm.linkedMods())
+	})
+	updatesButton.Importance = widget.MediumImportance
+
+	bisectButton := widget.NewButton("Find Crashing Mod", func() {
+		mm.showBisectPopup()
+	})
+	bisectButton.Importance = widget.MediumImportance
+
+	rightSide := container.NewHBox(addButton)
+	if CatalogURL() != "" {
+		rightSide.Add(catalogButton)
+	}
+	rightSide.Add(updatesButton)
+	rightSide.Add(bisectButton)
+	rightSide.Add(refreshButton)
 
 	headerContainer := container.NewBorder(
 		nil,
-		widget.NewSeparator(),
+		container.NewVBox(mm.createProfilesBar(), widget.NewSeparator()),
 		leftSide,
 		rightSide,
 		nil,
@@ -547,14 +757,26 @@ func (mm *ModManager) createModsList() *container.Scroll {
 }
 
 func (mm *ModManager) createModCard(mod *Mod, modIndex int) *fyne.Container {
-	nameText := fmt.Sprintf("**%s**", mod.Name)
+	nameText := fmt.Sprintf("**%s**", mod.DisplayName())
+	if mod.Metadata.Version != "" {
+		nameText += " " + mod.Metadata.Version
+	}
+	if mod.Metadata.Author != "" {
+		nameText += " by " + mod.Metadata.Author
+	}
+	if mm.versionManager == nil {
+		// No game version to check compatibility against
+	} else if currentVer, err := mm.versionManager.GetCurrentVersion(); err == nil && !mod.Metadata.SupportsVersion(currentVer.ID) {
+		nameText += " *(not compatible with this version)*"
+	}
 	nameLabel := widget.NewRichTextFromMarkdown(nameText)
 	nameLabel.Wrapping = fyne.TextWrapOff
 
 	// Show file size and last modified
 	sizeText := fmt.Sprintf("Size: %.1f KB", float64(mod.FileSize)/1024)
 	lastModText := fmt.Sprintf("Modified: %s", mod.LastMod.Format("2006-01-02 15:04"))
-	infoLabel := widget.NewLabel(fmt.Sprintf("%s | %s", sizeText, lastModText))
+	orderText := fmt.Sprintf("Load order: %d", modIndex+1)
+	infoLabel := widget.NewLabel(fmt.Sprintf("%s | %s | %s", orderText, sizeText, lastModText))
 	infoLabel.TextStyle = fyne.TextStyle{Italic: true}
 
 	nameContainer := container.NewHBox(nameLabel)
@@ -579,14 +801,45 @@ func (mm *ModManager) createModCard(mod *Mod, modIndex int) *fyne.Container {
 		enabledCheck.SetChecked(true)
 	} else {
 		// Only add callback for non-required mods
+		reverting := false
 		enabledCheck.OnChanged = func(checked bool) {
+			if reverting {
+				return
+			}
 			debug.Printf("Checkbox changed for mod %s: %v", mod.Name, checked)
-			if err := mm.ToggleMod(modIndex); err != nil {
-				debug.Printf("Error toggling mod: %v", err)
-				// Revert checkbox state on error
+			revert := func() {
+				reverting = true
 				enabledCheck.SetChecked(!checked)
-				dialog.ShowError(err, mm.window)
+				reverting = false
+			}
+			toggle := func() {
+				if err := mm.ToggleMod(modIndex); err != nil {
+					debug.Printf("Error toggling mod: %v", err)
+					// Revert checkbox state on error
+					revert()
+					dialog.ShowError(err, mm.window)
+				}
+			}
+
+			// Warn about mods patching the same code before dlls.txt is written
+			if warnings := mm.overlapWarnings(mod); checked && len(warnings) > 0 {
+				message := fmt.Sprintf("%s may conflict with enabled mods:\n\n- %s\n\nMods that patch the same client code often crash the game. Enable it anyway?",
+					mod.DisplayName(), strings.Join(warnings, "\n- "))
+				dialog.ShowConfirm("Possible Mod Conflict", message, func(confirmed bool) {
+					if confirmed {
+						toggle()
+					} else {
+						revert()
+					}
+				}, mm.window)
+				return
 			}
+			toggle()
+		}
+
+		// Invalid DLLs can only be disabled
+		if mod.Problem != "" && !mod.Enabled {
+			enabledCheck.Disable()
 		}
 	}
 
@@ -597,9 +850,16 @@ func (mm *ModManager) createModCard(mod *Mod, modIndex int) *fyne.Container {
 		requiredLabel.TextStyle = fyne.TextStyle{Italic: true}
 	}
 
+	// Invalid indicator, the info popup shows the reason
+	var invalidLabel *widget.Label
+	if mod.Problem != "" {
+		invalidLabel = widget.NewLabel("(Invalid)")
+		invalidLabel.Importance = widget.DangerImportance
+	}
+
 	// Info button
 	infoButton := widget.NewButton("Info", func() {
-		mm.showModInfoPopup(mod.Name, mod.Description)
+		mm.showModInfoPopup(mod)
 	})
 	infoButton.Importance = widget.LowImportance
 
@@ -613,12 +873,32 @@ func (mm *ModManager) createModCard(mod *Mod, modIndex int) *fyne.Container {
 		deleteButton.Disable()
 	}
 
-	var buttonsContainer *fyne.Container
+	// Load order buttons
+	upButton := widget.NewButton("↑", func() {
+		mm.moveModAndRefresh(modIndex, -1)
+	})
+	upButton.Importance = widget.LowImportance
+	if modIndex == 0 {
+		upButton.Disable()
+	}
+
+	downButton := widget.NewButton("↓", func() {
+		mm.moveModAndRefresh(modIndex, 1)
+	})
+	downButton.Importance = widget.LowImportance
+	if modIndex == len(mm.mods)-1 {
+		downButton.Disable()
+	}
+
+	buttonsContainer := container.NewHBox(upButton, downButton, enabledCheck)
 	if requiredLabel != nil {
-		buttonsContainer = container.NewHBox(enabledCheck, requiredLabel, infoButton, deleteButton)
-	} else {
-		buttonsContainer = container.NewHBox(enabledCheck, infoButton, deleteButton)
+		buttonsContainer.Add(requiredLabel)
+	}
+	if invalidLabel != nil {
+		buttonsContainer.Add(invalidLabel)
 	}
+	buttonsContainer.Add(infoButton)
+	buttonsContainer.Add(deleteButton)
 
 	buttonsWithMargin := container.NewPadded(buttonsContainer)
 
@@ -635,6 +915,15 @@ func (mm *ModManager) createModCard(mod *Mod, modIndex int) *fyne.Container {
 	return container.NewPadded(cardContainer)
 }
 
+// moveModAndRefresh changes the load order of a mod and redraws the list
+func (mm *ModManager) moveModAndRefresh(modIndex int, offset int) {
+	if err := mm.MoveMod(modIndex, offset); err != nil {
+		debug.Printf("Error moving mod: %v", err)
+		dialog.ShowError(err, mm.window)
+	}
+	mm.refreshModsList()
+}
+
 func (mm *ModManager) refreshModsList() {
 	if mm.currentPopup == nil || mm.modsList == nil {
 		return
@@ -703,17 +992,19 @@ func (mm *ModManager) confirmDeleteMod(modIndex int) {
 	confirmDialog.Show()
 }
 
-func (mm *ModManager) showModInfoPopup(modName, description string) {
-	titleText := widget.NewRichTextFromMarkdown(fmt.Sprintf("# %s", modName))
+func (mm *ModManager) showModInfoPopup(mod *Mod) {
+	titleText := widget.NewRichTextFromMarkdown(fmt.Sprintf("# %s", mod.DisplayName()))
 	titleText.Wrapping = fyne.TextWrapOff
 
-	descriptionLabel := widget.NewLabel(description)
+	descriptionLabel := widget.NewLabel(mm.modInfoText(mod))
 	descriptionLabel.Wrapping = fyne.TextWrapWord
 
 	contentContainer := container.NewVBox(
 		container.NewCenter(titleText),
 		widget.NewSeparator(),
 		descriptionLabel,
+		widget.NewSeparator(),
+		mm.createGitHubRow(mod),
 	)
 
 	windowSize := mm.window.Content().Size()
@@ -757,6 +1048,47 @@ func (mm *ModManager) showModInfoPopup(modName, description string) {
 	popup.Show()
 }
 
+// modInfoText returns the description and the other metadata of a mod for the info popup
+func (mm *ModManager) modInfoText(mod *Mod) string {
+	metadata := mod.Metadata
+	lines := []string{mod.Description, ""}
+	if mod.Problem != "" {
+		lines = append([]string{"This DLL cannot be loaded: " + mod.Problem, ""}, lines...)
+	}
+	lines = append(lines, "File: mods/"+mod.Name)
+	if metadata.Version != "" {
+		lines = append(lines, "Version: "+metadata.Version)
+	}
+	if metadata.Author != "" {
+		lines = append(lines, "Author: "+metadata.Author)
+	}
+	if metadata.Homepage != "" {
+		lines = append(lines, "Homepage: "+metadata.Homepage)
+	}
+	if metadata.Repository != "" {
+		lines = append(lines, "Updates from: github.com/"+metadata.Repository+" ("+metadata.AssetPattern+")")
+	}
+	if len(metadata.GameVersions) > 0 {
+		lines = append(lines, "Compatible versions: "+strings.Join(metadata.GameVersions, ", "))
+	}
+	if len(metadata.Requires) > 0 {
+		lines = append(lines, "Requires: "+strings.Join(metadata.Requires, ", "))
+	}
+	if len(metadata.Conflicts) > 0 {
+		lines = append(lines, "Conflicts with: "+strings.Join(metadata.Conflicts, ", "))
+	}
+	if len(mod.Analysis.Hooks) > 0 {
+		lines = append(lines, "Hooks: "+strings.Join(mod.Analysis.Hooks, ", "))
+	}
+	if len(mod.Analysis.Exports) > 0 {
+		lines = append(lines, "Exports: "+strings.Join(limitNames(mod.Analysis.Exports, 10), ", "))
+	}
+	if warnings := mm.overlapWarnings(mod); len(warnings) > 0 {
+		lines = append(lines, "", "May conflict with:", "- "+strings.Join(warnings, "\n- "))
+	}
+	return strings.Join(lines, "\n")
+}
+
 func (mm *ModManager) showAddModDialog() {
 	// Create content for the dialog
 	titleLabel := widget.NewLabel("Add Mods")
@@ -764,7 +1096,11 @@ func (mm *ModManager) showAddModDialog() {
 
 	instructionText := widget.NewLabel("To add mods:\n\n" +
 		"1. Place your .dll files in the 'mods' directory inside your game folder\n" +
-		"2. Click 'Refresh' to reload the mod list\n\n" +
+		"2. Click 'Refresh' to reload the mod list\n" +
+		"3. Use the arrows to change the order the mods are loaded in\n\n" +
+		"A mod can describe itself in a metadata file next to the DLL, e.g. mods/foo.dll.json.\n" +
+		"Mods listed in a catalog can be installed and updated from the 'Catalog' button, which shows once a catalog URL is set below.\n" +
+		"A mod released on GitHub can be linked to its repository from 'Info' and updated with 'Check Updates'.\n" +
 		"The mods directory will be created automatically if it doesn't exist.\n" +
 		"Note: d3d9.dll should remain in the root game directory, not in mods/")
 	instructionText.Wrapping = fyne.TextWrapWord
@@ -774,6 +1110,14 @@ func (mm *ModManager) showAddModDialog() {
 	})
 	okButton.Importance = widget.HighImportance
 
+	catalogEntry := widget.NewEntry()
+	catalogEntry.SetPlaceHolder("https://example.com/catalog.json")
+	catalogEntry.SetText(CatalogURL())
+	catalogSaveButton := widget.NewButton("Save", func() {
+		// Set when the popup is created
+	})
+	catalogSaveButton.Importance = widget.MediumImportance
+
 	// Only show "Where do I get mods?" button for turtlesilicon
 	var buttonsContainer *fyne.Container
 	currentVer, err := mm.versionManager.GetCurrentVersion()
@@ -796,6 +1140,7 @@ func (mm *ModManager) showAddModDialog() {
 		container.NewCenter(titleLabel),
 		widget.NewSeparator(),
 		instructionText,
+		container.NewBorder(nil, nil, widget.NewLabel("Catalog URL:"), catalogSaveButton, catalogEntry),
 		widget.NewSeparator(),
 		container.NewCenter(buttonsContainer),
 	)
@@ -811,6 +1156,14 @@ func (mm *ModManager) showAddModDialog() {
 	okButton.OnTapped = func() {
 		popup.Hide()
 	}
+	catalogSaveButton.OnTapped = func() {
+		if err := SetCatalogURL(catalogEntry.Text); err != nil {
+			dialog.ShowError(fmt.Errorf("failed to save catalog URL: %v", err), mm.window)
+			return
+		}
+		popup.Hide()
+		mm.refreshModManager()
+	}
 
 	popup.Show()
 }
@@ -0,0 +1,207 @@
+package mods
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"turtlesilicon/pkg/backup"
+	"turtlesilicon/pkg/debug"
+	"turtlesilicon/pkg/dllstxt"
+	"turtlesilicon/pkg/utils"
+	"turtlesilicon/pkg/version"
+)
+
+// ErrUnknownProfile is returned for a mod profile name the game version does not have
+var ErrUnknownProfile = errors.New("unknown mod profile")
+
+// requiredModEntry is enabled by every profile, the game does not start without it
+const requiredModEntry = "mods/winerosetta.dll"
+
+// ModProfileNames returns the names of the mod profiles of a game version, sorted
+func ModProfileNames(ver *version.GameVersion) []string {
+	names := make([]string, 0, len(ver.Settings.ModProfiles))
+	for name := range ver.Settings.ModProfiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CaptureModProfile returns the mods/ entries of the game's dlls.txt in load order, disabled
+// mods with a # prefix
+func CaptureModProfile(gamePath string) ([]string, error) {
+	dlls, err := dllstxt.Load(filepath.Join(gamePath, dllstxt.FileName))
+	if err != nil {
+		return nil, err
+	}
+	var entries []string
+	for _, entry := range dlls.Entries() {
+		if isModEntry(entry.Name) {
+			entries = append(entries, dllstxt.EntryText(entry.Name, entry.Enabled))
+		}
+	}
+	return entries, nil
+}
+
+// SaveModProfile stores the enabled mods and load order of the game directory under name in the
+// game version, replacing a profile of the same name
+func SaveModProfile(vm *version.VersionManager, ver *version.GameVersion, gamePath string, name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return fmt.Errorf("profile name is empty")
+	}
+	entries, err := CaptureModProfile(gamePath)
+	if err != nil {
+		return err
+	}
+	if ver.Settings.ModProfiles == nil {
+		ver.Settings.ModProfiles = make(map[string][]string)
+	}
+	ver.Settings.ModProfiles[name] = entries
+	if err := vm.UpdateVersion(ver); err != nil {
+		return fmt.Errorf("failed to save mod profile: %v", err)
+	}
+	debug.Printf("Saved mod profile %q for %s with %d entries", name, ver.ID, len(entries))
+	return nil
+}
+
+// DeleteModProfile removes a mod profile. A launch profile of that name is cleared too.
+func DeleteModProfile(vm *version.VersionManager, ver *version.GameVersion, name string) error {
+	if _, ok := ver.Settings.ModProfiles[name]; !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownProfile, name)
+	}
+	delete(ver.Settings.ModProfiles, name)
+	if ver.Settings.LaunchModProfile == name {
+		ver.Settings.LaunchModProfile = ""
+	}
+	if err := vm.UpdateVersion(ver); err != nil {
+		return fmt.Errorf("failed to delete mod profile: %v", err)
+	}
+	return nil
+}
+
+// ApplyModProfile rewrites the game directory's dlls.txt in one step so exactly the mods of the
+// profile are enabled, in the profile's load order. Mods the profile does not list are disabled,
+// entries of other DLLs and comments are kept. It returns the profile entries that could not be
+// enabled and why, and warnings about enabled mods that patch the same code.
+func ApplyModProfile(vm *version.VersionManager, ver *version.GameVersion, gamePath string, name string) ([]string, []string, error) {
+	entries, ok := ver.Settings.ModProfiles[name]
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: %s", ErrUnknownProfile, name)
+	}
+	if gamePath == "" {
+		return nil, nil, fmt.Errorf("game path not set")
+	}
+
+	dllsPath := filepath.Join(gamePath, dllstxt.FileName)
+	dlls, err := dllstxt.Load(dllsPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var skipped []string
+	var order []string
+	wanted := make(map[string]bool)
+	for _, text := range entries {
+		entry, enabled := dllstxt.ParseEntry(text)
+		if entry == "" {
+			continue
+		}
+		modPath := filepath.Join(gamePath, filepath.FromSlash(strings.ReplaceAll(entry, "\\", "/")))
+		if !utils.PathExists(modPath) {
+			skipped = append(skipped, fmt.Sprintf("%s: not in the mods directory", entry))
+			continue
+		}
+		if enabled {
+			if problem := modProblem(modPath, gamePath); problem != "" {
+				skipped = append(skipped, fmt.Sprintf("%s: %s", entry, problem))
+				enabled = false
+			}
+		}
+		wanted[strings.ToLower(entry)] = enabled
+		order = append(order, entry)
+	}
+	skipped = append(skipped, checkProfileRules(ver, gamePath, dlls, order, wanted)...)
+
+	// Disable the mods the profile does not list
+	for _, entry := range dlls.Entries() {
+		if _, listed := wanted[strings.ToLower(entry.Name)]; isModEntry(entry.Name) && !listed {
+			dlls.Set(entry.Name, false)
+		}
+	}
+	for _, entry := range order {
+		dlls.Set(entry, wanted[strings.ToLower(entry)])
+	}
+	if utils.PathExists(filepath.Join(gamePath, filepath.FromSlash(requiredModEntry))) {
+		dlls.Set(requiredModEntry, true)
+	}
+	dlls.Reorder(order)
+
+	if err := backup.SaveFile(gamePath, dllsPath, backup.OpModManager); err != nil {
+		return nil, nil, fmt.Errorf("failed to back up dlls.txt: %v", err)
+	}
+	if err := dlls.Save(dllsPath); err != nil {
+		return nil, nil, err
+	}
+
+	// Keep the libSiliconPatch setting in line with dlls.txt, like toggling it in the mod manager
+	if listed, enabled := dlls.State("mods/libSiliconPatch.dll"); listed && enabled != ver.Settings.EnableLibSiliconPatch {
+		ver.Settings.EnableLibSiliconPatch = enabled
+		ver.Settings.UserDisabledLibSiliconPatch = !enabled
+		if err := vm.UpdateVersion(ver); err != nil {
+			debug.Printf("Warning: failed to save libSiliconPatch setting: %v", err)
+		}
+	}
+
+	warnings := enabledOverlapWarnings(modsInDirectory(gamePath, dlls))
+	debug.Printf("Applied mod profile %q to %s (%d entries, %d skipped, %d overlaps)", name, dllsPath, len(order), len(skipped), len(warnings))
+	return skipped, warnings, nil
+}
+
+// checkProfileRules disables the wanted entries that are not compatible with the game version or
+// whose requires or conflicts rules the rest of the profile breaks, like the mod manager refuses
+// to enable them, and returns why. Of two conflicting mods the one loaded later is disabled.
+// Disabling a mod can break one that requires it, so the check repeats until nothing changes.
+func checkProfileRules(ver *version.GameVersion, gamePath string, dlls *dllstxt.File, order []string, wanted map[string]bool) []string {
+	mods := modsInDirectory(gamePath, dlls)
+	var problems []string
+	for {
+		for i := range mods {
+			mods[i].Enabled = strings.EqualFold(modEntry(mods[i].Name), requiredModEntry)
+		}
+		for _, entry := range order {
+			if mod := findMod(mods, entry); mod != nil && wanted[strings.ToLower(entry)] {
+				mod.Enabled = true
+			}
+		}
+
+		broken := false
+		for i := len(order) - 1; i >= 0 && !broken; i-- {
+			entry := order[i]
+			mod := findMod(mods, entry)
+			if mod == nil || !wanted[strings.ToLower(entry)] {
+				continue
+			}
+			err := checkEnableRules(mod, mods)
+			if !mod.Metadata.SupportsVersion(ver.ID) {
+				err = fmt.Errorf("%s is not compatible with %s", mod.DisplayName(), ver.DisplayName)
+			}
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %v", entry, err))
+				wanted[strings.ToLower(entry)] = false
+				broken = true
+			}
+		}
+		if !broken {
+			return problems
+		}
+	}
+}
+
+// isModEntry returns true for dlls.txt entries of DLLs in the mods directory
+func isModEntry(entry string) bool {
+	return strings.HasPrefix(strings.ToLower(strings.ReplaceAll(entry, "\\", "/")), "mods/")
+}
@@ -0,0 +1,142 @@
+package mods
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"turtlesilicon/pkg/assets"
+	"turtlesilicon/pkg/dllstxt"
+	"turtlesilicon/pkg/version"
+)
+
+func TestApplyModProfile(t *testing.T) {
+	dll, err := assets.ReadFile("winerosetta/winerosetta.dll")
+	if err != nil {
+		t.Fatalf("failed to read test DLL: %v", err)
+	}
+	const dlls = "# comment\r\nother.dll\r\nmods/b.dll\r\n#mods/a.dll\r\nmods/libSiliconPatch.dll\r\n"
+
+	tests := []struct {
+		name        string
+		profile     []string
+		wantDlls    string
+		wantSkipped int
+		wantSilicon bool // EnableLibSiliconPatch afterwards
+	}{
+		{
+			name:        "disables unlisted mods and skips broken ones",
+			profile:     []string{"mods/a.dll", "mods/missing.dll", "mods/bad.dll"},
+			wantDlls:    "# comment\r\nother.dll\r\n#mods/b.dll\r\nmods/a.dll\r\n#mods/libSiliconPatch.dll\r\n#mods/bad.dll\r\nmods/winerosetta.dll\r\n",
+			wantSkipped: 2,
+			wantSilicon: false,
+		},
+		{
+			name:        "applies the load order",
+			profile:     []string{"mods/libSiliconPatch.dll", "#mods/a.dll", "mods/b.dll"},
+			wantDlls:    "# comment\r\nother.dll\r\nmods/libSiliconPatch.dll\r\n#mods/a.dll\r\nmods/b.dll\r\nmods/winerosetta.dll\r\n",
+			wantSilicon: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+		t.Setenv("HOME", t.TempDir())
+		gamePath := t.TempDir()
+		files := map[string][]byte{
+			"mods/winerosetta.dll":     dll,
+			"mods/libSiliconPatch.dll": dll,
+			"mods/a.dll":               dll,
+			"mods/b.dll":               dll,
+			"mods/bad.dll":             []byte("not a DLL"),
+			dllstxt.FileName:           []byte(dlls),
+		}
+		for rel, content := range files {
+			path := filepath.Join(gamePath, filepath.FromSlash(rel))
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, content, 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		ver := &version.GameVersion{ID: "turtlesilicon"}
+		ver.Settings.EnableLibSiliconPatch = true
+		ver.Settings.ModProfiles = map[string][]string{"raid": tt.profile}
+		vm := &version.VersionManager{Versions: map[string]*version.GameVersion{ver.ID: ver}}
+
+		skipped, _, err := ApplyModProfile(vm, ver, gamePath, "raid")
+		if err != nil {
+			t.Fatalf("%s: ApplyModProfile() error = %v", tt.name, err)
+		}
+		if len(skipped) != tt.wantSkipped {
+			t.Errorf("%s: ApplyModProfile() skipped %v, want %d entries", tt.name, skipped, tt.wantSkipped)
+		}
+		if content, _ := os.ReadFile(filepath.Join(gamePath, dllstxt.FileName)); string(content) != tt.wantDlls {
+			t.Errorf("%s: dlls.txt =\n%q\nwant\n%q", tt.name, content, tt.wantDlls)
+		}
+		if ver.Settings.EnableLibSiliconPatch != tt.wantSilicon {
+			t.Errorf("%s: EnableLibSiliconPatch = %v, want %v", tt.name, ver.Settings.EnableLibSiliconPatch, tt.wantSilicon)
+		}
+
+		if _, _, err := ApplyModProfile(vm, ver, gamePath, "unknown"); !errors.Is(err, ErrUnknownProfile) {
+			t.Errorf("%s: ApplyModProfile() of an unknown profile error = %v, want ErrUnknownProfile", tt.name, err)
+		}
+	}
+}
+
+func TestApplyModProfileRules(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	dll, err := assets.ReadFile("winerosetta/winerosetta.dll")
+	if err != nil {
+		t.Fatalf("failed to read test DLL: %v", err)
+	}
+
+	gamePath := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(gamePath, "mods"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	metadata := map[string]ModMetadata{
+		"winerosetta.dll": {},
+		"a.dll":           {},
+		"b.dll":           {Conflicts: []string{"a"}},
+		"c.dll":           {Requires: []string{"d"}},
+		"d.dll":           {GameVersions: []string{"vanillasilicon"}},
+		"e.dll":           {Requires: []string{"a"}},
+	}
+	for name, meta := range metadata {
+		path := filepath.Join(gamePath, "mods", name)
+		if err := os.WriteFile(path, dll, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := SaveModMetadata(path, meta); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ver := &version.GameVersion{ID: "turtlesilicon", DisplayName: "TurtleSilicon"}
+	ver.Settings.ModProfiles = map[string][]string{"all": {"mods/a.dll", "mods/b.dll", "mods/c.dll", "mods/d.dll", "mods/e.dll"}}
+	vm := &version.VersionManager{Versions: map[string]*version.GameVersion{ver.ID: ver}}
+
+	skipped, _, err := ApplyModProfile(vm, ver, gamePath, "all")
+	if err != nil {
+		t.Fatalf("ApplyModProfile() error = %v", err)
+	}
+	if len(skipped) != 3 {
+		t.Errorf("ApplyModProfile() skipped %v, want b (conflict), d (game version) and c (requires d)", skipped)
+	}
+
+	dlls, err := dllstxt.Load(filepath.Join(gamePath, dllstxt.FileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]bool{"mods/a.dll": true, "mods/b.dll": false, "mods/c.dll": false, "mods/d.dll": false, "mods/e.dll": true}
+	for entry, enabled := range want {
+		if got := dlls.IsEnabled(entry); got != enabled {
+			t.Errorf("%s enabled = %v, want %v", entry, got, enabled)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package mods
+
+import (
+	"fmt"
+	"strings"
+
+	"turtlesilicon/pkg/debug"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// noLaunchProfile is the launch profile option that leaves dlls.txt as it is
+const noLaunchProfile = "None"
+
+// createProfilesBar returns the row to apply, save and delete mod profiles and to choose the
+// profile applied on launch
+func (mm *ModManager) createProfilesBar() fyne.CanvasObject {
+	currentVer, err := mm.versionManager.GetCurrentVersion()
+	if err != nil || currentVer == nil {
+		return container.NewHBox()
+	}
+	names := ModProfileNames(currentVer)
+
+	profileSelect := widget.NewSelect(names, nil)
+	profileSelect.PlaceHolder = "Select a profile"
+
+	applyButton := widget.NewButton("Apply", func() {
+		name := profileSelect.Selected
+		if name == "" {
+			return
+		}
+		skipped, warnings, err := ApplyModProfile(mm.versionManager, currentVer, currentVer.GamePath, name)
+		if err != nil {
+			dialog.ShowError(err, mm.window)
+			return
+		}
+		mm.showOverlapWarnings(fmt.Sprintf("%s enables mods that may conflict:", name), warnings)
+		if len(skipped) > 0 {
+			dialog.ShowInformation("Profile Applied", fmt.Sprintf("Applied %s, but these mods could not be enabled:\n\n- %s", name, strings.Join(skipped, "\n- ")), mm.window)
+		}
+		mm.refreshModManager()
+	})
+	applyButton.Importance = widget.HighImportance
+
+	saveButton := widget.NewButton("Save As...", func() {
+		nameEntry := widget.NewEntry()
+		nameEntry.SetPlaceHolder("e.g. Raiding")
+		nameEntry.SetText(profileSelect.Selected)
+		dialog.ShowForm("Save Mod Profile", "Save", "Cancel", []*widget.FormItem{
+			widget.NewFormItem("Name", nameEntry),
+		}, func(confirmed bool) {
+			if !confirmed {
+				return
+			}
+			if err := SaveModProfile(mm.versionManager, currentVer, currentVer.GamePath, nameEntry.Text); err != nil {
+				dialog.ShowError(err, mm.window)
+				return
+			}
+			mm.refreshModManager()
+		}, mm.window)
+	})
+	saveButton.Importance = widget.MediumImportance
+
+	deleteButton := widget.NewButton("Delete", func() {
+		name := profileSelect.Selected
+		if name == "" {
+			return
+		}
+		dialog.ShowConfirm("Delete Mod Profile", fmt.Sprintf("Delete the mod profile '%s'?", name), func(confirmed bool) {
+			if !confirmed {
+				return
+			}
+			if err := DeleteModProfile(mm.versionManager, currentVer, name); err != nil {
+				dialog.ShowError(err, mm.window)
+				return
+			}
+			mm.refreshModManager()
+		}, mm.window)
+	})
+	deleteButton.Importance = widget.LowImportance
+
+	launchSelect := widget.NewSelect(append([]string{noLaunchProfile}, names...), nil)
+	if currentVer.Settings.LaunchModProfile != "" {
+		launchSelect.SetSelected(currentVer.Settings.LaunchModProfile)
+	} else {
+		launchSelect.SetSelected(noLaunchProfile)
+	}
+	launchSelect.OnChanged = func(selected string) {
+		if selected == noLaunchProfile {
+			selected = ""
+		}
+		currentVer.Settings.LaunchModProfile = selected
+		if err := mm.versionManager.UpdateVersion(currentVer); err != nil {
+			debug.Printf("Failed to save launch mod profile: %v", err)
+		}
+	}
+
+	return container.NewHBox(
+		widget.NewLabel("Profile:"),
+		profileSelect,
+		applyButton,
+		saveButton,
+		deleteButton,
+		widget.NewSeparator(),
+		widget.NewLabel("Apply on launch:"),
+		launchSelect,
+	)
+}
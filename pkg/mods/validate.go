@@ -0,0 +1,127 @@
+package mods
+
+import (
+	"debug/pe"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrInvalidMod is wrapped by every reason ValidateModDLL rejects a DLL for
+var ErrInvalidMod = errors.New("invalid mod")
+
+// systemDLLs are DLLs Wine provides, so mods may import them without shipping them
+var systemDLLs = map[string]bool{
+	"advapi32.dll": true, "bcrypt.dll": true, "comctl32.dll": true, "comdlg32.dll": true,
+	"crypt32.dll": true, "d3d8.dll": true, "d3d9.dll": true, "d3d11.dll": true, "dbghelp.dll": true,
+	"ddraw.dll": true, "dinput.dll": true, "dinput8.dll": true, "dsound.dll": true, "dwmapi.dll": true,
+	"dxgi.dll": true, "gdi32.dll": true, "gdiplus.dll": true, "glu32.dll": true, "hid.dll": true,
+	"imagehlp.dll": true, "imm32.dll": true, "iphlpapi.dll": true, "kernel32.dll": true,
+	"kernelbase.dll": true, "mpr.dll": true, "msimg32.dll": true, "mswsock.dll": true,
+	"msvcrt.dll": true, "netapi32.dll": true, "ntdll.dll": true, "ole32.dll": true,
+	"oleaut32.dll": true, "opengl32.dll": true, "powrprof.dll": true, "psapi.dll": true,
+	"rpcrt4.dll": true, "secur32.dll": true, "setupapi.dll": true, "shell32.dll": true,
+	"shlwapi.dll": true, "ucrtbase.dll": true, "urlmon.dll": true, "user32.dll": true,
+	"userenv.dll": true, "usp10.dll": true, "uxtheme.dll": true, "version.dll": true,
+	"winhttp.dll": true, "wininet.dll": true, "winmm.dll": true, "winspool.drv": true,
+	"wintrust.dll": true, "wldap32.dll": true, "ws2_32.dll": true, "wsock32.dll": true,
+}
+
+// systemDLLPrefixes match families of system and runtime DLLs Wine provides
+var systemDLLPrefixes = []string{"api-ms-win-", "ext-ms-", "msvcp", "msvcr", "vcruntime", "d3dx9_", "d3dcompiler_", "xinput", "mfc"}
+
+// isSystemDLL returns true if Wine provides the DLL
+func isSystemDLL(name string) bool {
+	name = strings.ToLower(name)
+	if systemDLLs[name] {
+		return true
+	}
+	for _, prefix := range systemDLLPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateModDLL checks that the file at path is a complete 32-bit i386 PE DLL whose imports are
+// either system DLLs or present in the game directory or its mods directory. The error explains
+// exactly what is wrong.
+func ValidateModDLL(path string, gamePath string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidMod, err)
+	}
+
+	f, err := pe.Open(path)
+	if err != nil {
+		return fmt.Errorf("%w: not a Windows DLL (%v)", ErrInvalidMod, err)
+	}
+	defer f.Close()
+
+	switch f.Machine {
+	case pe.IMAGE_FILE_MACHINE_I386:
+	case pe.IMAGE_FILE_MACHINE_AMD64:
+		return fmt.Errorf("%w: 64-bit (x86-64) DLL, the game can only load 32-bit i386 DLLs", ErrInvalidMod)
+	case pe.IMAGE_FILE_MACHINE_ARM64:
+		return fmt.Errorf("%w: ARM64 DLL, the game can only load 32-bit i386 DLLs", ErrInvalidMod)
+	default:
+		return fmt.Errorf("%w: built for machine type 0x%04x, the game can only load 32-bit i386 DLLs", ErrInvalidMod, f.Machine)
+	}
+	if _, ok := f.OptionalHeader.(*pe.OptionalHeader32); !ok {
+		return fmt.Errorf("%w: missing the 32-bit optional header", ErrInvalidMod)
+	}
+	if f.Characteristics&pe.IMAGE_FILE_DLL == 0 {
+		return fmt.Errorf("%w: an executable, not a DLL", ErrInvalidMod)
+	}
+
+	// A truncated download still has valid headers but its sections run past the end of the file
+	for _, section := range f.Sections {
+		end := int64(section.Offset) + int64(section.Size)
+		if section.Size > 0 && end > info.Size() {
+			return fmt.Errorf("%w: truncated, section %s ends at byte %d but the file has %d bytes", ErrInvalidMod, section.Name, end, info.Size())
+		}
+	}
+
+	imports, err := f.ImportedLibraries()
+	if err != nil {
+		return fmt.Errorf("%w: unreadable import table (%v)", ErrInvalidMod, err)
+	}
+	var missing []string
+	for _, name := range imports {
+		if isSystemDLL(name) || dllExistsIn(name, gamePath, filepath.Join(gamePath, "mods")) {
+			continue
+		}
+		missing = append(missing, name)
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: depends on %s, which is neither in the game folder nor a system DLL", ErrInvalidMod, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// modProblem returns why a mod DLL cannot be loaded, or "" if it is valid
+func modProblem(path string, gamePath string) string {
+	if err := ValidateModDLL(path, gamePath); err != nil {
+		return strings.TrimPrefix(err.Error(), ErrInvalidMod.Error()+": ")
+	}
+	return ""
+}
+
+// dllExistsIn checks the directories for a DLL, ignoring case like Windows does
+func dllExistsIn(name string, dirs ...string) bool {
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if strings.EqualFold(entry.Name(), name) {
+				return true
+			}
+		}
+	}
+	return false
+}
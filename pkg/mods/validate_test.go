@@ -0,0 +1,116 @@
+package mods
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"turtlesilicon/pkg/assets"
+)
+
+func TestValidateModDLL(t *testing.T) {
+	dll, err := assets.ReadFile("winerosetta/winerosetta.dll")
+	if err != nil {
+		t.Fatalf("failed to read test DLL: %v", err)
+	}
+	header := int(binary.LittleEndian.Uint32(dll[0x3c:]))
+
+	// variant returns a copy of the DLL with a 16-bit field of the file header replaced
+	variant := func(offset int, value uint16) []byte {
+		content := append([]byte(nil), dll...)
+		binary.LittleEndian.PutUint16(content[header+offset:], value)
+		return content
+	}
+	const machine, characteristics = 4, 22
+	flags := binary.LittleEndian.Uint16(dll[header+characteristics:])
+
+	tests := []struct {
+		name    string
+		content []byte
+		want    string // Part of the error, empty if the DLL is valid
+	}{
+		{"i386 DLL", dll, ""},
+		{"x86-64 DLL", variant(machine, 0x8664), "64-bit (x86-64)"},
+		{"ARM64 DLL", variant(machine, 0xaa64), "ARM64"},
+		{"other machine", variant(machine, 0x01c4), "machine type 0x01c4"},
+		{"executable", variant(characteristics, flags&^0x2000), "not a DLL"},
+		{"truncated", dll[:len(dll)/2], "truncated"},
+		{"not a PE file", []byte("<html>404</html>"), "not a Windows DLL"},
+	}
+
+	dir := t.TempDir()
+	for _, tt := range tests {
+		path := filepath.Join(dir, "mod.dll")
+		if err := os.WriteFile(path, tt.content, 0644); err != nil {
+			t.Fatal(err)
+		}
+		err := ValidateModDLL(path, dir)
+		if tt.want == "" {
+			if err != nil {
+				t.Errorf("%s: ValidateModDLL() error = %v, want nil", tt.name, err)
+			}
+			continue
+		}
+		if !errors.Is(err, ErrInvalidMod) || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: ValidateModDLL() error = %v, want ErrInvalidMod mentioning %q", tt.name, err, tt.want)
+		}
+		if problem := modProblem(path, dir); strings.HasPrefix(problem, ErrInvalidMod.Error()) || problem == "" {
+			t.Errorf("%s: modProblem() = %q, want the reason without the prefix", tt.name, problem)
+		}
+	}
+
+	if err := ValidateModDLL(filepath.Join(dir, "missing.dll"), dir); !errors.Is(err, ErrInvalidMod) {
+		t.Errorf("ValidateModDLL() of a missing file error = %v, want ErrInvalidMod", err)
+	}
+}
+
+func TestIsSystemDLL(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"KERNEL32.dll", true},
+		{"ws2_32.dll", true},
+		{"api-ms-win-crt-runtime-l1-1-0.dll", true},
+		{"MSVCP140.dll", true},
+		{"d3dx9_43.dll", true},
+		{"winerosetta.dll", false},
+		{"lua51.dll", false},
+	}
+
+	for _, tt := range tests {
+		if got := isSystemDLL(tt.name); got != tt.want {
+			t.Errorf("isSystemDLL(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDllExistsIn(t *testing.T) {
+	gamePath := t.TempDir()
+	modsPath := filepath.Join(gamePath, "mods")
+	if err := os.MkdirAll(modsPath, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{filepath.Join(gamePath, "Lua51.DLL"), filepath.Join(modsPath, "helper.dll")} {
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"lua51.dll", true},
+		{"HELPER.dll", true},
+		{"other.dll", false},
+	}
+	for _, tt := range tests {
+		if got := dllExistsIn(tt.name, gamePath, modsPath, filepath.Join(gamePath, "missing")); got != tt.want {
+			t.Errorf("dllExistsIn(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
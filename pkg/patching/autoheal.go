@@ -0,0 +1,302 @@
+package patching
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"turtlesilicon/pkg/debug"
+	"turtlesilicon/pkg/dllstxt"
+	"turtlesilicon/pkg/utils"
+)
+
+// ModStateSnapshot is the dlls.txt content taken while the game was known to be patched, so mod
+// enable states can be restored after the game client rewrites dlls.txt during an update
+type ModStateSnapshot struct {
+	GamePath    string    `json:"game_path"`
+	SavedAt     time.Time `json:"saved_at"`
+	DllsEntries []string  `json:"dlls_entries"` // In file order, disabled mods keep their # prefix
+}
+
+// AutoHealResult reports what an automatic re-patch changed
+type AutoHealResult struct {
+	Healed          []ComponentStatus // Components that were broken and got reapplied
+	RestoredEntries []string          // dlls.txt entries restored from the snapshot
+	Unhealable      []ComponentStatus // Components that could not be reapplied automatically
+	Warnings        []string
+}
+
+// Summary returns a human readable description of what was healed
+func (r *AutoHealResult) Summary() string {
+	var lines []string
+	for _, c := range r.Healed {
+		lines = append(lines, fmt.Sprintf("Reapplied %s (%s)", c.Component, c.Reason))
+	}
+	for _, entry := range r.RestoredEntries {
+		lines = append(lines, fmt.Sprintf("Restored dlls.txt entry %s", entry))
+	}
+	for _, c := range r.Unhealable {
+		lines = append(lines, fmt.Sprintf("Could not reapply %s (%s)", c.Component, c.Reason))
+	}
+	for _, warning := range r.Warnings {
+		lines = append(lines, "Warning: "+warning)
+	}
+	if len(lines) == 0 {
+		return "Nothing needed to be reapplied."
+	}
+	return strings.Join(lines, "\n")
+}
+
+func init() {
+	dllstxt.OnSave = refreshModState
+}
+
+// getTurtleSiliconConfigDir returns the directory TurtleSilicon keeps its settings in
+func getTurtleSiliconConfigDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "TurtleSilicon"), nil
+}
+
+func modStateDir() (string, error) {
+	dir, err := getTurtleSiliconConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "mod_state"), nil
+}
+
+func modStatePath(versionID string) (string, error) {
+	dir, err := modStateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, versionID+".json"), nil
+}
+
+// SaveModState remembers the current dlls.txt entries of a game directory. It is called while the
+// game is known to be patched, e.g. right before launching.
+func SaveModState(gamePath string, versionID string) error {
+	dllsPath := filepath.Join(gamePath, dllstxt.FileName)
+	if !utils.PathExists(dllsPath) {
+		return nil
+	}
+	dlls, err := dllstxt.Load(dllsPath)
+	if err != nil {
+		return err
+	}
+
+	snapshot := ModStateSnapshot{GamePath: gamePath, SavedAt: time.Now()}
+	for _, entry := range dlls.Entries() {
+		snapshot.DllsEntries = append(snapshot.DllsEntries, dllstxt.EntryText(entry.Name, entry.Enabled))
+	}
+
+	path, err := modStatePath(versionID)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create mod state directory: %v", err)
+	}
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode mod state: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to save mod state: %v", err)
+	}
+
+	debug.Printf("Saved mod state for %s with %d dlls.txt entries", versionID, len(snapshot.DllsEntries))
+	return nil
+}
+
+// refreshModState updates the snapshots of the game directory a dlls.txt file belongs to after
+// TurtleSilicon wrote it, so auto-heal does not undo changes made in the mod manager. Game
+// directories without a snapshot get theirs on the next launch.
+func refreshModState(dllsPath string) {
+	gamePath := filepath.Dir(dllsPath)
+	dir, err := modStateDir()
+	if err != nil {
+		return
+	}
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, file := range files {
+		versionID := strings.TrimSuffix(file.Name(), ".json")
+		if file.IsDir() || versionID == file.Name() || loadModState(gamePath, versionID) == nil {
+			continue
+		}
+		if err := SaveModState(gamePath, versionID); err != nil {
+			debug.Printf("Failed to refresh mod state for %s: %v", versionID, err)
+		}
+	}
+}
+
+// loadModState returns the last snapshot for the game directory, or nil if there is none
+func loadModState(gamePath string, versionID string) *ModStateSnapshot {
+	path, err := modStatePath(versionID)
+	if err != nil {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+
+	var snapshot ModStateSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		debug.Printf("Failed to parse mod state %s: %v", path, err)
+		return nil
+	}
+	if snapshot.GamePath != gamePath {
+		// The snapshot belongs to a different installation
+		return nil
+	}
+	return &snapshot
+}
+
+// planDllsRestore re-adds the snapshot entries the client removed and enables the ones it
+// disabled. Entries that were disabled in the snapshot are left as they are now.
+func (p *PatchPlan) planDllsRestore(snapshot *ModStateSnapshot) []string {
+	var restored []string
+	for _, entry := range snapshot.DllsEntries {
+		name, enabled := dllstxt.ParseEntry(entry)
+		if name == "" {
+			continue
+		}
+		if listed, nowEnabled := p.dllsState(name); listed && (nowEnabled || !enabled) {
+			continue
+		}
+		p.planDllsAdd(entry)
+		restored = append(restored, entry)
+	}
+	return restored
+}
+
+// PlanAutoHeal builds a plan that reapplies only the patch components that are missing or were
+// replaced, e.g. after the game client updated itself
+func PlanAutoHeal(gamePath string, opts GameOptions) (*PatchPlan, *AutoHealResult, error) {
+	if gamePath == "" {
+		return nil, nil, planError(ErrGamePathNotSet, "")
+	}
+
+	status := CheckPatchStatus(gamePath, opts)
+	result := &AutoHealResult{}
+	plan := &PatchPlan{
+		Title:          "Repair Game Patch",
+		Root:           gamePath,
+		Target:         PlanTargetGame,
+		SuccessMessage: "The game patch was repaired.",
+		EmptyMessage:   "The game patch is intact, nothing to repair.",
+	}
+	plan.useComponents(opts)
+
+	// The client may comment out or drop mod entries without touching the patch entries
+	if snapshot := loadModState(gamePath, opts.VersionID); snapshot != nil {
+		result.RestoredEntries = plan.planDllsRestore(snapshot)
+	}
+
+	modsDir := filepath.Join(gamePath, "mods")
+	for _, c := range status.GameProblems() {
+		healed := true
+		switch c.Component {
+		case ComponentLoaderDLL:
+			if status.Method == PatchMethodDivxDecoder {
+				plan.planDivxDecoderReplacement()
+			} else {
+				plan.planResourceCopy("winerosetta/libDllLdr.dll", filepath.Join(gamePath, "libDllLdr.dll"), 0644)
+			}
+		case ComponentWinerosetta:
+			plan.planMkdir(modsDir)
+			plan.planResourceCopy("winerosetta/winerosetta.dll", filepath.Join(modsDir, "winerosetta.dll"), 0644)
+		case ComponentD3D9:
+			plan.planResourceCopy("winerosetta/d3d9.dll", filepath.Join(gamePath, "d3d9.dll"), 0644)
+		case ComponentLibSiliconPatch:
+			plan.planMkdir(modsDir)
+			plan.planResourceCopy("winerosetta/libSiliconPatch.dll", filepath.Join(modsDir, "libSiliconPatch.dll"), 0644)
+		case ComponentRosettaX87:
+			plan.planRosettaX87(true)
+		case ComponentPatchedExe:
+			if err := plan.planPatchedExecutable(opts); err != nil {
+				c.Reason = fmt.Sprintf("%s: %v", c.Reason, err)
+				healed = false
+			} else {
+				plan.planLargeAddressAware(opts)
+			}
+		case ComponentLargeAddress:
+			plan.planLargeAddressAware(opts)
+		case ComponentDllsTxt:
+			plan.planDllsAdd("mods/winerosetta.dll")
+			if status.Method == PatchMethodRosetta && opts.EnableLibSiliconPatch {
+				name := "mods/libSiliconPatch.dll"
+				if listed, enabled := plan.dllsState(name); !listed || enabled {
+					plan.planDllsAdd(name)
+				}
+			}
+		case ComponentMovieSetting:
+			plan.planConfigSet("movie", "0")
+		default:
+			healed = false
+		}
+
+		if healed {
+			result.Healed = append(result.Healed, c)
+		} else {
+			result.Unhealable = append(result.Unhealable, c)
+		}
+	}
+
+	return plan, result, nil
+}
+
+// AutoHeal reapplies the patch components the game client removed or replaced and writes what it
+// did to the auto-heal log
+func AutoHeal(gamePath string, opts GameOptions) (*AutoHealResult, error) {
+	plan, result, err := PlanAutoHeal(gamePath, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if !plan.IsEmpty() {
+		warnings, err := NewEngine(nil).Apply(plan)
+		if err != nil {
+			logAutoHeal(gamePath, opts.VersionID, fmt.Sprintf("Auto-heal failed: %v", err))
+			return nil, err
+		}
+		result.Warnings = warnings
+	}
+
+	logAutoHeal(gamePath, opts.VersionID, result.Summary())
+	return result, nil
+}
+
+// logAutoHeal appends to the auto-heal log in the config directory. The debug log is not
+// available in release builds, so this is the only record of what was changed.
+func logAutoHeal(gamePath string, versionID string, message string) {
+	for _, line := range strings.Split(message, "\n") {
+		debug.Printf("Auto-heal: %s", line)
+	}
+
+	dir, err := getTurtleSiliconConfigDir()
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return
+	}
+	file, err := os.OpenFile(filepath.Join(dir, "autoheal.log"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		debug.Printf("Failed to open auto-heal log: %v", err)
+		return
+	}
+	defer file.Close()
+
+	fmt.Fprintf(file, "=== %s %s (%s) ===\n%s\n\n", time.Now().Format("2006-01-02 15:04:05"), versionID, gamePath, message)
+}
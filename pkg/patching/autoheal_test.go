@@ -0,0 +1,80 @@
+package patching
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"turtlesilicon/pkg/dllstxt"
+)
+
+func TestPlanDllsRestore(t *testing.T) {
+	tests := []struct {
+		name     string
+		current  string
+		snapshot []string
+		want     []string
+	}{
+		{"removed entries are added", "mods/winerosetta.dll\n", []string{"mods/winerosetta.dll", "mods/a.dll", "#mods/b.dll"}, []string{"mods/a.dll", "#mods/b.dll"}},
+		{"commented out entries are enabled", "mods/winerosetta.dll\n#mods/a.dll\n", []string{"mods/winerosetta.dll", "mods/a.dll"}, []string{"mods/a.dll"}},
+		{"entries disabled in the snapshot stay enabled", "mods/a.dll\n", []string{"#mods/a.dll"}, nil},
+		{"unchanged entries are left alone", "mods/a.dll\n#mods/b.dll\n", []string{"mods/a.dll", "#mods/b.dll"}, nil},
+	}
+
+	for _, tt := range tests {
+		gamePath := t.TempDir()
+		if err := os.WriteFile(filepath.Join(gamePath, dllstxt.FileName), []byte(tt.current), 0644); err != nil {
+			t.Fatal(err)
+		}
+		plan := &PatchPlan{Root: gamePath}
+		restored := plan.planDllsRestore(&ModStateSnapshot{GamePath: gamePath, DllsEntries: tt.snapshot})
+		if !reflect.DeepEqual(restored, tt.want) {
+			t.Errorf("%s: planDllsRestore() = %v, want %v", tt.name, restored, tt.want)
+		}
+		for _, entry := range tt.snapshot {
+			name, enabled := dllstxt.ParseEntry(entry)
+			if listed, nowEnabled := plan.dlls.State(name); !listed || (enabled && !nowEnabled) {
+				t.Errorf("%s: %s is listed %v, enabled %v after the restore", tt.name, name, listed, nowEnabled)
+			}
+		}
+	}
+}
+
+func TestRefreshModState(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	gamePath := t.TempDir()
+	otherPath := t.TempDir()
+	dllsPath := filepath.Join(gamePath, dllstxt.FileName)
+
+	if err := dllstxt.Parse("mods/winerosetta.dll\nmods/a.dll\n").Save(dllsPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveModState(gamePath, "turtlesilicon"); err != nil {
+		t.Fatalf("SaveModState() error = %v", err)
+	}
+	if err := dllstxt.Parse("mods/b.dll\n").Save(filepath.Join(otherPath, dllstxt.FileName)); err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveModState(otherPath, "vanillasilicon"); err != nil {
+		t.Fatalf("SaveModState() error = %v", err)
+	}
+
+	// Disabling a mod in the mod manager saves dlls.txt, the snapshot must follow
+	if err := dllstxt.Parse("mods/winerosetta.dll\n#mods/a.dll\n").Save(dllsPath); err != nil {
+		t.Fatal(err)
+	}
+	snapshot := loadModState(gamePath, "turtlesilicon")
+	if want := []string{"mods/winerosetta.dll", "#mods/a.dll"}; snapshot == nil || !reflect.DeepEqual(snapshot.DllsEntries, want) {
+		t.Fatalf("snapshot after saving dlls.txt = %+v, want entries %v", snapshot, want)
+	}
+	if restored := (&PatchPlan{Root: gamePath}).planDllsRestore(snapshot); len(restored) != 0 {
+		t.Errorf("planDllsRestore() = %v after a mod manager change, want nothing", restored)
+	}
+
+	// Snapshots of other game directories are left alone
+	if other := loadModState(otherPath, "vanillasilicon"); other == nil || !reflect.DeepEqual(other.DllsEntries, []string{"mods/b.dll"}) {
+		t.Errorf("snapshot of another game directory = %+v, want entries [mods/b.dll]", other)
+	}
+}
@@ -0,0 +1,226 @@
+package patching
+
+import (
+	"bytes"
+	"debug/macho"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"turtlesilicon/pkg/debug"
+	"turtlesilicon/pkg/utils"
+	"turtlesilicon/pkg/wine"
+)
+
+// CrossOverPatchRecord remembers which wineloader and CrossOver version wineloader2 was created
+// from, so a CrossOver update that replaces wineloader or removes wineloader2 can be detected
+type CrossOverPatchRecord struct {
+	CrossOverPath    string    `json:"crossover_path"`
+	CrossOverVersion string    `json:"crossover_version"`
+	WineloaderSHA256 string    `json:"wineloader_sha256"`
+	PatchedAt        time.Time `json:"patched_at"`
+}
+
+// CrossOverChange describes how a CrossOver installation changed since wineloader2 was created
+type CrossOverChange struct {
+	Record             CrossOverPatchRecord
+	CurrentVersion     string
+	WineloaderChanged  bool
+	Wineloader2Missing bool
+	Unrecorded         bool // wineloader2 exists but no record says which wineloader it was created from
+}
+
+// Describe returns a short explanation of the change for the status and the update prompt
+func (c *CrossOverChange) Describe() string {
+	if c.Unrecorded {
+		return "wineloader2 was not created by this version of TurtleSilicon, it may not match the installed CrossOver"
+	}
+	var what string
+	switch {
+	case c.CurrentVersion != "" && c.Record.CrossOverVersion != "" && c.CurrentVersion != c.Record.CrossOverVersion:
+		what = fmt.Sprintf("CrossOver was updated from %s to %s", c.Record.CrossOverVersion, c.CurrentVersion)
+	case c.WineloaderChanged:
+		what = "CrossOver's wineloader changed"
+	default:
+		what = "CrossOver changed"
+	}
+	if c.Wineloader2Missing {
+		return what + " and wineloader2 was removed"
+	}
+	return what + " since wineloader2 was created"
+}
+
+func crossOverRecordsPath() (string, error) {
+	dir, err := getTurtleSiliconConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "crossover_patch.json"), nil
+}
+
+// loadCrossOverRecords returns the records keyed by CrossOver path
+func loadCrossOverRecords() map[string]CrossOverPatchRecord {
+	records := make(map[string]CrossOverPatchRecord)
+	path, err := crossOverRecordsPath()
+	if err != nil {
+		return records
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return records
+	}
+	if err := json.Unmarshal(data, &records); err != nil {
+		debug.Printf("Failed to parse %s: %v", path, err)
+	}
+	return records
+}
+
+func saveCrossOverRecords(records map[string]CrossOverPatchRecord) error {
+	path, err := crossOverRecordsPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %v", err)
+	}
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode CrossOver patch record: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to save CrossOver patch record: %v", err)
+	}
+	return nil
+}
+
+// recordCrossOverPatch remembers the wineloader wineloader2 was just created from, or forgets
+// the installation when wineloader2 was removed
+func recordCrossOverPatch(crossoverPath string, patched bool) error {
+	records := loadCrossOverRecords()
+	key := filepath.Clean(crossoverPath)
+	if !patched {
+		if _, ok := records[key]; !ok {
+			return nil
+		}
+		delete(records, key)
+		return saveCrossOverRecords(records)
+	}
+
+	crossover := wine.NewCrossOver(crossoverPath)
+	hash, _, err := fileSHA256(crossover.ToolLoaderPath())
+	if err != nil {
+		return fmt.Errorf("failed to hash wineloader: %v", err)
+	}
+	record := CrossOverPatchRecord{
+		CrossOverPath:    crossoverPath,
+		CrossOverVersion: crossover.Version(),
+		WineloaderSHA256: hash,
+		PatchedAt:        time.Now(),
+	}
+	records[key] = record
+	debug.Printf("Recorded CrossOver %s patch: wineloader sha256 %s", record.CrossOverVersion, hash)
+	return saveCrossOverRecords(records)
+}
+
+// CheckCrossOverChange compares a CrossOver installation with the record taken when wineloader2
+// was created. It returns nil if nothing changed or wineloader2 does not exist. A wineloader2
+// without a record, e.g. from an older version of TurtleSilicon, is recorded if it is an unsigned
+// copy of CrossOver's wineloader and needs to be created again otherwise.
+func CheckCrossOverChange(crossoverPath string) *CrossOverChange {
+	if crossoverPath == "" {
+		return nil
+	}
+	crossover := wine.NewCrossOver(crossoverPath)
+	record, ok := loadCrossOverRecords()[filepath.Clean(crossoverPath)]
+	if !ok {
+		if !utils.PathExists(crossover.LoaderPath()) {
+			return nil
+		}
+		if !unsignedCopyOf(crossover.LoaderPath(), crossover.ToolLoaderPath()) {
+			return &CrossOverChange{CurrentVersion: crossover.Version(), Unrecorded: true}
+		}
+		if err := recordCrossOverPatch(crossoverPath, true); err != nil {
+			debug.Printf("Failed to record the existing wineloader2: %v", err)
+		}
+		return nil
+	}
+
+	change := &CrossOverChange{
+		Record:             record,
+		CurrentVersion:     crossover.Version(),
+		Wineloader2Missing: !utils.PathExists(crossover.LoaderPath()),
+	}
+	if hash, _, err := fileSHA256(crossover.ToolLoaderPath()); err != nil || hash != record.WineloaderSHA256 {
+		change.WineloaderChanged = true
+	}
+	if !change.WineloaderChanged && !change.Wineloader2Missing && (change.CurrentVersion == "" || change.CurrentVersion == record.CrossOverVersion) {
+		return nil
+	}
+	return change
+}
+
+// unsignedCopyOf returns true if copyPath is the executable at originalPath with at most its code
+// signature removed, which is how wineloader2 is created. Removing the signature rewrites the load
+// commands and __LINKEDIT, so Mach-O files are compared by the contents of their sections. Other
+// files have to be identical.
+func unsignedCopyOf(copyPath string, originalPath string) bool {
+	copyContent, err := os.ReadFile(copyPath)
+	if err != nil {
+		return false
+	}
+	originalContent, err := os.ReadFile(originalPath)
+	if err != nil {
+		return false
+	}
+	if bytes.Equal(copyContent, originalContent) {
+		return true
+	}
+
+	copySections, err := machoSections(copyContent)
+	if err != nil {
+		return false
+	}
+	originalSections, err := machoSections(originalContent)
+	if err != nil || len(copySections) != len(originalSections) {
+		return false
+	}
+	for name, data := range originalSections {
+		if !bytes.Equal(copySections[name], data) {
+			return false
+		}
+	}
+	return true
+}
+
+// machoSections returns the section contents of a thin or universal Mach-O executable keyed by
+// architecture, segment and section
+func machoSections(content []byte) (map[string][]byte, error) {
+	var files []*macho.File
+	if fat, err := macho.NewFatFile(bytes.NewReader(content)); err == nil {
+		for _, arch := range fat.Arches {
+			files = append(files, arch.File)
+		}
+	} else if file, err := macho.NewFile(bytes.NewReader(content)); err == nil {
+		files = append(files, file)
+	} else {
+		return nil, err
+	}
+
+	sections := make(map[string][]byte)
+	for _, file := range files {
+		for _, section := range file.Sections {
+			if section.Offset == 0 {
+				// Zero filled sections have no contents in the file
+				continue
+			}
+			data, err := section.Data()
+			if err != nil {
+				return nil, err
+			}
+			sections[fmt.Sprintf("%v/%s/%s", file.Cpu, section.Seg, section.Name)] = data
+		}
+	}
+	return sections, nil
+}
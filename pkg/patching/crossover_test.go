@@ -0,0 +1,119 @@
+package patching
+
+import (
+	"bytes"
+	"debug/macho"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"turtlesilicon/pkg/assets"
+	"turtlesilicon/pkg/wine"
+)
+
+func TestCheckCrossOverChange(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	crossoverPath := t.TempDir()
+	crossover := wine.NewCrossOver(crossoverPath)
+	if err := os.MkdirAll(wine.CrossOverLoaderDir(crossoverPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	write := func(path, content string) {
+		if err := os.WriteFile(path, []byte(content), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(crossover.ToolLoaderPath(), "wineloader")
+
+	if change := CheckCrossOverChange(crossoverPath); change != nil {
+		t.Errorf("CheckCrossOverChange() without wineloader2 = %+v, want nil", change)
+	}
+
+	// A wineloader2 nobody recorded that is not a copy of wineloader is of unknown origin
+	write(crossover.LoaderPath(), "other wineloader")
+	if change := CheckCrossOverChange(crossoverPath); change == nil || !change.Unrecorded {
+		t.Errorf("CheckCrossOverChange() of an unrecorded wineloader2 = %+v, want Unrecorded", change)
+	}
+	if _, ok := loadCrossOverRecords()[filepath.Clean(crossoverPath)]; ok {
+		t.Errorf("unrecorded wineloader2 that differs from wineloader was recorded")
+	}
+
+	// A copy made by an older version of TurtleSilicon is recorded on first sight
+	write(crossover.LoaderPath(), "wineloader")
+	if change := CheckCrossOverChange(crossoverPath); change != nil {
+		t.Errorf("CheckCrossOverChange() of an unrecorded copy of wineloader = %+v, want nil", change)
+	}
+	if _, ok := loadCrossOverRecords()[filepath.Clean(crossoverPath)]; !ok {
+		t.Errorf("unrecorded copy of wineloader was not recorded")
+	}
+	if change := CheckCrossOverChange(crossoverPath); change != nil {
+		t.Errorf("CheckCrossOverChange() after recording = %+v, want nil", change)
+	}
+
+	write(crossover.ToolLoaderPath(), "updated wineloader")
+	if change := CheckCrossOverChange(crossoverPath); change == nil || !change.WineloaderChanged || change.Unrecorded {
+		t.Errorf("CheckCrossOverChange() after a wineloader update = %+v, want WineloaderChanged", change)
+	}
+
+	if err := os.Remove(crossover.LoaderPath()); err != nil {
+		t.Fatal(err)
+	}
+	if change := CheckCrossOverChange(crossoverPath); change == nil || !change.Wineloader2Missing {
+		t.Errorf("CheckCrossOverChange() without wineloader2 = %+v, want Wineloader2Missing", change)
+	}
+
+	if err := recordCrossOverPatch(crossoverPath, false); err != nil {
+		t.Fatalf("recordCrossOverPatch() error = %v", err)
+	}
+	if _, ok := loadCrossOverRecords()[filepath.Clean(crossoverPath)]; ok {
+		t.Errorf("record still stored after unpatching")
+	}
+}
+
+func TestUnsignedCopyOf(t *testing.T) {
+	original, err := assets.ReadFile("rosettax87/rosettax87")
+	if err != nil {
+		t.Fatalf("Failed to read the bundled rosettax87: %v", err)
+	}
+	file, err := macho.NewFile(bytes.NewReader(original))
+	if err != nil {
+		t.Fatalf("Failed to parse the bundled rosettax87: %v", err)
+	}
+	text := file.Section("__text")
+	if text == nil {
+		t.Fatalf("The bundled rosettax87 has no __text section")
+	}
+
+	// codesign --remove-signature rewrites the end of __LINKEDIT, the sections stay the same
+	unsigned := append([]byte(nil), original[:len(original)-16]...)
+	unsigned = append(unsigned, make([]byte, 16)...)
+	patched := append([]byte(nil), original...)
+	patched[text.Offset] ^= 0xff
+
+	tests := []struct {
+		name string
+		copy []byte
+		want bool
+	}{
+		{"identical", original, true},
+		{"signature removed", unsigned, true},
+		{"code changed", patched, false},
+		{"not Mach-O", []byte("wineloader"), false},
+	}
+
+	dir := t.TempDir()
+	originalPath := filepath.Join(dir, "wineloader")
+	if err := os.WriteFile(originalPath, original, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		copyPath := filepath.Join(dir, "wineloader2")
+		if err := os.WriteFile(copyPath, tt.copy, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if got := unsignedCopyOf(copyPath, originalPath); got != tt.want {
+			t.Errorf("%s: unsignedCopyOf() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
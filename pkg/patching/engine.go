@@ -0,0 +1,346 @@
+package patching
+
+import (
+	"errors"
+	"fmt"
+
+	"turtlesilicon/pkg/backup"
+	"turtlesilicon/pkg/components"
+	"turtlesilicon/pkg/debug"
+	"turtlesilicon/pkg/fingerprint"
+	"turtlesilicon/pkg/version"
+	"turtlesilicon/pkg/wine"
+)
+
+// Errors returned by the patching engine, wrapped in a PatchError
+var (
+	ErrGamePathNotSet      = errors.New("game path not set")
+	ErrGameDirNotFound     = errors.New("game directory does not exist")
+	ErrGameDirNotWritable  = errors.New("cannot write to game directory (permission denied)")
+	ErrCrossOverPathNotSet = errors.New("CrossOver path not set")
+	ErrWineloaderNotFound  = errors.New("wineloader not found")
+	ErrExecutableNotFound  = errors.New("game executable not found")
+	ErrUnsupportedExe      = errors.New("unsupported game executable")
+)
+
+// PatchEventKind identifies the kind of progress event emitted by the engine
+type PatchEventKind int
+
+const (
+	EventStepStarted PatchEventKind = iota
+	EventFileCopied
+	EventWarning
+	EventDone
+)
+
+// PatchEvent reports progress while a plan is applied
+type PatchEvent struct {
+	Kind    PatchEventKind
+	Step    int // 1-based index of the current action
+	Total   int // Number of actions in the plan
+	Message string
+	Path    string
+	Err     error // Set on EventDone when the plan failed
+}
+
+// PatchError describes why planning or applying a patch failed
+type PatchError struct {
+	Op          string // "plan" or "apply"
+	Step        int    // 1-based index of the failed action, 0 while planning
+	Action      string // Description of the failed action
+	Path        string
+	Err         error
+	RolledBack  bool
+	RollbackErr error
+}
+
+func (e *PatchError) Error() string {
+	if e.Op == "plan" {
+		if e.Path != "" {
+			return fmt.Sprintf("%v: %s", e.Err, e.Path)
+		}
+		return e.Err.Error()
+	}
+
+	msg := fmt.Sprintf("%s failed: %v", e.Action, e.Err)
+	if e.RollbackErr != nil {
+		msg += "\n\nThe changes could not be fully reverted: " + e.RollbackErr.Error()
+	} else if e.RolledBack {
+		msg += "\n\nAll changes made during patching have been reverted."
+	}
+	return msg
+}
+
+func (e *PatchError) Unwrap() error {
+	return e.Err
+}
+
+// planError wraps an error found while building a plan
+func planError(err error, path string) error {
+	return &PatchError{Op: "plan", Path: path, Err: err}
+}
+
+// GameOptions configures how a game directory is patched
+type GameOptions struct {
+	VersionID             string
+	UsesRosettaPatching   bool
+	UsesDivxDecoderPatch  bool
+	ExecutableName        string
+	CrossOverPath         string // Needed to generate the patched executable with rundll32
+	EnableLibSiliconPatch bool
+	EnableShadowLOD       bool
+	RemoveShadowLOD       bool              // Unpatching also removes shadowLOD from Config.wtf
+	ComponentVersions     map[string]string // Requested build per component, see components.Resolve
+	LargeAddressAware     bool              // Set the Large Address Aware flag of the patched executable
+	Runtime               wine.Runtime      // Wine runtime to patch for, CrossOver at CrossOverPath when nil
+}
+
+// WineRuntime returns the Wine runtime the options patch for
+func (o GameOptions) WineRuntime() wine.Runtime {
+	if o.Runtime != nil {
+		return o.Runtime
+	}
+	return wine.NewCrossOver(o.CrossOverPath)
+}
+
+// runtimeError wraps a runtime that cannot be used in a plan error. CrossOver keeps reporting
+// ErrCrossOverPathNotSet and ErrWineloaderNotFound.
+func runtimeError(runtime wine.Runtime, err error) error {
+	if runtime.Kind() == wine.KindCrossOver {
+		if errors.Is(err, wine.ErrPathNotSet) {
+			return planError(ErrCrossOverPathNotSet, "")
+		}
+		return planError(ErrWineloaderNotFound, runtime.ToolLoaderPath())
+	}
+	return planError(err, "")
+}
+
+// GameOptionsForVersion builds the patch options for a configured game version
+func GameOptionsForVersion(ver *version.GameVersion) GameOptions {
+	opts := GameOptions{
+		VersionID:            ver.ID,
+		UsesRosettaPatching:  ver.UsesRosettaPatching,
+		UsesDivxDecoderPatch: ver.UsesDivxDecoderPatch,
+		ExecutableName:       ver.ExecutableName,
+		CrossOverPath:        ver.CrossOverPath,
+		// libSiliconPatch is only available for TurtleSilicon and enabled unless the user disabled it
+		EnableLibSiliconPatch: ver.ID == "turtlesilicon" && !ver.Settings.UserDisabledLibSiliconPatch,
+		EnableShadowLOD:       !ver.Settings.UserDisabledShadowLOD,
+		RemoveShadowLOD:       ver.Settings.SetShadowLOD0,
+		ComponentVersions:     ver.Settings.ComponentVersions,
+		LargeAddressAware:     ver.Settings.LargeAddressAware,
+	}
+	// CrossOver is left to WineRuntime, so callers can still change CrossOverPath
+	if ver.WineRuntime != "" && ver.WineRuntime != wine.KindCrossOver {
+		if runtime, err := wine.ForVersion(ver); err == nil {
+			opts.Runtime = runtime
+		} else {
+			debug.Printf("Ignoring Wine runtime of %s: %v", ver.ID, err)
+		}
+	}
+	return opts
+}
+
+// RememberPatchDefaults stores the libSiliconPatch and shadowLOD choices applied by a patch in
+// the version settings, so the options dialog reflects what is installed
+func RememberPatchDefaults(ver *version.GameVersion, opts GameOptions) {
+	if !opts.UsesRosettaPatching {
+		return
+	}
+	ver.Settings.EnableLibSiliconPatch = opts.EnableLibSiliconPatch
+	if opts.EnableShadowLOD {
+		ver.Settings.SetShadowLOD0 = true
+	}
+}
+
+// Engine applies patch plans to a target directory without any UI. Progress is reported
+// through OnEvent, which may be nil.
+type Engine struct {
+	OnEvent func(PatchEvent)
+}
+
+// NewEngine creates an engine that reports progress to onEvent
+func NewEngine(onEvent func(PatchEvent)) *Engine {
+	return &Engine{OnEvent: onEvent}
+}
+
+func (e *Engine) emit(event PatchEvent) {
+	if e.OnEvent != nil {
+		e.OnEvent(event)
+	}
+}
+
+// PatchGame plans and applies the patch for a game directory
+func (e *Engine) PatchGame(gamePath string, opts GameOptions) ([]string, error) {
+	plan, err := PlanVersionPatch(gamePath, opts)
+	if err != nil {
+		return nil, err
+	}
+	return e.Apply(plan)
+}
+
+// UnpatchGame plans and applies the removal of the patch from a game directory
+func (e *Engine) UnpatchGame(gamePath string, opts GameOptions) ([]string, error) {
+	plan, err := PlanVersionUnpatch(gamePath, opts)
+	if err != nil {
+		return nil, err
+	}
+	return e.Apply(plan)
+}
+
+// PatchCrossOver plans and applies the wineloader2 patch
+func (e *Engine) PatchCrossOver(crossoverPath string) ([]string, error) {
+	plan, err := PlanCrossOverPatch(crossoverPath)
+	if err != nil {
+		return nil, err
+	}
+	return e.Apply(plan)
+}
+
+// UnpatchCrossOver plans and applies the removal of wineloader2
+func (e *Engine) UnpatchCrossOver(crossoverPath string) ([]string, error) {
+	plan, err := PlanCrossOverUnpatch(crossoverPath)
+	if err != nil {
+		return nil, err
+	}
+	return e.Apply(plan)
+}
+
+// PatchRuntime plans and applies the patch steps of a Wine runtime
+func (e *Engine) PatchRuntime(runtime wine.Runtime) ([]string, error) {
+	plan, err := PlanRuntimePatch(runtime)
+	if err != nil {
+		return nil, err
+	}
+	return e.Apply(plan)
+}
+
+// UnpatchRuntime plans and applies the removal of the files a Wine runtime patch created
+func (e *Engine) UnpatchRuntime(runtime wine.Runtime) ([]string, error) {
+	plan, err := PlanRuntimeUnpatch(runtime)
+	if err != nil {
+		return nil, err
+	}
+	return e.Apply(plan)
+}
+
+// Apply runs every action of the plan. If a required action fails, everything changed so far
+// is rolled back and a *PatchError is returned. Failed optional actions are returned as warnings.
+func (e *Engine) Apply(plan *PatchPlan) ([]string, error) {
+	warnings, err := e.apply(plan)
+
+	done := PatchEvent{Kind: EventDone, Total: len(plan.Actions), Err: err}
+	if err != nil {
+		done.Message = err.Error()
+	} else if plan.NothingToApply() {
+		done.Message = plan.EmptyMessage
+	} else {
+		done.Message = plan.SuccessMessage
+	}
+	e.emit(done)
+	return warnings, err
+}
+
+func (e *Engine) apply(plan *PatchPlan) ([]string, error) {
+	if plan.NothingToApply() {
+		debug.Printf("Plan %q has nothing to do", plan.Title)
+		return nil, nil
+	}
+
+	journal, err := newPatchJournal()
+	if err != nil {
+		return nil, &PatchError{Op: "apply", Action: "Prepare rollback", Path: plan.Root, Err: err}
+	}
+	if plan.Target == PlanTargetGame {
+		operation := backup.OpPatch
+		if plan.Unpatch {
+			operation = backup.OpUnpatch
+		}
+		if err := journal.keepBackups(plan.Root, operation); err != nil {
+			journal.discardBackups()
+			return nil, &PatchError{Op: "apply", Action: "Prepare backups", Path: plan.Root, Err: err}
+		}
+	}
+
+	var warnings []string
+	warn := func(step int, message string) {
+		debug.Printf("Warning: %s", message)
+		warnings = append(warnings, message)
+		e.emit(PatchEvent{Kind: EventWarning, Step: step, Total: len(plan.Actions), Message: message})
+	}
+
+	for _, warning := range plan.warnings {
+		warn(0, warning)
+	}
+
+	for i, action := range plan.Actions {
+		step := i + 1
+		description := plan.Describe(action)
+		debug.Printf("Plan step %d/%d: %s", step, len(plan.Actions), description)
+		e.emit(PatchEvent{Kind: EventStepStarted, Step: step, Total: len(plan.Actions), Message: description, Path: action.Path})
+
+		if err := executePlanAction(action, journal); err != nil {
+			if action.Optional {
+				warn(step, fmt.Sprintf("%s: %v", description, err))
+				continue
+			}
+
+			patchErr := &PatchError{Op: "apply", Step: step, Action: description, Path: action.Path, Err: err}
+			if rbErr := journal.rollback(); rbErr != nil {
+				debug.Printf("Rollback failed: %v", rbErr)
+				patchErr.RollbackErr = rbErr
+			} else {
+				patchErr.RolledBack = true
+			}
+			return warnings, patchErr
+		}
+
+		switch action.Kind {
+		case PlanCopyResource, PlanCopyFile, PlanRename:
+			e.emit(PatchEvent{Kind: EventFileCopied, Step: step, Total: len(plan.Actions), Message: description, Path: action.Path})
+		}
+	}
+
+	if plan.manifestMethod != "" {
+		sources := plan.installedSources()
+		if err := recordPatchManifest(plan.Root, plan.manifestMethod, sources, journal); err != nil {
+			// The patch itself is in place, only verify and repair will be unavailable
+			warn(len(plan.Actions), err.Error())
+		}
+
+		if plan.versionID != "" {
+			installed := make(map[string]string)
+			for _, source := range sources {
+				if source.Resource != "" {
+					installed[source.Resource] = source.Version
+				}
+			}
+			if err := components.RecordInstalled(plan.versionID, installed); err != nil {
+				// Only rollback to the previous component versions is affected
+				warn(len(plan.Actions), err.Error())
+			}
+		}
+	}
+
+	if plan.sourceExecutable != nil && plan.patchedExecutable != "" {
+		if err := fingerprint.RecordPatched(plan.versionID, plan.patchedExecutable, plan.sourceExecutable); err != nil {
+			// The patched executable is only reported as unknown if it is patched again
+			debug.Printf("Failed to record patched executable: %v", err)
+		}
+	}
+
+	if plan.crossoverPath != "" {
+		if err := recordCrossOverPatch(plan.crossoverPath, !plan.Unpatch); err != nil {
+			// Only the detection of CrossOver updates is affected
+			debug.Printf("Failed to record CrossOver patch: %v", err)
+		}
+	}
+
+	journal.commit()
+	if plan.OnApplied != nil {
+		plan.OnApplied()
+	}
+
+	debug.Printf("Plan %q applied successfully with %d warning(s)", plan.Title, len(warnings))
+	return warnings, nil
+}
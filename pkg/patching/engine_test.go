@@ -0,0 +1,63 @@
+package patching
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEngineApply(t *testing.T) {
+	gamePath := testGameDir(t, map[string]string{"WoW.exe": "exe", "dlls.txt": "mods/other.dll\n"})
+	opts := GameOptions{VersionID: "turtlesilicon", EnableLibSiliconPatch: true}
+
+	plan, err := PlanTurtleWoWPatch(gamePath, opts)
+	if err != nil {
+		t.Fatalf("PlanTurtleWoWPatch() error = %v", err)
+	}
+	var events []PatchEvent
+	if _, err := NewEngine(func(event PatchEvent) { events = append(events, event) }).Apply(plan); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if len(events) == 0 || events[len(events)-1].Kind != EventDone || events[len(events)-1].Err != nil {
+		t.Errorf("Apply() did not end with a successful EventDone: %+v", events)
+	}
+
+	if content, _ := os.ReadFile(filepath.Join(gamePath, "dlls.txt")); string(content) != "mods/other.dll\nmods/winerosetta.dll\nmods/libSiliconPatch.dll\n" {
+		t.Errorf("dlls.txt after patching = %q", content)
+	}
+
+	// Everything is in place, planning again has nothing left to do
+	again, err := PlanTurtleWoWPatch(gamePath, opts)
+	if err != nil {
+		t.Fatalf("PlanTurtleWoWPatch() error = %v", err)
+	}
+	if !again.IsEmpty() {
+		t.Errorf("PlanTurtleWoWPatch() after patching has actions:\n%s", strings.Join(actionSummary(again), "\n"))
+	}
+}
+
+func TestEngineApplyRollsBack(t *testing.T) {
+	gamePath := testGameDir(t, map[string]string{"d3d9.dll": "original"})
+
+	plan := &PatchPlan{Title: "Patch Game", Root: gamePath, Target: PlanTargetGame}
+	plan.add(PlanAction{Kind: PlanMkdir, Path: filepath.Join(gamePath, "mods")})
+	plan.add(PlanAction{Kind: PlanCopyResource, Path: filepath.Join(gamePath, "d3d9.dll"), Source: "winerosetta/d3d9.dll", Mode: 0644, Overwrite: true})
+	plan.add(PlanAction{Kind: PlanRename, Path: filepath.Join(gamePath, "renamed.exe"), Source: filepath.Join(gamePath, "missing.exe")})
+
+	_, err := NewEngine(nil).Apply(plan)
+	var patchErr *PatchError
+	if !errors.As(err, &patchErr) || patchErr.Step != 3 || !patchErr.RolledBack {
+		t.Fatalf("Apply() error = %v, want a rolled back PatchError at step 3", err)
+	}
+
+	if content, _ := os.ReadFile(filepath.Join(gamePath, "d3d9.dll")); string(content) != "original" {
+		t.Errorf("d3d9.dll after rollback = %q, want \"original\"", content)
+	}
+	for _, rel := range []string{"mods", "renamed.exe"} {
+		if _, err := os.Stat(filepath.Join(gamePath, rel)); !os.IsNotExist(err) {
+			t.Errorf("%s exists after rollback, want it removed", rel)
+		}
+	}
+}
@@ -0,0 +1,305 @@
+package patching
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"turtlesilicon/pkg/backup"
+	"turtlesilicon/pkg/debug"
+	"turtlesilicon/pkg/utils"
+)
+
+// journalAction identifies the kind of filesystem change recorded in a patch journal
+type journalAction int
+
+const (
+	journalCreatedFile journalAction = iota
+	journalReplacedFile
+	journalCreatedDir
+	journalRemovedPath
+)
+
+func (a journalAction) String() string {
+	switch a {
+	case journalCreatedFile:
+		return "created file"
+	case journalReplacedFile:
+		return "replaced file"
+	case journalCreatedDir:
+		return "created directory"
+	case journalRemovedPath:
+		return "removed path"
+	default:
+		return "unknown"
+	}
+}
+
+// journalEntry is a single change made to the game directory during patching
+type journalEntry struct {
+	Action     journalAction
+	Path       string
+	BackupPath string
+	Mode       os.FileMode
+}
+
+// patchJournal records every change made while patching so a failed run can be
+// rolled back to the state the game directory was in before patching started
+type patchJournal struct {
+	backupDir string
+	entries   []journalEntry
+	tracked   map[string]bool
+
+	store     *backup.Store // Keeps replaced and removed files after the journal is committed
+	operation string        // Recorded with every file saved to store
+}
+
+// newPatchJournal creates a journal whose backups are kept in the configuration directory, outside
+// of the game directory and signed bundles like CrossOver.app
+func newPatchJournal() (*patchJournal, error) {
+	configDir, err := getTurtleSiliconConfigDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find the configuration directory: %v", err)
+	}
+	rollbackDir := filepath.Join(configDir, "rollback")
+	if err := os.MkdirAll(rollbackDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create rollback directory: %v", err)
+	}
+	backupDir, err := os.MkdirTemp(rollbackDir, "journal-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create rollback directory: %v", err)
+	}
+	debug.Printf("Created patch journal with backups in: %s", backupDir)
+	return &patchJournal{
+		backupDir: backupDir,
+		tracked:   make(map[string]bool),
+	}, nil
+}
+
+// keepBackups makes the journal save every file it replaces or removes to the backup store of the
+// game directory. Unlike the rollback backups these are kept after the journal is committed.
+func (j *patchJournal) keepBackups(gamePath string, operation string) error {
+	store, err := backup.Open(gamePath)
+	if err != nil {
+		return err
+	}
+	j.store = store
+	j.operation = operation
+	return nil
+}
+
+// saveToStore backs up path to the backup store if the journal keeps backups
+func (j *patchJournal) saveToStore(path string) error {
+	if j.store == nil {
+		return nil
+	}
+	return j.store.Save(path, j.operation)
+}
+
+// nextBackupPath returns a unique path inside the backup directory for the given file
+func (j *patchJournal) nextBackupPath(path string) string {
+	return filepath.Join(j.backupDir, fmt.Sprintf("%03d_%s", len(j.entries), filepath.Base(path)))
+}
+
+// trackFile must be called before a file is written. Existing files are backed
+// up so they can be restored, new files are deleted again on rollback.
+func (j *patchJournal) trackFile(path string) error {
+	if j.tracked[path] {
+		return nil
+	}
+
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		j.entries = append(j.entries, journalEntry{Action: journalCreatedFile, Path: path})
+		j.tracked[path] = true
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %v", path, err)
+	}
+
+	if err := j.saveToStore(path); err != nil {
+		return err
+	}
+	backupPath := j.nextBackupPath(path)
+	if err := utils.CopyFile(path, backupPath); err != nil {
+		return fmt.Errorf("failed to back up %s: %v", path, err)
+	}
+	j.entries = append(j.entries, journalEntry{
+		Action:     journalReplacedFile,
+		Path:       path,
+		BackupPath: backupPath,
+		Mode:       info.Mode().Perm(),
+	})
+	j.tracked[path] = true
+	debug.Printf("Journal: backed up %s to %s", path, backupPath)
+	return nil
+}
+
+// mkdirAll creates dir and any missing parents, recording each one it created
+func (j *patchJournal) mkdirAll(dir string) error {
+	var missing []string
+	for current := dir; !utils.PathExists(current); current = filepath.Dir(current) {
+		missing = append(missing, current)
+		if filepath.Dir(current) == current {
+			break
+		}
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	// Record outermost first so rollback removes the innermost first
+	for i := len(missing) - 1; i >= 0; i-- {
+		j.entries = append(j.entries, journalEntry{Action: journalCreatedDir, Path: missing[i]})
+		j.tracked[missing[i]] = true
+	}
+	return nil
+}
+
+// removeAll moves path into the backup directory instead of deleting it
+func (j *patchJournal) removeAll(path string) error {
+	if !utils.PathExists(path) {
+		return nil
+	}
+
+	if err := j.saveToStore(path); err != nil {
+		return err
+	}
+	backupPath := j.nextBackupPath(path)
+	if err := movePath(path, backupPath); err != nil {
+		return fmt.Errorf("failed to move %s out of the way: %v", path, err)
+	}
+	j.entries = append(j.entries, journalEntry{Action: journalRemovedPath, Path: path, BackupPath: backupPath})
+	debug.Printf("Journal: moved %s to %s", path, backupPath)
+	return nil
+}
+
+// rollback replays the journal in reverse, restoring the game directory
+func (j *patchJournal) rollback() error {
+	debug.Printf("Rolling back %d patch journal entries", len(j.entries))
+
+	var failures []string
+	for i := len(j.entries) - 1; i >= 0; i-- {
+		entry := j.entries[i]
+		var err error
+
+		switch entry.Action {
+		case journalCreatedFile:
+			err = os.Remove(entry.Path)
+			if os.IsNotExist(err) {
+				err = nil
+			}
+		case journalReplacedFile:
+			if err = utils.CopyFile(entry.BackupPath, entry.Path); err == nil {
+				err = os.Chmod(entry.Path, entry.Mode)
+			}
+		case journalCreatedDir:
+			// Anything inside was created by this run and is removed by earlier entries,
+			// so only remove the directory if it is empty now
+			err = os.Remove(entry.Path)
+			if os.IsNotExist(err) {
+				err = nil
+			}
+		case journalRemovedPath:
+			if rmErr := os.RemoveAll(entry.Path); rmErr != nil {
+				err = rmErr
+			} else {
+				err = movePath(entry.BackupPath, entry.Path)
+			}
+		}
+
+		if err != nil {
+			debug.Printf("Rollback: failed to undo %s %s: %v", entry.Action, entry.Path, err)
+			failures = append(failures, fmt.Sprintf("%s (%s): %v", entry.Path, entry.Action, err))
+		} else {
+			debug.Printf("Rollback: undid %s %s", entry.Action, entry.Path)
+		}
+	}
+
+	if len(failures) > 0 {
+		// Keep the backups around so nothing is lost if the restore was incomplete
+		return fmt.Errorf("rollback incomplete, backups kept in %s:\n%s", j.backupDir, strings.Join(failures, "\n"))
+	}
+
+	j.discardBackups()
+	return nil
+}
+
+// commit marks the patch as successful and removes the backups
+func (j *patchJournal) commit() {
+	debug.Printf("Committing patch journal with %d entries", len(j.entries))
+	j.discardBackups()
+}
+
+func (j *patchJournal) discardBackups() {
+	if err := os.RemoveAll(j.backupDir); err != nil {
+		debug.Printf("Warning: failed to remove rollback directory %s: %v", j.backupDir, err)
+	}
+}
+
+// movePath renames src to dst. Paths on another volume than the configuration directory, like a
+// game on an external drive, are copied and removed instead.
+func movePath(src, dst string) error {
+	err := os.Rename(src, dst)
+	if err == nil || !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+	debug.Printf("Journal: %s is on another volume, copying it to %s", src, dst)
+	if err := copyPath(src, dst); err != nil {
+		os.RemoveAll(dst)
+		return err
+	}
+	return os.RemoveAll(src)
+}
+
+// copyPath copies a file, symlink or directory tree and keeps the permissions
+func copyPath(src, dst string) error {
+	info, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case info.Mode()&os.ModeSymlink != 0:
+		target, err := os.Readlink(src)
+		if err != nil {
+			return err
+		}
+		return os.Symlink(target, dst)
+	case info.IsDir():
+		if err := os.Mkdir(dst, info.Mode().Perm()); err != nil {
+			return err
+		}
+		entries, err := os.ReadDir(src)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if err := copyPath(filepath.Join(src, entry.Name()), filepath.Join(dst, entry.Name())); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		if err := utils.CopyFile(src, dst); err != nil {
+			return err
+		}
+		return os.Chmod(dst, info.Mode().Perm())
+	}
+}
+
+// writeFile writes data to path after recording it in the journal
+func (j *patchJournal) writeFile(path string, data []byte, perm os.FileMode) error {
+	if err := j.trackFile(path); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, perm); err != nil {
+		return err
+	}
+	return os.Chmod(path, perm)
+}
@@ -0,0 +1,154 @@
+package patching
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestJournalRollback(t *testing.T) {
+	configDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configDir)
+	t.Setenv("HOME", configDir)
+	gamePath := t.TempDir()
+	write := func(rel, content string) {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(gamePath, rel)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(gamePath, rel), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("WoW.exe", "original exe")
+	write("rosettax87/rosettax87", "old binary")
+	write("dlls.txt", "winerosetta.dll\n")
+
+	journal, err := newPatchJournal()
+	if err != nil {
+		t.Fatalf("newPatchJournal() error = %v", err)
+	}
+	if rel, err := filepath.Rel(configDir, journal.backupDir); err != nil || strings.HasPrefix(rel, "..") {
+		t.Errorf("rollback directory %s is outside of the configuration directory %s", journal.backupDir, configDir)
+	}
+
+	// Replace a file twice, only the first content counts
+	for _, content := range []string{"patched exe", "patched again"} {
+		if err := journal.writeFile(filepath.Join(gamePath, "WoW.exe"), []byte(content), 0755); err != nil {
+			t.Fatalf("writeFile() error = %v", err)
+		}
+	}
+	if err := journal.mkdirAll(filepath.Join(gamePath, "mods", "sub")); err != nil {
+		t.Fatalf("mkdirAll() error = %v", err)
+	}
+	if err := journal.writeFile(filepath.Join(gamePath, "mods", "sub", "new.dll"), []byte("new"), 0644); err != nil {
+		t.Fatalf("writeFile() error = %v", err)
+	}
+	if err := journal.removeAll(filepath.Join(gamePath, "rosettax87")); err != nil {
+		t.Fatalf("removeAll() error = %v", err)
+	}
+	write("rosettax87/rosettax87", "new binary")
+	if err := journal.removeAll(filepath.Join(gamePath, "missing")); err != nil {
+		t.Errorf("removeAll() of a missing path error = %v, want nil", err)
+	}
+
+	if err := journal.rollback(); err != nil {
+		t.Fatalf("rollback() error = %v", err)
+	}
+
+	tests := []struct {
+		rel  string
+		want string // Empty if the path must not exist
+	}{
+		{"WoW.exe", "original exe"},
+		{"rosettax87/rosettax87", "old binary"},
+		{"dlls.txt", "winerosetta.dll\n"},
+		{"mods/sub/new.dll", ""},
+		{"mods", ""},
+	}
+	for _, tt := range tests {
+		content, err := os.ReadFile(filepath.Join(gamePath, tt.rel))
+		if tt.want == "" {
+			if _, statErr := os.Stat(filepath.Join(gamePath, tt.rel)); !os.IsNotExist(statErr) {
+				t.Errorf("%s exists after rollback, want it removed", tt.rel)
+			}
+			continue
+		}
+		if err != nil || string(content) != tt.want {
+			t.Errorf("%s after rollback = %q, %v, want %q", tt.rel, content, err, tt.want)
+		}
+	}
+	if info, err := os.Stat(filepath.Join(gamePath, "WoW.exe")); err == nil && info.Mode().Perm() != 0644 {
+		t.Errorf("WoW.exe mode after rollback = %v, want 0644", info.Mode().Perm())
+	}
+
+	entries, _ := os.ReadDir(gamePath)
+	if len(entries) != 3 {
+		t.Errorf("game directory has %d entries after rollback, want 3 without the rollback directory", len(entries))
+	}
+}
+
+func TestJournalCommit(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	gamePath := t.TempDir()
+	journal, err := newPatchJournal()
+	if err != nil {
+		t.Fatalf("newPatchJournal() error = %v", err)
+	}
+	path := filepath.Join(gamePath, "d3d9.dll")
+	if err := journal.writeFile(path, []byte("d3d9"), 0644); err != nil {
+		t.Fatalf("writeFile() error = %v", err)
+	}
+	journal.commit()
+
+	if content, err := os.ReadFile(path); err != nil || string(content) != "d3d9" {
+		t.Errorf("d3d9.dll after commit = %q, %v, want \"d3d9\"", content, err)
+	}
+	if _, err := os.Stat(journal.backupDir); !os.IsNotExist(err) {
+		t.Errorf("rollback directory still exists after commit")
+	}
+}
+
+func TestCopyPath(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "rosettax87")
+	if err := os.MkdirAll(filepath.Join(src, "lib"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "rosettax87"), []byte("binary"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "lib", "data"), []byte("data"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("rosettax87", filepath.Join(src, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	// The fallback for moves across volumes, which a single temporary directory can't produce
+	dst := filepath.Join(t.TempDir(), "backup")
+	if err := copyPath(src, dst); err != nil {
+		t.Fatalf("copyPath() error = %v", err)
+	}
+
+	tests := []struct {
+		rel     string
+		content string
+		mode    os.FileMode
+	}{
+		{"rosettax87", "binary", 0755},
+		{"lib/data", "data", 0600},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(dst, filepath.FromSlash(tt.rel))
+		if content, err := os.ReadFile(path); err != nil || string(content) != tt.content {
+			t.Errorf("%s = %q, %v, want %q", tt.rel, content, err, tt.content)
+		}
+		if info, err := os.Stat(path); err == nil && info.Mode().Perm() != tt.mode {
+			t.Errorf("%s mode = %v, want %v", tt.rel, info.Mode().Perm(), tt.mode)
+		}
+	}
+	if target, err := os.Readlink(filepath.Join(dst, "link")); err != nil || target != "rosettax87" {
+		t.Errorf("link = %q, %v, want a symlink to rosettax87", target, err)
+	}
+}
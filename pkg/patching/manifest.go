@@ -0,0 +1,377 @@
+package patching
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"turtlesilicon/pkg/backup"
+	"turtlesilicon/pkg/components"
+	"turtlesilicon/pkg/debug"
+	"turtlesilicon/pkg/utils"
+)
+
+// PatchManifestFileName is the file written into the game directory listing everything patching installed
+const PatchManifestFileName = "turtlesilicon_patch.json"
+
+// Patch methods recorded in the manifest
+const (
+	PatchMethodRosetta     = "rosetta"
+	PatchMethodDivxDecoder = "divx-decoder"
+	PatchMethodLibDllLdr   = "libdllldr"
+)
+
+// ManifestFile describes a single file installed by patching
+type ManifestFile struct {
+	Path       string `json:"path"` // Relative to the game directory, always forward slashes
+	SHA256     string `json:"sha256"`
+	Size       int64  `json:"size"`
+	Resource   string `json:"resource,omitempty"`          // Bundled resource the file was copied from, empty for generated files
+	Version    string `json:"component_version,omitempty"` // Component version of Resource, see components.Resolve
+	Method     string `json:"method"`
+	Executable bool   `json:"executable,omitempty"`
+}
+
+// PatchManifest lists every file installed into a game directory by patching
+type PatchManifest struct {
+	Method      string         `json:"method"`
+	PatchedAt   time.Time      `json:"patched_at"`
+	ManagedDirs []string       `json:"managed_dirs,omitempty"` // Directories whose whole content belongs to the patch
+	Files       []ManifestFile `json:"files"`
+}
+
+// ManifestVerifyResult reports how a game directory differs from its patch manifest
+type ManifestVerifyResult struct {
+	Missing  []ManifestFile
+	Modified []ManifestFile
+	Extra    []string
+}
+
+// ManifestRepairResult reports what RepairPatchManifest changed
+type ManifestRepairResult struct {
+	Restored     []string
+	Removed      []string
+	Unrepairable []string // Generated files that need a full re-patch
+}
+
+// manifestSource is a file that patching installed, used to build the manifest after a successful run
+type manifestSource struct {
+	Path       string
+	Resource   string
+	Version    string
+	Executable bool
+}
+
+// rosettaManifestSources are the rosettax87 binaries every patch method installs
+var rosettaManifestSources = []manifestSource{
+	{Path: "rosettax87/rosettax87", Resource: "rosettax87/rosettax87", Executable: true},
+	{Path: "rosettax87/libRuntimeRosettax87", Resource: "rosettax87/libRuntimeRosettax87", Executable: true},
+}
+
+// OK returns true if the game directory matches the manifest exactly
+func (r *ManifestVerifyResult) OK() bool {
+	return len(r.Missing) == 0 && len(r.Modified) == 0 && len(r.Extra) == 0
+}
+
+// Summary returns a human readable description of the verification result
+func (r *ManifestVerifyResult) Summary() string {
+	if r.OK() {
+		return "All patched files are intact."
+	}
+
+	var sb strings.Builder
+	if len(r.Missing) > 0 {
+		sb.WriteString("Missing files:\n")
+		for _, file := range r.Missing {
+			sb.WriteString("  - " + file.Path + "\n")
+		}
+	}
+	if len(r.Modified) > 0 {
+		sb.WriteString("Modified files:\n")
+		for _, file := range r.Modified {
+			sb.WriteString("  - " + file.Path + "\n")
+		}
+	}
+	if len(r.Extra) > 0 {
+		sb.WriteString("Unexpected files:\n")
+		for _, path := range r.Extra {
+			sb.WriteString("  - " + path + "\n")
+		}
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// fileSHA256 calculates the SHA-256 hash and size of a file
+func fileSHA256(filePath string) (string, int64, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", 0, err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, file)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), size, nil
+}
+
+// buildPatchManifest hashes the installed files and returns the manifest describing them
+func buildPatchManifest(gamePath string, method string, sources []manifestSource) (*PatchManifest, error) {
+	manifest := &PatchManifest{
+		Method:    method,
+		PatchedAt: time.Now(),
+	}
+
+	managedDirs := make(map[string]bool)
+	for _, source := range sources {
+		hash, size, err := fileSHA256(filepath.Join(gamePath, filepath.FromSlash(source.Path)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash %s: %v", source.Path, err)
+		}
+		manifest.Files = append(manifest.Files, ManifestFile{
+			Path:       source.Path,
+			SHA256:     hash,
+			Size:       size,
+			Resource:   source.Resource,
+			Version:    source.Version,
+			Method:     method,
+			Executable: source.Executable,
+		})
+		if strings.HasPrefix(source.Path, "rosettax87/") {
+			managedDirs["rosettax87"] = true
+		}
+	}
+
+	for dir := range managedDirs {
+		manifest.ManagedDirs = append(manifest.ManagedDirs, dir)
+	}
+	sort.Strings(manifest.ManagedDirs)
+	return manifest, nil
+}
+
+// writePatchManifest saves the manifest into the game directory, recording the write in journal if one is given
+func writePatchManifest(gamePath string, manifest *PatchManifest, journal *patchJournal) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode patch manifest: %v", err)
+	}
+
+	manifestPath := filepath.Join(gamePath, PatchManifestFileName)
+	if journal != nil {
+		err = journal.writeFile(manifestPath, data, 0644)
+	} else {
+		err = os.WriteFile(manifestPath, data, 0644)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write patch manifest: %v", err)
+	}
+
+	debug.Printf("Wrote patch manifest with %d files to %s", len(manifest.Files), manifestPath)
+	return nil
+}
+
+// recordPatchManifest builds and writes the manifest after a successful patch. Callers treat a
+// failure as a warning because the patch itself is already in place.
+func recordPatchManifest(gamePath string, method string, sources []manifestSource, journal *patchJournal) error {
+	manifest, err := buildPatchManifest(gamePath, method, sources)
+	if err != nil {
+		return fmt.Errorf("failed to build patch manifest: %v", err)
+	}
+	return writePatchManifest(gamePath, manifest, journal)
+}
+
+// LoadPatchManifest reads the patch manifest from the game directory. It returns nil without an error
+// if the game was patched before manifests existed or was never patched.
+func LoadPatchManifest(gamePath string) (*PatchManifest, error) {
+	data, err := os.ReadFile(filepath.Join(gamePath, PatchManifestFileName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read patch manifest: %v", err)
+	}
+
+	var manifest PatchManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse patch manifest: %v", err)
+	}
+	return &manifest, nil
+}
+
+// VerifyPatchManifest compares the game directory against its patch manifest
+func VerifyPatchManifest(gamePath string) (*ManifestVerifyResult, error) {
+	if gamePath == "" {
+		return nil, fmt.Errorf("game path not set")
+	}
+
+	manifest, err := LoadPatchManifest(gamePath)
+	if err != nil {
+		return nil, err
+	}
+	if manifest == nil {
+		return nil, fmt.Errorf("no patch manifest found in %s. Please patch the game again to create one", gamePath)
+	}
+
+	return verifyManifestFiles(gamePath, manifest), nil
+}
+
+func verifyManifestFiles(gamePath string, manifest *PatchManifest) *ManifestVerifyResult {
+	result := &ManifestVerifyResult{}
+	known := make(map[string]bool)
+
+	for _, file := range manifest.Files {
+		known[file.Path] = true
+		fullPath := filepath.Join(gamePath, filepath.FromSlash(file.Path))
+
+		if !utils.PathExists(fullPath) {
+			debug.Printf("Manifest verification: %s is missing", file.Path)
+			result.Missing = append(result.Missing, file)
+			continue
+		}
+
+		hash, size, err := fileSHA256(fullPath)
+		if err != nil || size != file.Size || hash != file.SHA256 {
+			debug.Printf("Manifest verification: %s was modified (size=%d, hash=%s)", file.Path, size, hash)
+			result.Modified = append(result.Modified, file)
+		}
+	}
+
+	for _, dir := range manifest.ManagedDirs {
+		entries, err := os.ReadDir(filepath.Join(gamePath, filepath.FromSlash(dir)))
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			relPath := dir + "/" + entry.Name()
+			if !known[relPath] {
+				debug.Printf("Manifest verification: %s is not part of the patch", relPath)
+				result.Extra = append(result.Extra, relPath)
+			}
+		}
+	}
+
+	return result
+}
+
+// RepairPatchManifest restores missing or modified files from the bundled resources and removes
+// unexpected files from managed directories. Everything else in the game directory is left alone.
+func RepairPatchManifest(gamePath string) (*ManifestRepairResult, error) {
+	result, err := VerifyPatchManifest(gamePath)
+	if err != nil {
+		return nil, err
+	}
+
+	repair := &ManifestRepairResult{}
+	if result.OK() {
+		return repair, nil
+	}
+
+	manifest, err := LoadPatchManifest(gamePath)
+	if err != nil {
+		return nil, err
+	}
+
+	journal, err := newPatchJournal()
+	if err != nil {
+		return nil, err
+	}
+	if err := journal.keepBackups(gamePath, backup.OpRepair); err != nil {
+		journal.discardBackups()
+		return nil, err
+	}
+
+	broken := append(append([]ManifestFile{}, result.Missing...), result.Modified...)
+	for _, file := range broken {
+		if file.Resource == "" {
+			debug.Printf("Cannot repair generated file %s, a full re-patch is needed", file.Path)
+			repair.Unrepairable = append(repair.Unrepairable, file.Path)
+			continue
+		}
+
+		if err := restoreManifestFile(gamePath, manifest, file, journal); err != nil {
+			if rbErr := journal.rollback(); rbErr != nil {
+				debug.Printf("Rollback after failed repair failed: %v", rbErr)
+			}
+			return nil, fmt.Errorf("failed to restore %s: %v", file.Path, err)
+		}
+		repair.Restored = append(repair.Restored, file.Path)
+	}
+
+	for _, relPath := range result.Extra {
+		if err := journal.removeAll(filepath.Join(gamePath, filepath.FromSlash(relPath))); err != nil {
+			if rbErr := journal.rollback(); rbErr != nil {
+				debug.Printf("Rollback after failed repair failed: %v", rbErr)
+			}
+			return nil, fmt.Errorf("failed to remove %s: %v", relPath, err)
+		}
+		repair.Removed = append(repair.Removed, relPath)
+	}
+
+	if err := writePatchManifest(gamePath, manifest, journal); err != nil {
+		if rbErr := journal.rollback(); rbErr != nil {
+			debug.Printf("Rollback after failed repair failed: %v", rbErr)
+		}
+		return nil, err
+	}
+
+	journal.commit()
+	debug.Printf("Repair complete: restored=%v removed=%v unrepairable=%v", repair.Restored, repair.Removed, repair.Unrepairable)
+	return repair, nil
+}
+
+// restoreManifestFile rewrites a single file from the component build it was installed from and
+// updates its manifest entry
+func restoreManifestFile(gamePath string, manifest *PatchManifest, file ManifestFile, journal *patchJournal) error {
+	build, err := components.Resolve(file.Resource, file.Version)
+	if err != nil {
+		// The cached build is gone, the bundled copy is the only one left
+		debug.Printf("Cannot restore %s from %s %s (%v), using the bundled one", file.Path, file.Resource, file.Version, err)
+		build, err = components.Resolve(file.Resource, components.VersionBundled)
+		if err != nil {
+			return err
+		}
+	}
+	content, err := build.ReadFile()
+	if err != nil {
+		return err
+	}
+
+	resourceHash := build.SHA256
+	if resourceHash != file.SHA256 {
+		// The build changed since the game was patched, e.g. the app was updated
+		debug.Printf("%s differs from the patched version, using it anyway", build)
+	}
+
+	fullPath := filepath.Join(gamePath, filepath.FromSlash(file.Path))
+	if err := journal.mkdirAll(filepath.Dir(fullPath)); err != nil {
+		return err
+	}
+
+	perm := os.FileMode(0644)
+	if file.Executable {
+		perm = 0755
+	}
+	if err := journal.writeFile(fullPath, content, perm); err != nil {
+		return err
+	}
+
+	for i := range manifest.Files {
+		if manifest.Files[i].Path == file.Path {
+			manifest.Files[i].SHA256 = resourceHash
+			manifest.Files[i].Size = int64(len(content))
+			manifest.Files[i].Version = build.Version
+		}
+	}
+
+	debug.Printf("Restored %s from %s", file.Path, build)
+	return nil
+}
@@ -1,556 +1,19 @@
 package patching
 
 import (
-	"bytes"
-	"errors"
 	"fmt"
-	"io"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"regexp"
 	"strings"
 
 	"turtlesilicon/pkg/debug"
+	"turtlesilicon/pkg/dllstxt"
 	"turtlesilicon/pkg/paths" // Corrected import path
 	"turtlesilicon/pkg/utils" // Corrected import path
 	"turtlesilicon/pkg/version"
-
-	"fyne.io/fyne/v2"
-	"fyne.io/fyne/v2/dialog"
 )
 
-func PatchTurtleWoW(myWindow fyne.Window, updateAllStatuses func()) {
-	debug.Println("Patch TurtleWoW clicked")
-	if paths.TurtlewowPath == "" {
-		dialog.ShowError(fmt.Errorf("game path not set. Please set it first."), myWindow)
-		return
-	}
-
-	// Create mods directory if it doesn't exist
-	modsDir := filepath.Join(paths.TurtlewowPath, "mods")
-	if !utils.DirExists(modsDir) {
-		debug.Printf("Creating mods directory: %s", modsDir)
-		if err := os.MkdirAll(modsDir, 0755); err != nil {
-			errMsg := fmt.Sprintf("failed to create mods directory: %v", err)
-			dialog.ShowError(errors.New(errMsg), myWindow)
-			debug.Println(errMsg)
-			paths.PatchesAppliedTurtleWoW = false
-			updateAllStatuses()
-			return
-		}
-	}
-
-	targetWinerosettaDll := filepath.Join(modsDir, "winerosetta.dll")
-	targetD3d9Dll := filepath.Join(paths.TurtlewowPath, "d3d9.dll") // d3d9.dll stays in root
-	targetLibSiliconPatchDll := filepath.Join(modsDir, "libSiliconPatch.dll")
-	targetRosettaX87Dir := filepath.Join(paths.TurtlewowPath, "rosettax87")
-	dllsTextFile := filepath.Join(paths.TurtlewowPath, "dlls.txt")
-	filesToCopy := map[string]string{
-		"winerosetta/winerosetta.dll":     targetWinerosettaDll,
-		"winerosetta/d3d9.dll":            targetD3d9Dll,
-		"winerosetta/libSiliconPatch.dll": targetLibSiliconPatchDll,
-	}
-
-	for resourceName, destPath := range filesToCopy {
-		debug.Printf("Processing resource: %s to %s", resourceName, destPath)
-
-		// Check if file already exists and has correct size
-		if utils.PathExists(destPath) && utils.CompareFileWithBundledResource(destPath, resourceName) {
-			debug.Printf("File %s already exists with correct size, skipping copy", destPath)
-			continue
-		}
-
-		if utils.PathExists(destPath) {
-			debug.Printf("File %s exists but has incorrect size, updating...", destPath)
-		} else {
-			debug.Printf("File %s does not exist, creating...", destPath)
-		}
-
-		resource, err := fyne.LoadResourceFromPath(resourceName)
-		if err != nil {
-			errMsg := fmt.Sprintf("failed to open bundled resource %s: %v", resourceName, err)
-			dialog.ShowError(errors.New(errMsg), myWindow)
-			debug.Println(errMsg)
-			paths.PatchesAppliedTurtleWoW = false
-			updateAllStatuses()
-			return
-		}
-
-		destinationFile, err := os.Create(destPath)
-		if err != nil {
-			errMsg := fmt.Sprintf("failed to create destination file %s: %v", destPath, err)
-			dialog.ShowError(errors.New(errMsg), myWindow)
-			debug.Println(errMsg)
-			paths.PatchesAppliedTurtleWoW = false
-			updateAllStatuses()
-			return
-		}
-		defer destinationFile.Close()
-
-		_, err = io.Copy(destinationFile, bytes.NewReader(resource.Content()))
-		if err != nil {
-			errMsg := fmt.Sprintf("failed to copy bundled resource %s to %s: %v", resourceName, destPath, err)
-			dialog.ShowError(errors.New(errMsg), myWindow)
-			debug.Println(errMsg)
-			paths.PatchesAppliedTurtleWoW = false
-			updateAllStatuses()
-			return
-		}
-		debug.Printf("Successfully copied %s to %s", resourceName, destPath)
-	}
-
-	debug.Printf("Preparing rosettax87 directory at: %s", targetRosettaX87Dir)
-	if err := os.RemoveAll(targetRosettaX87Dir); err != nil {
-		debug.Printf("Warning: could not remove existing rosettax87 folder '%s': %v", targetRosettaX87Dir, err)
-	}
-	if err := os.MkdirAll(targetRosettaX87Dir, 0755); err != nil {
-		errMsg := fmt.Sprintf("failed to create directory %s: %v", targetRosettaX87Dir, err)
-		dialog.ShowError(errors.New(errMsg), myWindow)
-		debug.Println(errMsg)
-		paths.PatchesAppliedTurtleWoW = false
-		updateAllStatuses()
-		return
-	}
-
-	rosettaFilesToCopy := map[string]string{
-		"rosettax87/rosettax87":           filepath.Join(targetRosettaX87Dir, "rosettax87"),
-		"rosettax87/libRuntimeRosettax87": filepath.Join(targetRosettaX87Dir, "libRuntimeRosettax87"),
-	}
-
-	for resourceName, destPath := range rosettaFilesToCopy {
-		debug.Printf("Processing rosetta resource: %s to %s", resourceName, destPath)
-
-		// Check if file already exists and has correct size and hash
-		if utils.PathExists(destPath) && utils.CompareFileWithBundledResource(destPath, resourceName) {
-			debug.Printf("File %s already exists with correct size and hash, skipping copy", destPath)
-
-			// Ensure executable permission for rosettax87
-			if filepath.Base(destPath) == "rosettax87" {
-				if err := os.Chmod(destPath, 0755); err != nil {
-					debug.Printf("Warning: failed to set execute permission for existing %s: %v", destPath, err)
-				}
-			}
-			continue
-		}
-
-		if utils.PathExists(destPath) {
-			debug.Printf("File %s exists but has incorrect size/hash, updating...", destPath)
-		} else {
-			debug.Printf("File %s does not exist, creating...", destPath)
-		}
-
-		resource, err := fyne.LoadResourceFromPath(resourceName)
-		if err != nil {
-			errMsg := fmt.Sprintf("failed to open bundled resource %s: %v", resourceName, err)
-			dialog.ShowError(errors.New(errMsg), myWindow)
-			debug.Println(errMsg)
-			paths.PatchesAppliedTurtleWoW = false
-			updateAllStatuses()
-			return
-		}
-
-		destinationFile, err := os.Create(destPath)
-		if err != nil {
-			errMsg := fmt.Sprintf("failed to create destination file %s: %v", destPath, err)
-			dialog.ShowError(errors.New(errMsg), myWindow)
-			debug.Println(errMsg)
-			paths.PatchesAppliedTurtleWoW = false
-			updateAllStatuses()
-			return
-		}
-
-		_, err = io.Copy(destinationFile, bytes.NewReader(resource.Content()))
-		if err != nil {
-			destinationFile.Close()
-			errMsg := fmt.Sprintf("failed to copy bundled resource %s to %s: %v", resourceName, destPath, err)
-			dialog.ShowError(errors.New(errMsg), myWindow)
-			debug.Println(errMsg)
-			paths.PatchesAppliedTurtleWoW = false
-			updateAllStatuses()
-			return
-		}
-		destinationFile.Close()
-
-		if filepath.Base(destPath) == "rosettax87" {
-			debug.Printf("Setting execute permission for %s", destPath)
-			if err := os.Chmod(destPath, 0755); err != nil {
-				errMsg := fmt.Sprintf("failed to set execute permission for %s: %v", destPath, err)
-				dialog.ShowError(errors.New(errMsg), myWindow)
-				debug.Println(errMsg)
-				paths.PatchesAppliedTurtleWoW = false
-				updateAllStatuses()
-				return
-			}
-		}
-		debug.Printf("Successfully copied %s to %s", resourceName, destPath)
-	}
-
-	debug.Printf("Checking dlls.txt file at: %s", dllsTextFile)
-	winerosettaEntry := "mods/winerosetta.dll"
-	libSiliconPatchEntry := "mods/libSiliconPatch.dll"
-	needsWinerosettaUpdate := true
-	needsLibSiliconPatchUpdate := true
-
-	// Enable by default unless user has explicitly disabled them
-	shouldEnableLibSiliconPatch := true
-	shouldEnableShadowLOD := true
-
-	// Get current version settings
-	vm, err := version.LoadVersionManager()
-	if err != nil {
-		debug.Printf("Warning: failed to load version manager: %v", err)
-		// Fall back to enable by default (variables already set above)
-	} else {
-		currentVer, err := vm.GetCurrentVersion()
-		if err != nil {
-			debug.Printf("Warning: failed to get current version: %v", err)
-			// Fall back to enable by default (variables already set above)
-		} else {
-			// Check if user has explicitly disabled them
-			shouldEnableLibSiliconPatch = !currentVer.Settings.UserDisabledLibSiliconPatch
-			shouldEnableShadowLOD = !currentVer.Settings.UserDisabledShadowLOD
-
-			// libSiliconPatch is only available for TurtleSilicon
-			if currentVer.ID != "turtlesilicon" {
-				shouldEnableLibSiliconPatch = false
-				currentVer.Settings.EnableLibSiliconPatch = false
-				debug.Printf("libSiliconPatch disabled for version %s (only available for turtlesilicon)", currentVer.ID)
-			} else if currentVer.Settings.UserDisabledLibSiliconPatch {
-				debug.Printf("libSiliconPatch disabled by user choice")
-			} else {
-				// Enable by default and update settings for TurtleSilicon
-				currentVer.Settings.EnableLibSiliconPatch = true
-				debug.Printf("libSiliconPatch enabled by default (new user or not explicitly disabled)")
-			}
-
-			if currentVer.Settings.UserDisabledShadowLOD {
-				debug.Printf("shadowLOD disabled by user choice")
-			} else {
-				// Enable by default and update settings
-				currentVer.Settings.SetShadowLOD0 = true
-				debug.Printf("shadowLOD enabled by default (new user or not explicitly disabled)")
-			}
-
-			// Save updated version settings
-			vm.UpdateVersion(currentVer)
-		}
-	}
-
-	if fileContentBytes, err := os.ReadFile(dllsTextFile); err == nil {
-		fileContent := string(fileContentBytes)
-		if strings.Contains(fileContent, winerosettaEntry) {
-			debug.Printf("dlls.txt already contains %s", winerosettaEntry)
-			needsWinerosettaUpdate = false
-		}
-		if strings.Contains(fileContent, libSiliconPatchEntry) {
-			debug.Printf("dlls.txt already contains %s", libSiliconPatchEntry)
-			needsLibSiliconPatchUpdate = false
-		}
-	} else {
-		debug.Printf("dlls.txt not found, will create a new one")
-	}
-
-	// Only add libSiliconPatch if user wants it enabled
-	if !shouldEnableLibSiliconPatch {
-		needsLibSiliconPatchUpdate = false
-		debug.Printf("libSiliconPatch disabled by user preference, will not add to dlls.txt")
-	}
-
-	if needsWinerosettaUpdate || needsLibSiliconPatchUpdate {
-		var fileContentBytes []byte
-		var err error
-		if utils.PathExists(dllsTextFile) {
-			fileContentBytes, err = os.ReadFile(dllsTextFile)
-			if err != nil {
-				errMsg := fmt.Sprintf("failed to read dlls.txt for update: %v", err)
-				dialog.ShowError(errors.New(errMsg), myWindow)
-				debug.Println(errMsg)
-			}
-		}
-
-		currentContent := string(fileContentBytes)
-		updatedContent := currentContent
-
-		if len(updatedContent) > 0 && !strings.HasSuffix(updatedContent, "\n") {
-			updatedContent += "\n"
-		}
-
-		if needsWinerosettaUpdate {
-			if !strings.Contains(updatedContent, winerosettaEntry+"\n") {
-				updatedContent += winerosettaEntry + "\n"
-				debug.Printf("Adding %s to dlls.txt", winerosettaEntry)
-			}
-		}
-		if needsLibSiliconPatchUpdate && shouldEnableLibSiliconPatch {
-			if !strings.Contains(updatedContent, libSiliconPatchEntry+"\n") {
-				updatedContent += libSiliconPatchEntry + "\n"
-				debug.Printf("Adding %s to dlls.txt", libSiliconPatchEntry)
-			}
-		}
-
-		if err := os.WriteFile(dllsTextFile, []byte(updatedContent), 0644); err != nil {
-			errMsg := fmt.Sprintf("failed to update dlls.txt: %v", err)
-			dialog.ShowError(errors.New(errMsg), myWindow)
-			debug.Println(errMsg)
-		} else {
-			debug.Printf("Successfully updated dlls.txt")
-		}
-	}
-
-	// If user has disabled libSiliconPatch, make sure it's removed from dlls.txt
-	if !shouldEnableLibSiliconPatch {
-		if err := disableLibSiliconPatchInDlls(); err != nil {
-			debug.Printf("Warning: failed to remove libSiliconPatch from dlls.txt: %v", err)
-		}
-	}
-
-	// Always apply vertex animation shaders setting to Config.wtf
-	if err := applyVertexAnimShadersSetting(); err != nil {
-		debug.Printf("Warning: failed to apply vertex animation shaders setting to Config.wtf: %v", err)
-		// Continue with patching even if Config.wtf update fails
-	}
-
-	if err := applyMovieSetting(); err != nil {
-		debug.Printf("Warning: failed to apply movie setting to Config.wtf: %v", err)
-	}
-
-	// Apply shadowLOD setting to Config.wtf for FPS optimization
-	// Use shouldEnableShadowLOD which accounts for first-time patching
-	if shouldEnableShadowLOD {
-		if err := applyShadowLODSetting(); err != nil {
-			debug.Printf("Warning: failed to apply shadowLOD setting to Config.wtf: %v", err)
-			// Continue with patching even if Config.wtf update fails
-		}
-	} else {
-		// If user has disabled shadowLOD, make sure it's removed from Config.wtf
-		if err := removeShadowLODSetting(); err != nil {
-			debug.Printf("Warning: failed to remove shadowLOD setting from Config.wtf: %v", err)
-		}
-	}
-
-	debug.Println("TurtleWoW patching with bundled resources completed successfully.")
-	dialog.ShowInformation("Success", "TurtleWoW patching process completed using bundled resources.", myWindow)
-	updateAllStatuses()
-}
-
-func PatchCrossOver(myWindow fyne.Window, updateAllStatuses func()) {
-	debug.Println("Patch CrossOver clicked")
-	if paths.CrossoverPath == "" {
-		dialog.ShowError(fmt.Errorf("CrossOver path not set. Please set it first."), myWindow)
-		return
-	}
-
-	wineloaderBasePath := filepath.Join(paths.CrossoverPath, "Contents", "SharedSupport", "CrossOver", "CrossOver-Hosted Application")
-	wineloaderOrig := filepath.Join(wineloaderBasePath, "wineloader")
-	wineloaderCopy := filepath.Join(wineloaderBasePath, "wineloader2")
-
-	if !utils.PathExists(wineloaderOrig) {
-		dialog.ShowError(fmt.Errorf("original wineloader not found at %s", wineloaderOrig), myWindow)
-		paths.PatchesAppliedCrossOver = false
-		updateAllStatuses()
-		return
-	}
-
-	debug.Printf("Copying %s to %s", wineloaderOrig, wineloaderCopy)
-	if err := utils.CopyFile(wineloaderOrig, wineloaderCopy); err != nil {
-		errMsg := fmt.Sprintf("failed to copy wineloader: %v", err)
-		if strings.Contains(err.Error(), "operation not permitted") {
-			errMsg += "\n\nSolution: Open System Settings, go to Privacy & Security > App Management, and enable TurtleSilicon."
-		}
-		dialog.ShowError(fmt.Errorf(errMsg), myWindow)
-		paths.PatchesAppliedCrossOver = false
-		updateAllStatuses()
-		return
-	}
-
-	debug.Printf("Executing: codesign --remove-signature %s", wineloaderCopy)
-	cmd := exec.Command("codesign", "--remove-signature", wineloaderCopy)
-	combinedOutput, err := cmd.CombinedOutput()
-	if err != nil {
-		derrMsg := fmt.Sprintf("failed to remove signature from %s: %v\nOutput: %s", wineloaderCopy, err, string(combinedOutput))
-		dialog.ShowError(errors.New(derrMsg), myWindow)
-		debug.Println(derrMsg)
-		paths.PatchesAppliedCrossOver = false
-		if err := os.Remove(wineloaderCopy); err != nil {
-			debug.Printf("Warning: failed to cleanup wineloader2 after codesign failure: %v", err)
-		}
-		updateAllStatuses()
-		return
-	}
-	debug.Printf("codesign output: %s", string(combinedOutput))
-
-	debug.Printf("Setting execute permissions for %s", wineloaderCopy)
-	if err := os.Chmod(wineloaderCopy, 0755); err != nil {
-		errMsg := fmt.Sprintf("failed to set executable permissions for %s: %v", wineloaderCopy, err)
-		dialog.ShowError(errors.New(errMsg), myWindow)
-		debug.Println(errMsg)
-		paths.PatchesAppliedCrossOver = false
-		updateAllStatuses()
-		return
-	}
-
-	debug.Println("CrossOver patching completed successfully.")
-	paths.PatchesAppliedCrossOver = true
-	dialog.ShowInformation("Success", "CrossOver patching process completed.", myWindow)
-	updateAllStatuses()
-}
-
-func UnpatchTurtleWoW(myWindow fyne.Window, updateAllStatuses func()) {
-	debug.Println("Unpatch TurtleWoW clicked")
-	if paths.TurtlewowPath == "" {
-		dialog.ShowError(fmt.Errorf("game path not set. Please set it first."), myWindow)
-		return
-	}
-
-	// Files to remove
-	modsDir := filepath.Join(paths.TurtlewowPath, "mods")
-	winerosettaDllPath := filepath.Join(modsDir, "winerosetta.dll")
-	d3d9DllPath := filepath.Join(paths.TurtlewowPath, "d3d9.dll")
-	libSiliconPatchDllPath := filepath.Join(modsDir, "libSiliconPatch.dll")
-	rosettaX87DirPath := filepath.Join(paths.TurtlewowPath, "rosettax87")
-	dllsTextFile := filepath.Join(paths.TurtlewowPath, "dlls.txt")
-
-	// Remove the rosettaX87 directory
-	if utils.DirExists(rosettaX87DirPath) {
-		debug.Printf("Removing directory: %s", rosettaX87DirPath)
-		if err := os.RemoveAll(rosettaX87DirPath); err != nil {
-			errMsg := fmt.Sprintf("failed to remove directory %s: %v", rosettaX87DirPath, err)
-			dialog.ShowError(errors.New(errMsg), myWindow)
-			debug.Println(errMsg)
-		} else {
-			debug.Printf("Successfully removed directory: %s", rosettaX87DirPath)
-		}
-	}
-
-	// Remove DLL files
-	filesToRemove := []string{winerosettaDllPath, d3d9DllPath, libSiliconPatchDllPath}
-	for _, file := range filesToRemove {
-		if utils.PathExists(file) {
-			debug.Printf("Removing file: %s", file)
-			if err := os.Remove(file); err != nil {
-				errMsg := fmt.Sprintf("failed to remove file %s: %v", file, err)
-				dialog.ShowError(errors.New(errMsg), myWindow)
-				debug.Println(errMsg)
-			} else {
-				debug.Printf("Successfully removed file: %s", file)
-			}
-		}
-	}
-
-	// Update dlls.txt file - remove winerosetta.dll and libSiliconPatch.dll entries
-	if utils.PathExists(dllsTextFile) {
-		debug.Printf("Updating dlls.txt file: %s", dllsTextFile)
-		content, err := os.ReadFile(dllsTextFile)
-		if err != nil {
-			errMsg := fmt.Sprintf("failed to read dlls.txt file: %v", err)
-			dialog.ShowError(errors.New(errMsg), myWindow)
-			debug.Println(errMsg)
-		} else {
-			lines := strings.Split(string(content), "\n")
-			filteredLines := make([]string, 0, len(lines))
-
-			for _, line := range lines {
-				trimmedLine := strings.TrimSpace(line)
-				// Remove both old and new format entries
-				if trimmedLine != "winerosetta.dll" && trimmedLine != "libSiliconPatch.dll" &&
-					trimmedLine != "mods/winerosetta.dll" && trimmedLine != "mods/libSiliconPatch.dll" {
-					filteredLines = append(filteredLines, line)
-				}
-			}
-
-			updatedContent := strings.Join(filteredLines, "\n")
-			if err := os.WriteFile(dllsTextFile, []byte(updatedContent), 0644); err != nil {
-				errMsg := fmt.Sprintf("failed to update dlls.txt file: %v", err)
-				dialog.ShowError(errors.New(errMsg), myWindow)
-				debug.Println(errMsg)
-			} else {
-				debug.Printf("Successfully updated dlls.txt file")
-			}
-		}
-	}
-
-	// Remove shadowLOD setting from Config.wtf - only if it was applied via graphics settings
-	prefs, _ := utils.LoadPrefs()
-	if prefs.SetShadowLOD0 {
-		if err := removeShadowLODSetting(); err != nil {
-			debug.Printf("Warning: failed to remove shadowLOD setting from Config.wtf: %v", err)
-			// Continue with unpatching even if Config.wtf update fails
-		}
-	}
-
-	debug.Println("TurtleWoW unpatching completed successfully.")
-	paths.PatchesAppliedTurtleWoW = false
-	dialog.ShowInformation("Success", "TurtleWoW unpatching process completed.", myWindow)
-	updateAllStatuses()
-}
-
-func UnpatchCrossOver(myWindow fyne.Window, updateAllStatuses func()) {
-	debug.Println("Unpatch CrossOver clicked")
-	if paths.CrossoverPath == "" {
-		dialog.ShowError(fmt.Errorf("CrossOver path not set. Please set it first."), myWindow)
-		return
-	}
-
-	wineloaderCopy := filepath.Join(paths.CrossoverPath, "Contents", "SharedSupport", "CrossOver", "CrossOver-Hosted Application", "wineloader2")
-
-	if utils.PathExists(wineloaderCopy) {
-		debug.Printf("Removing file: %s", wineloaderCopy)
-		if err := os.Remove(wineloaderCopy); err != nil {
-			errMsg := fmt.Sprintf("failed to remove file %s: %v", wineloaderCopy, err)
-			dialog.ShowError(errors.New(errMsg), myWindow)
-			debug.Println(errMsg)
-			updateAllStatuses()
-			return
-		} else {
-			debug.Printf("Successfully removed file: %s", wineloaderCopy)
-		}
-	} else {
-		debug.Printf("File not found to remove: %s", wineloaderCopy)
-	}
-
-	debug.Println("CrossOver unpatching completed successfully.")
-	paths.PatchesAppliedCrossOver = false
-	dialog.ShowInformation("Success", "CrossOver unpatching process completed.", myWindow)
-	updateAllStatuses()
-}
-
-// applyShadowLODSetting applies the shadowLOD setting to Config.wtf for FPS optimization
-func applyShadowLODSetting() error {
-	if paths.TurtlewowPath == "" {
-		return fmt.Errorf("TurtleWoW path not set")
-	}
-
-	configPath := filepath.Join(paths.TurtlewowPath, "WTF", "Config.wtf")
-
-	// Create WTF directory if it doesn't exist
-	wtfDir := filepath.Dir(configPath)
-	if err := os.MkdirAll(wtfDir, 0755); err != nil {
-		return fmt.Errorf("failed to create WTF directory: %v", err)
-	}
-
-	var configText string
-
-	// Read existing config if it exists
-	if content, err := os.ReadFile(configPath); err == nil {
-		configText = string(content)
-	} else {
-		debug.Printf("Config.wtf not found, creating new file")
-		configText = ""
-	}
-
-	// Apply shadowLOD setting
-	configText = updateOrAddConfigSetting(configText, "shadowLOD", "0")
-
-	// Write the updated config back to file
-	if err := os.WriteFile(configPath, []byte(configText), 0644); err != nil {
-		return fmt.Errorf("failed to write Config.wtf: %v", err)
-	}
-
-	debug.Printf("Successfully applied shadowLOD setting to Config.wtf")
-	return nil
-}
-
 // updateOrAddConfigSetting updates an existing setting or adds a new one if it doesn't exist
 func updateOrAddConfigSetting(configText, setting, value string) string {
 	// Create regex pattern to match the setting
@@ -665,79 +128,8 @@ func removeShadowLODSetting() error {
 	return nil
 }
 
-// applyVertexAnimShadersSetting applies the vertex animation shaders setting to Config.wtf
-func applyVertexAnimShadersSetting() error {
-	if paths.TurtlewowPath == "" {
-		return fmt.Errorf("TurtleWoW path not set")
-	}
-
-	configPath := filepath.Join(paths.TurtlewowPath, "WTF", "Config.wtf")
-
-	// Create WTF directory if it doesn't exist
-	wtfDir := filepath.Dir(configPath)
-	if err := os.MkdirAll(wtfDir, 0755); err != nil {
-		return fmt.Errorf("failed to create WTF directory: %v", err)
-	}
-
-	var configText string
-
-	// Read existing config if it exists
-	if content, err := os.ReadFile(configPath); err == nil {
-		configText = string(content)
-	} else {
-		debug.Printf("Config.wtf not found, creating new file")
-		configText = ""
-	}
-
-	// Apply vertex animation shaders setting
-	configText = updateOrAddConfigSetting(configText, "M2UseShaders", "1")
-
-	// Write the updated config back to file
-	if err := os.WriteFile(configPath, []byte(configText), 0644); err != nil {
-		return fmt.Errorf("failed to write Config.wtf: %v", err)
-	}
-
-	debug.Printf("Successfully applied vertex animation shaders setting to Config.wtf")
-	return nil
-}
-
-// applyMovieSetting applies the movie setting to Config.wtf to prevent crashes
-func applyMovieSetting() error {
-	if paths.TurtlewowPath == "" {
-		return fmt.Errorf("TurtleWoW path not set")
-	}
-
-	configPath := filepath.Join(paths.TurtlewowPath, "WTF", "Config.wtf")
-
-	// Create WTF directory if it doesn't exist
-	wtfDir := filepath.Dir(configPath)
-	if err := os.MkdirAll(wtfDir, 0755); err != nil {
-		return fmt.Errorf("failed to create WTF directory: %v", err)
-	}
-
-	var configText string
-
-	// Read existing config if it exists
-	if content, err := os.ReadFile(configPath); err == nil {
-		configText = string(content)
-	} else {
-		debug.Printf("Config.wtf not found, creating new file")
-		configText = ""
-	}
-
-	configText = updateOrAddConfigSetting(configText, "movie", "0")
-
-	// Write the updated config back to file
-	if err := os.WriteFile(configPath, []byte(configText), 0644); err != nil {
-		return fmt.Errorf("failed to write Config.wtf: %v", err)
-	}
-
-	debug.Printf("Successfully applied movie setting to Config.wtf")
-	return nil
-}
-
 // ApplyGraphicsSettings applies the selected graphics settings to Config.wtf using current version settings
-func ApplyGraphicsSettings(myWindow fyne.Window) error {
+func ApplyGraphicsSettings() error {
 	// Get current version settings instead of global preferences
 	vm, err := version.LoadVersionManager()
 	if err != nil {
@@ -753,11 +145,11 @@ func ApplyGraphicsSettings(myWindow fyne.Window) error {
 		return fmt.Errorf("game path not set for current version")
 	}
 
-	return ApplyGraphicsSettingsForVersion(myWindow, currentVer.GamePath, currentVer.Settings.ReduceTerrainDistance, currentVer.Settings.SetMultisampleTo2x, currentVer.Settings.SetShadowLOD0, currentVer.Settings.EnableLibSiliconPatch)
+	return ApplyGraphicsSettingsForVersion(currentVer.GamePath, currentVer.Settings.ReduceTerrainDistance, currentVer.Settings.SetMultisampleTo2x, currentVer.Settings.SetShadowLOD0, currentVer.Settings.EnableLibSiliconPatch)
 }
 
 // ApplyGraphicsSettingsForVersion applies graphics settings to a specific game path with explicit settings
-func ApplyGraphicsSettingsForVersion(myWindow fyne.Window, gamePath string, reduceTerrainDistance bool, setMultisampleTo2x bool, setShadowLOD0 bool, enableLibSiliconPatch bool) error {
+func ApplyGraphicsSettingsForVersion(gamePath string, reduceTerrainDistance bool, setMultisampleTo2x bool, setShadowLOD0 bool, enableLibSiliconPatch bool) error {
 	if gamePath == "" {
 		return fmt.Errorf("game path not set")
 	}
@@ -907,15 +299,14 @@ func LoadGraphicsSettingsFromConfig() error {
 
 	// Check libSiliconPatch status (DLL exists and enabled in dlls.txt)
 	libSiliconPatchPath := filepath.Join(currentVer.GamePath, "mods", "libSiliconPatch.dll")
-	dllsTextFile := filepath.Join(currentVer.GamePath, "dlls.txt")
+	dllsTextFile := filepath.Join(currentVer.GamePath, dllstxt.FileName)
 	libSiliconPatchExists := utils.PathExists(libSiliconPatchPath)
 	libSiliconPatchEnabled := false
 
 	if libSiliconPatchExists && utils.PathExists(dllsTextFile) {
-		if dllsContent, err := os.ReadFile(dllsTextFile); err == nil {
-			content := string(dllsContent)
+		if dlls, err := dllstxt.Load(dllsTextFile); err == nil {
 			// Check for both old and new format entries
-			libSiliconPatchEnabled = strings.Contains(content, "mods/libSiliconPatch.dll") || strings.Contains(content, "libSiliconPatch.dll")
+			libSiliconPatchEnabled = dlls.IsEnabled("mods/libSiliconPatch.dll") || dlls.IsEnabled("libSiliconPatch.dll")
 		}
 	}
 	currentVer.Settings.EnableLibSiliconPatch = libSiliconPatchExists && libSiliconPatchEnabled
@@ -951,7 +342,7 @@ func CheckGraphicsSettingsPresence() {
 	}
 
 	libSiliconPatchPath := filepath.Join(currentVer.GamePath, "mods", "libSiliconPatch.dll")
-	dllsTextFile := filepath.Join(currentVer.GamePath, "dlls.txt")
+	dllsTextFile := filepath.Join(currentVer.GamePath, dllstxt.FileName)
 
 	// Check if libSiliconPatch.dll exists
 	libSiliconPatchExists := utils.PathExists(libSiliconPatchPath)
@@ -959,10 +350,9 @@ func CheckGraphicsSettingsPresence() {
 	// Check if it's enabled in dlls.txt
 	libSiliconPatchEnabled := false
 	if utils.PathExists(dllsTextFile) {
-		if fileContentBytes, err := os.ReadFile(dllsTextFile); err == nil {
-			fileContent := string(fileContentBytes)
+		if dlls, err := dllstxt.Load(dllsTextFile); err == nil {
 			// Check for both old and new format entries
-			libSiliconPatchEnabled = strings.Contains(fileContent, "mods/libSiliconPatch.dll") || strings.Contains(fileContent, "libSiliconPatch.dll")
+			libSiliconPatchEnabled = dlls.IsEnabled("mods/libSiliconPatch.dll") || dlls.IsEnabled("libSiliconPatch.dll")
 		}
 	}
 
@@ -1010,32 +400,19 @@ func enableLibSiliconPatchInDlls() error {
 		return fmt.Errorf("TurtleWoW path not set")
 	}
 
-	dllsTextFile := filepath.Join(paths.TurtlewowPath, "dlls.txt")
-	libSiliconPatchEntry := "mods/libSiliconPatch.dll"
-
-	var fileContentBytes []byte
-	var err error
-	if utils.PathExists(dllsTextFile) {
-		fileContentBytes, err = os.ReadFile(dllsTextFile)
-		if err != nil {
-			return fmt.Errorf("failed to read dlls.txt: %v", err)
-		}
+	dllsTextFile := filepath.Join(paths.TurtlewowPath, dllstxt.FileName)
+	dlls, err := dllstxt.Load(dllsTextFile)
+	if err != nil {
+		return err
 	}
 
-	currentContent := string(fileContentBytes)
-	if strings.Contains(currentContent, libSiliconPatchEntry) {
+	if !dlls.Set("mods/libSiliconPatch.dll", true) {
 		debug.Printf("libSiliconPatch.dll already present in dlls.txt")
 		return nil
 	}
 
-	// Add libSiliconPatch.dll to dlls.txt
-	if len(currentContent) > 0 && !strings.HasSuffix(currentContent, "\n") {
-		currentContent += "\n"
-	}
-	currentContent += libSiliconPatchEntry + "\n"
-
-	if err := os.WriteFile(dllsTextFile, []byte(currentContent), 0644); err != nil {
-		return fmt.Errorf("failed to update dlls.txt: %v", err)
+	if err := dlls.Save(dllsTextFile); err != nil {
+		return err
 	}
 
 	debug.Printf("Added libSiliconPatch.dll to dlls.txt")
@@ -1104,32 +481,26 @@ func disableLibSiliconPatchInDlls() error {
 		return fmt.Errorf("TurtleWoW path not set")
 	}
 
-	dllsTextFile := filepath.Join(paths.TurtlewowPath, "dlls.txt")
+	dllsTextFile := filepath.Join(paths.TurtlewowPath, dllstxt.FileName)
 
 	if !utils.PathExists(dllsTextFile) {
 		debug.Printf("dlls.txt not found, nothing to remove")
 		return nil
 	}
 
-	content, err := os.ReadFile(dllsTextFile)
+	dlls, err := dllstxt.Load(dllsTextFile)
 	if err != nil {
-		return fmt.Errorf("failed to read dlls.txt: %v", err)
+		return err
 	}
 
-	lines := strings.Split(string(content), "\n")
-	filteredLines := make([]string, 0, len(lines))
-
-	for _, line := range lines {
-		trimmedLine := strings.TrimSpace(line)
-		// Remove both old and new format entries
-		if trimmedLine != "libSiliconPatch.dll" && trimmedLine != "mods/libSiliconPatch.dll" {
-			filteredLines = append(filteredLines, line)
-		}
+	// Remove both old and new format entries
+	removedOld := dlls.Remove("libSiliconPatch.dll")
+	removedNew := dlls.Remove("mods/libSiliconPatch.dll")
+	if !removedOld && !removedNew {
+		return nil
 	}
-
-	updatedContent := strings.Join(filteredLines, "\n")
-	if err := os.WriteFile(dllsTextFile, []byte(updatedContent), 0644); err != nil {
-		return fmt.Errorf("failed to update dlls.txt: %v", err)
+	if err := dlls.Save(dllsTextFile); err != nil {
+		return err
 	}
 
 	debug.Printf("Removed libSiliconPatch.dll from dlls.txt")
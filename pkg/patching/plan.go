@@ -0,0 +1,897 @@
+package patching
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"turtlesilicon/pkg/backup"
+	"turtlesilicon/pkg/components"
+	"turtlesilicon/pkg/debug"
+	"turtlesilicon/pkg/dllstxt"
+	"turtlesilicon/pkg/fingerprint"
+	"turtlesilicon/pkg/utils"
+	"turtlesilicon/pkg/wine"
+)
+
+// PlanActionKind identifies what a single step of a patch plan does
+type PlanActionKind int
+
+const (
+	PlanCopyResource PlanActionKind = iota // Copy a bundled resource into place
+	PlanCopyFile                           // Copy a file that already exists on disk
+	PlanRename                             // Move a file to a new name
+	PlanDelete                             // Delete a file or directory
+	PlanMkdir                              // Create a directory
+	PlanDllsAdd                            // Add an entry to dlls.txt
+	PlanDllsRemove                         // Remove an entry from dlls.txt
+	PlanConfigSet                          // Set a Config.wtf setting
+	PlanConfigRemove                       // Remove a Config.wtf setting
+	PlanRunCommand                         // Run an external command
+	PlanLargeAddress                       // Set or clear the Large Address Aware flag of an executable
+	PlanRestoreFile                        // Restore a file from the backup store
+)
+
+// PlanTarget identifies what a plan modifies
+type PlanTarget int
+
+const (
+	PlanTargetGame PlanTarget = iota
+	PlanTargetCrossOver
+)
+
+// PlanAction is a single change a patch plan will make
+type PlanAction struct {
+	Kind      PlanActionKind
+	Path      string      // File or directory that is changed
+	Source    string      // Bundled resource name, source file path or backup SHA-256
+	Version   string      // Component version copied by PlanCopyResource, see components.Resolve
+	Mode      os.FileMode // Permissions for copied files
+	Overwrite bool        // Path already exists and will be replaced
+	Entry     string      // dlls.txt entry
+	Setting   string      // Config.wtf setting name
+	Value     string      // New Config.wtf value
+	OldValue  string      // Current Config.wtf value, empty if not set
+	Command   []string
+	Env       []string
+	TempDir   string // Scratch directory removed before and after the command
+	Creates   string // File the command is expected to create
+	Optional  bool   // A failure is only logged instead of aborting the plan
+	Enable    bool   // New state of the flag changed by PlanLargeAddress
+}
+
+// PatchPlan is the full list of changes patching or unpatching would make. It can be shown
+// to the user for confirmation and is then applied by an Engine.
+type PatchPlan struct {
+	Title          string
+	Root           string // Directory every change happens in
+	Target         PlanTarget
+	Unpatch        bool
+	Actions        []PlanAction
+	SuccessMessage string
+	EmptyMessage   string // Shown instead of running anything when there are no actions
+	OnApplied      func() // Called after the plan was applied successfully
+
+	manifestMethod  string
+	manifestSources []manifestSource
+
+	versionID string                         // Game version whose component choices the plan uses
+	resolved  map[string]components.Resolved // Component builds the plan installs
+	warnings  []string                       // Reported when the plan is applied
+
+	sourceExecutable  *fingerprint.Identification // Executable the patched copy is generated from
+	patchedExecutable string                      // Patched copy generated by the plan
+
+	crossoverPath string // CrossOver installation whose wineloader2 the plan creates or removes
+
+	backups       *backup.Store // Backup store of the game directory, loaded on first use
+	backupsLoaded bool
+
+	dlls *dllstxt.File // Simulated dlls.txt state while the plan is built, loaded on first use
+}
+
+// IsEmpty returns true if applying the plan would not change anything
+func (p *PatchPlan) IsEmpty() bool {
+	return len(p.Actions) == 0
+}
+
+// NothingToApply returns true if applying the plan changes nothing, not even the patch manifest
+func (p *PatchPlan) NothingToApply() bool {
+	return p.IsEmpty() && p.manifestMethod == ""
+}
+
+// relPath shows paths relative to the plan root when possible
+func (p *PatchPlan) relPath(path string) string {
+	if rel, err := filepath.Rel(p.Root, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+	return path
+}
+
+// Describe returns a one line human readable description of an action
+func (p *PatchPlan) Describe(action PlanAction) string {
+	var line string
+	switch action.Kind {
+	case PlanCopyResource:
+		verb := "Copy"
+		if action.Overwrite {
+			verb = "Overwrite"
+		}
+		if action.Version == "" || action.Version == components.VersionBundled {
+			line = fmt.Sprintf("%s %s with bundled %s", verb, p.relPath(action.Path), action.Source)
+		} else {
+			line = fmt.Sprintf("%s %s with %s version %s", verb, p.relPath(action.Path), action.Source, action.Version)
+		}
+	case PlanCopyFile:
+		verb := "Copy"
+		if action.Overwrite {
+			verb = "Overwrite"
+		}
+		line = fmt.Sprintf("%s %s from %s", verb, p.relPath(action.Path), p.relPath(action.Source))
+	case PlanRestoreFile:
+		line = fmt.Sprintf("Restore the original %s from the backup store", p.relPath(action.Path))
+	case PlanRename:
+		line = fmt.Sprintf("Rename %s to %s", p.relPath(action.Source), p.relPath(action.Path))
+	case PlanDelete:
+		line = fmt.Sprintf("Delete %s", p.relPath(action.Path))
+	case PlanMkdir:
+		line = fmt.Sprintf("Create directory %s", p.relPath(action.Path))
+	case PlanDllsAdd:
+		line = fmt.Sprintf("Add %s to dlls.txt", action.Entry)
+	case PlanDllsRemove:
+		line = fmt.Sprintf("Remove %s from dlls.txt", action.Entry)
+	case PlanConfigSet:
+		if action.OldValue != "" {
+			line = fmt.Sprintf("Change %s in Config.wtf from \"%s\" to \"%s\"", action.Setting, action.OldValue, action.Value)
+		} else {
+			line = fmt.Sprintf("Set %s to \"%s\" in Config.wtf", action.Setting, action.Value)
+		}
+	case PlanConfigRemove:
+		line = fmt.Sprintf("Remove %s from Config.wtf", action.Setting)
+	case PlanLargeAddress:
+		if action.Enable {
+			line = fmt.Sprintf("Set the Large Address Aware flag of %s", p.relPath(action.Path))
+		} else {
+			line = fmt.Sprintf("Clear the Large Address Aware flag of %s", p.relPath(action.Path))
+		}
+	case PlanRunCommand:
+		line = strings.TrimSpace(fmt.Sprintf("Run %s %s", filepath.Base(action.Command[0]), strings.Join(action.Command[1:], " ")))
+	default:
+		line = "Unknown action"
+	}
+
+	if action.Optional {
+		line += " (optional)"
+	}
+	return line
+}
+
+// Summary returns the full plan as text, one action per line
+func (p *PatchPlan) Summary() string {
+	if p.IsEmpty() {
+		return p.EmptyMessage
+	}
+
+	lines := make([]string, 0, len(p.Actions))
+	for i, action := range p.Actions {
+		lines = append(lines, fmt.Sprintf("%d. %s", i+1, p.Describe(action)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (p *PatchPlan) add(action PlanAction) {
+	p.Actions = append(p.Actions, action)
+}
+
+// useComponents makes the plan install the component builds the game version asked for instead
+// of the bundled ones. Pinned versions missing from the cache fall back to the bundled build.
+func (p *PatchPlan) useComponents(opts GameOptions) {
+	p.versionID = opts.VersionID
+	p.resolved, p.warnings = components.ResolveAll(opts.ComponentVersions)
+}
+
+// component returns the build of a component the plan installs
+func (p *PatchPlan) component(name string) components.Resolved {
+	if build, ok := p.resolved[name]; ok {
+		return build
+	}
+	build, _ := components.Resolve(name, components.VersionBundled)
+	return build
+}
+
+// installedSources returns the manifest sources with the component versions the plan installs
+func (p *PatchPlan) installedSources() []manifestSource {
+	sources := make([]manifestSource, len(p.manifestSources))
+	for i, source := range p.manifestSources {
+		if source.Resource != "" {
+			source.Version = p.component(source.Resource).Version
+		}
+		sources[i] = source
+	}
+	return sources
+}
+
+// planResourceCopy copies a component unless the target already matches the build the plan installs
+func (p *PatchPlan) planResourceCopy(resourceName, target string, mode os.FileMode) {
+	build := p.component(resourceName)
+	exists := utils.PathExists(target)
+	if exists && build.Matches(target) {
+		debug.Printf("Plan: %s already matches %s, skipping copy", target, build)
+		return
+	}
+	p.add(PlanAction{Kind: PlanCopyResource, Path: target, Source: resourceName, Version: build.Version, Mode: mode, Overwrite: exists})
+}
+
+// planMkdir creates a directory if it does not exist yet
+func (p *PatchPlan) planMkdir(dir string) {
+	if !utils.DirExists(dir) {
+		p.add(PlanAction{Kind: PlanMkdir, Path: dir})
+	}
+}
+
+// planDelete deletes a file or directory if it exists
+func (p *PatchPlan) planDelete(path string, optional bool) {
+	if utils.PathExists(path) {
+		p.add(PlanAction{Kind: PlanDelete, Path: path, Optional: optional})
+	}
+}
+
+// originalBackup returns the backup of the file the game directory held at path before it was
+// first patched. Files patching installed itself are not originals.
+func (p *PatchPlan) originalBackup(path string) (backup.Entry, bool) {
+	if !p.backupsLoaded {
+		p.backupsLoaded = true
+		store, err := backup.Open(p.Root)
+		if err != nil {
+			debug.Printf("Plan: backup store unavailable: %v", err)
+		}
+		p.backups = store
+	}
+	if p.backups == nil {
+		return backup.Entry{}, false
+	}
+
+	rel, err := filepath.Rel(p.Root, path)
+	if err != nil {
+		return backup.Entry{}, false
+	}
+	original, ok := p.backups.Original(filepath.ToSlash(rel))
+	if !ok || components.IsComponentBuild(original.SHA256) {
+		return backup.Entry{}, false
+	}
+	return original, true
+}
+
+// planRemoveInstalled removes a file patching installed. If the backup store has the file the game
+// directory held there before it was patched, that file is restored instead.
+func (p *PatchPlan) planRemoveInstalled(path string, optional bool) {
+	original, ok := p.originalBackup(path)
+	if !ok {
+		p.planDelete(path, optional)
+		return
+	}
+	if utils.FileMatchesDigest(path, original.Size, original.SHA256) {
+		return
+	}
+	p.add(PlanAction{Kind: PlanRestoreFile, Path: path, Source: original.SHA256, Mode: original.Mode, Overwrite: utils.PathExists(path), Optional: optional})
+}
+
+// planRosettaX87 installs the rosettax87 binaries. With recreate the directory is wiped
+// first unless it already holds exactly the binaries the plan installs.
+func (p *PatchPlan) planRosettaX87(recreate bool) {
+	rosettaX87Dir := filepath.Join(p.Root, "rosettax87")
+	binaries := map[string]string{
+		"rosettax87/rosettax87":           filepath.Join(rosettaX87Dir, "rosettax87"),
+		"rosettax87/libRuntimeRosettax87": filepath.Join(rosettaX87Dir, "libRuntimeRosettax87"),
+	}
+
+	if recreate && utils.DirExists(rosettaX87Dir) {
+		intact := true
+		if entries, err := os.ReadDir(rosettaX87Dir); err != nil || len(entries) != len(binaries) {
+			intact = false
+		}
+		for resourceName, destPath := range binaries {
+			if !p.component(resourceName).Matches(destPath) {
+				intact = false
+			}
+		}
+		if intact {
+			debug.Printf("Plan: rosettax87 directory is up to date")
+			return
+		}
+
+		p.add(PlanAction{Kind: PlanDelete, Path: rosettaX87Dir})
+		p.add(PlanAction{Kind: PlanMkdir, Path: rosettaX87Dir})
+		for _, resourceName := range []string{"rosettax87/rosettax87", "rosettax87/libRuntimeRosettax87"} {
+			p.add(PlanAction{Kind: PlanCopyResource, Path: binaries[resourceName], Source: resourceName, Version: p.component(resourceName).Version, Mode: 0755})
+		}
+		return
+	}
+
+	p.planMkdir(rosettaX87Dir)
+	for _, resourceName := range []string{"rosettax87/rosettax87", "rosettax87/libRuntimeRosettax87"} {
+		p.planResourceCopy(resourceName, binaries[resourceName], 0755)
+	}
+}
+
+// loadDlls reads dlls.txt once so the plan can simulate later additions and removals
+func (p *PatchPlan) loadDlls() {
+	if p.dlls != nil {
+		return
+	}
+	dlls, err := dllstxt.Load(filepath.Join(p.Root, dllstxt.FileName))
+	if err != nil {
+		debug.Printf("Plan: %v", err)
+		dlls = &dllstxt.File{}
+	}
+	p.dlls = dlls
+}
+
+// dllsState reports whether dlls.txt lists the DLL and whether it is enabled
+func (p *PatchPlan) dllsState(name string) (listed bool, enabled bool) {
+	p.loadDlls()
+	return p.dlls.State(name)
+}
+
+// planDllsAdd adds an entry to dlls.txt unless it is already there. A # prefix adds the entry
+// disabled; an enabled entry also enables the DLL if it is listed but disabled.
+func (p *PatchPlan) planDllsAdd(entry string) {
+	name, enabled := dllstxt.ParseEntry(entry)
+	listed, alreadyEnabled := p.dllsState(name)
+	if listed && (alreadyEnabled || !enabled) {
+		debug.Printf("Plan: dlls.txt already contains %s", entry)
+		return
+	}
+	p.dlls.Set(name, enabled)
+	p.add(PlanAction{Kind: PlanDllsAdd, Path: filepath.Join(p.Root, dllstxt.FileName), Entry: entry})
+}
+
+// planDllsRemove removes the given entries from dlls.txt if present, enabled or not
+func (p *PatchPlan) planDllsRemove(optional bool, entries ...string) {
+	p.loadDlls()
+	for _, entry := range entries {
+		if !p.dlls.Remove(entry) {
+			continue
+		}
+		p.add(PlanAction{Kind: PlanDllsRemove, Path: filepath.Join(p.Root, dllstxt.FileName), Entry: entry, Optional: optional})
+	}
+}
+
+// currentConfigValue returns the value of a Config.wtf setting and whether it is set
+func (p *PatchPlan) currentConfigValue(setting string) (string, bool) {
+	content, err := os.ReadFile(filepath.Join(p.Root, "WTF", "Config.wtf"))
+	if err != nil {
+		return "", false
+	}
+	re := regexp.MustCompile(fmt.Sprintf(`SET\s+%s\s+"([^"]*)"`, regexp.QuoteMeta(setting)))
+	matches := re.FindStringSubmatch(string(content))
+	if len(matches) < 2 {
+		return "", false
+	}
+	return matches[1], true
+}
+
+// planConfigSet sets a Config.wtf setting unless it already has the value
+func (p *PatchPlan) planConfigSet(setting, value string) {
+	current, found := p.currentConfigValue(setting)
+	if found && current == value {
+		return
+	}
+	p.add(PlanAction{
+		Kind:     PlanConfigSet,
+		Path:     filepath.Join(p.Root, "WTF", "Config.wtf"),
+		Setting:  setting,
+		Value:    value,
+		OldValue: current,
+		Optional: true,
+	})
+}
+
+// planConfigRemove removes a Config.wtf setting if it is present
+func (p *PatchPlan) planConfigRemove(setting string) {
+	if _, found := p.currentConfigValue(setting); !found {
+		return
+	}
+	p.add(PlanAction{Kind: PlanConfigRemove, Path: filepath.Join(p.Root, "WTF", "Config.wtf"), Setting: setting, Optional: true})
+}
+
+// planManifestRemoval deletes a patch manifest left from an earlier patch
+func (p *PatchPlan) planManifestRemoval() {
+	p.planDelete(filepath.Join(p.Root, PatchManifestFileName), true)
+}
+
+// PlanTurtleWoWPatch builds the plan for the full rosettax87 patch including libSiliconPatch.dll
+func PlanTurtleWoWPatch(gamePath string, opts GameOptions) (*PatchPlan, error) {
+	if gamePath == "" {
+		return nil, planError(ErrGamePathNotSet, "")
+	}
+	if !utils.DirExists(gamePath) {
+		return nil, planError(ErrGameDirNotFound, gamePath)
+	}
+
+	plan := &PatchPlan{
+		Title:          "Patch Game",
+		Root:           gamePath,
+		Target:         PlanTargetGame,
+		SuccessMessage: "TurtleWoW patching process completed.",
+		manifestMethod: PatchMethodRosetta,
+	}
+	plan.useComponents(opts)
+
+	modsDir := filepath.Join(gamePath, "mods")
+	plan.planMkdir(modsDir)
+	plan.planResourceCopy("winerosetta/winerosetta.dll", filepath.Join(modsDir, "winerosetta.dll"), 0644)
+	plan.planResourceCopy("winerosetta/d3d9.dll", filepath.Join(gamePath, "d3d9.dll"), 0644) // d3d9.dll stays in root
+	plan.planResourceCopy("winerosetta/libSiliconPatch.dll", filepath.Join(modsDir, "libSiliconPatch.dll"), 0644)
+	plan.planRosettaX87(true)
+
+	plan.planDllsAdd("mods/winerosetta.dll")
+	if opts.EnableLibSiliconPatch {
+		plan.planDllsAdd("mods/libSiliconPatch.dll")
+	} else {
+		// If user has disabled libSiliconPatch, make sure it's removed from dlls.txt
+		debug.Printf("libSiliconPatch disabled for %s, will not add to dlls.txt", opts.VersionID)
+		plan.planDllsRemove(true, "libSiliconPatch.dll", "mods/libSiliconPatch.dll")
+	}
+
+	// Always apply vertex animation shaders and movie settings to Config.wtf
+	plan.planConfigSet("M2UseShaders", "1")
+	plan.planConfigSet("movie", "0")
+
+	// Apply shadowLOD setting to Config.wtf for FPS optimization
+	if opts.EnableShadowLOD {
+		plan.planConfigSet("shadowLOD", "0")
+	} else {
+		plan.planConfigRemove("shadowLOD")
+	}
+
+	plan.manifestSources = append([]manifestSource{
+		{Path: "mods/winerosetta.dll", Resource: "winerosetta/winerosetta.dll"},
+		{Path: "d3d9.dll", Resource: "winerosetta/d3d9.dll"},
+		{Path: "mods/libSiliconPatch.dll", Resource: "winerosetta/libSiliconPatch.dll"},
+	}, rosettaManifestSources...)
+
+	return plan, nil
+}
+
+// PlanTurtleWoWUnpatch builds the plan that removes the full rosettax87 patch
+func PlanTurtleWoWUnpatch(gamePath string, opts GameOptions) (*PatchPlan, error) {
+	if gamePath == "" {
+		return nil, planError(ErrGamePathNotSet, "")
+	}
+
+	plan := &PatchPlan{
+		Title:          "Unpatch Game",
+		Root:           gamePath,
+		Target:         PlanTargetGame,
+		Unpatch:        true,
+		SuccessMessage: "TurtleWoW unpatching process completed.",
+		EmptyMessage:   "No patches found to remove.",
+	}
+
+	modsDir := filepath.Join(gamePath, "mods")
+	plan.planDelete(filepath.Join(gamePath, "rosettax87"), false)
+	plan.planRemoveInstalled(filepath.Join(modsDir, "winerosetta.dll"), false)
+	plan.planRemoveInstalled(filepath.Join(gamePath, "d3d9.dll"), false)
+	plan.planRemoveInstalled(filepath.Join(modsDir, "libSiliconPatch.dll"), false)
+
+	// Remove both old and new format entries
+	plan.planDllsRemove(false, "winerosetta.dll", "libSiliconPatch.dll", "mods/winerosetta.dll", "mods/libSiliconPatch.dll")
+
+	// Remove shadowLOD setting from Config.wtf - only if it was applied via graphics settings
+	if opts.RemoveShadowLOD {
+		plan.planConfigRemove("shadowLOD")
+	}
+
+	plan.planManifestRemoval()
+	return plan, nil
+}
+
+// PlanVersionPatch builds the patch plan for a game version based on its configuration
+func PlanVersionPatch(gamePath string, opts GameOptions) (*PatchPlan, error) {
+	debug.Printf("Planning patch for %s at %s (rosetta=%v, divx=%v, executable=%s)", opts.VersionID, gamePath, opts.UsesRosettaPatching, opts.UsesDivxDecoderPatch, opts.ExecutableName)
+
+	if gamePath == "" {
+		return nil, planError(ErrGamePathNotSet, "")
+	}
+
+	if opts.UsesRosettaPatching {
+		// TurtleSilicon uses the full rosettax87 patching (includes libSiliconPatch.dll)
+		return PlanTurtleWoWPatch(gamePath, opts)
+	} else if opts.UsesDivxDecoderPatch {
+		// BurningSilicon and VanillaSilicon use the original DivX decoder approach
+		if opts.VersionID == "burningsilicon" || opts.VersionID == "vanillasilicon" {
+			return planOriginalDivxDecoderPatch(gamePath, opts)
+		}
+		// Other DivX versions use the new libDllLdr approach
+		return planLibDllLdrPatch(gamePath, opts, true)
+	}
+
+	// Versions with both flags false (EpochSilicon, WrathSilicon) use libDllLdr approach
+	return planLibDllLdrPatch(gamePath, opts, false)
+}
+
+// checkGameDirectoryWritable verifies the game directory exists and can be written to
+func checkGameDirectoryWritable(gamePath string) error {
+	if !utils.DirExists(gamePath) {
+		return planError(ErrGameDirNotFound, gamePath)
+	}
+
+	// Test write permissions by creating a temporary file
+	testFilePath := filepath.Join(gamePath, "test_write_permissions.tmp")
+	testFile, err := os.Create(testFilePath)
+	if err != nil {
+		return &PatchError{Op: "plan", Path: gamePath, Err: fmt.Errorf("%w: %v", ErrGameDirNotWritable, err)}
+	}
+	testFile.Close()
+	os.Remove(testFilePath) // Clean up test file
+	return nil
+}
+
+// planOriginalDivxDecoderPatch builds the plan for the original DivX decoder patching method
+func planOriginalDivxDecoderPatch(gamePath string, opts GameOptions) (*PatchPlan, error) {
+	if err := checkGameDirectoryWritable(gamePath); err != nil {
+		return nil, err
+	}
+
+	plan := &PatchPlan{
+		Title:          "Patch Game",
+		Root:           gamePath,
+		Target:         PlanTargetGame,
+		SuccessMessage: "Game patching completed successfully.",
+		manifestMethod: PatchMethodDivxDecoder,
+	}
+	plan.useComponents(opts)
+
+	plan.planDivxDecoderReplacement()
+	plan.planResourceCopy("winerosetta/d3d9.dll", filepath.Join(gamePath, "d3d9.dll"), 0644)
+	plan.planRosettaX87(false)
+	plan.planLargeAddressAware(opts)
+	plan.planConfigSet("movie", "0")
+
+	plan.manifestSources = append([]manifestSource{
+		{Path: "DivxDecoder.dll", Resource: "winerosetta/winerosetta.dll"},
+		{Path: "d3d9.dll", Resource: "winerosetta/d3d9.dll"},
+	}, rosettaManifestSources...)
+
+	return plan, nil
+}
+
+// planDivxDecoderReplacement replaces DivxDecoder.dll with winerosetta. The original is kept as a
+// backup, unless it already is the patched one or an earlier winerosetta build whose original was
+// backed up before.
+func (p *PatchPlan) planDivxDecoderReplacement() {
+	divxDecoderPath := filepath.Join(p.Root, "DivxDecoder.dll")
+	backupPath := filepath.Join(p.Root, "DivxDecoder.dll.backup")
+	winerosetta := p.component("winerosetta/winerosetta.dll")
+	if utils.PathExists(divxDecoderPath) && !winerosetta.Matches(divxDecoderPath) && !utils.PathExists(backupPath) {
+		p.add(PlanAction{Kind: PlanRename, Source: divxDecoderPath, Path: backupPath})
+		p.add(PlanAction{Kind: PlanCopyResource, Path: divxDecoderPath, Source: winerosetta.Name, Version: winerosetta.Version, Mode: 0644})
+	} else {
+		p.planResourceCopy("winerosetta/winerosetta.dll", divxDecoderPath, 0644)
+	}
+}
+
+// patchedExecutableNames returns the executable rundll32 patches and the name of the patched copy
+func patchedExecutableNames(executableName string) (string, string) {
+	if executableName == "Ascension.exe" {
+		return executableName, "Ascension_patched.exe"
+	}
+	// Default to Wow.exe for all other versions
+	return "Wow.exe", "Wow_patched.exe"
+}
+
+// planPatchedExecutable generates the patched executable with rundll32 unless it already exists.
+// libDllLdr.dll must be in place before the command runs.
+func (p *PatchPlan) planPatchedExecutable(opts GameOptions) error {
+	executableName, patchedExecutableName := patchedExecutableNames(opts.ExecutableName)
+	patchedExePath := filepath.Join(p.Root, patchedExecutableName)
+	if utils.PathExists(patchedExePath) {
+		debug.Printf("Patched executable already exists, skipping rundll32 command: %s", patchedExePath)
+		return nil
+	}
+
+	runtime := opts.WineRuntime()
+	if err := runtime.Detect(); err != nil {
+		return runtimeError(runtime, err)
+	}
+
+	if err := p.checkExecutable(filepath.Join(p.Root, executableName), opts.VersionID); err != nil {
+		return err
+	}
+	p.patchedExecutable = patchedExePath
+
+	// Use the tool loader (for CrossOver the original wineloader, not wineloader2) without any
+	// bottles, in a temporary wine prefix
+	tempDir := filepath.Join(os.TempDir(), "turtlesilicon_wine_temp")
+	p.add(PlanAction{
+		Kind:    PlanRunCommand,
+		Path:    p.Root,
+		Command: []string{runtime.ToolLoaderPath(), "rundll32", "libDllLdr.dll,RunDll32Entry", executableName},
+		Env: append(runtime.Env(),
+			"WINEPREFIX="+tempDir, // Use temporary directory instead of bottles
+			"WINEARCH=win64",      // Set architecture
+			"WINEDLLOVERRIDES=",   // Clear any DLL overrides
+		),
+		TempDir: tempDir,
+		Creates: patchedExePath,
+	})
+	return nil
+}
+
+// LargeAddressAwareExecutable returns the executable whose Large Address Aware flag the patch
+// method of a game version manages: the original executable the DivX decoder patch launches or
+// the patched copy generated by libDllLdr.dll. The Rosetta method leaves the executable alone.
+func LargeAddressAwareExecutable(opts GameOptions) (string, bool) {
+	if opts.UsesRosettaPatching {
+		return "", false
+	}
+	if opts.UsesDivxDecoderPatch && (opts.VersionID == "burningsilicon" || opts.VersionID == "vanillasilicon") {
+		return opts.ExecutableName, opts.ExecutableName != ""
+	}
+	_, patchedExecutableName := patchedExecutableNames(opts.ExecutableName)
+	return patchedExecutableName, true
+}
+
+// SupportsLargeAddressAware returns true if the patch method of a game version can make the game
+// Large Address Aware
+func SupportsLargeAddressAware(opts GameOptions) bool {
+	_, ok := LargeAddressAwareExecutable(opts)
+	return ok
+}
+
+// planLargeAddressAware sets or clears the Large Address Aware flag of the launched executable to
+// match opts. The unchanged executable is kept in the backup store. A patched executable that is
+// generated by this plan is only changed when the flag should be set.
+func (p *PatchPlan) planLargeAddressAware(opts GameOptions) {
+	executableName, ok := LargeAddressAwareExecutable(opts)
+	if !ok {
+		return
+	}
+	patchedExePath := filepath.Join(p.Root, executableName)
+
+	if utils.PathExists(patchedExePath) {
+		enabled, err := utils.IsLargeAddressAware(patchedExePath)
+		if err != nil {
+			debug.Printf("Plan: cannot read the Large Address Aware flag of %s: %v", patchedExePath, err)
+			return
+		}
+		if enabled == opts.LargeAddressAware {
+			return
+		}
+	} else if !opts.LargeAddressAware {
+		return
+	}
+
+	p.add(PlanAction{Kind: PlanLargeAddress, Path: patchedExePath, Enable: opts.LargeAddressAware})
+}
+
+// checkExecutable makes sure the executable the patched copy is generated from is a client for the
+// game version. Executables of other game versions and earlier patched outputs are refused,
+// unknown builds are patched with a warning once the version has known digests to compare with.
+func (p *PatchPlan) checkExecutable(exePath string, versionID string) error {
+	if !utils.PathExists(exePath) {
+		return planError(ErrExecutableNotFound, exePath)
+	}
+	id, err := fingerprint.Identify(exePath, versionID)
+	if err != nil {
+		return &PatchError{Op: "plan", Err: fmt.Errorf("%w: %v", ErrUnsupportedExe, err)}
+	}
+
+	switch {
+	case id.State == fingerprint.MatchOtherVersion:
+		return &PatchError{Op: "plan", Err: fmt.Errorf("%w: %s", ErrUnsupportedExe, id.Describe())}
+	case id.Build != nil && id.Build.Kind == fingerprint.KindPatched:
+		return &PatchError{Op: "plan", Err: fmt.Errorf("%w: %s is already a patched executable, restore the original client executable", ErrUnsupportedExe, filepath.Base(exePath))}
+	case id.State != fingerprint.MatchKnown && fingerprint.HasDigests(versionID):
+		p.warnings = append(p.warnings, id.Describe()+". It is patched anyway, report the build if the game does not start.")
+	}
+	p.sourceExecutable = id
+	return nil
+}
+
+// planLibDllLdrPatch builds the plan for the libDllLdr.dll patching method
+func planLibDllLdrPatch(gamePath string, opts GameOptions, applyMovieSetting bool) (*PatchPlan, error) {
+	_, patchedExecutableName := patchedExecutableNames(opts.ExecutableName)
+
+	if err := checkGameDirectoryWritable(gamePath); err != nil {
+		return nil, err
+	}
+
+	plan := &PatchPlan{
+		Title:          "Patch Game",
+		Root:           gamePath,
+		Target:         PlanTargetGame,
+		SuccessMessage: "Game patching completed successfully.",
+		manifestMethod: PatchMethodLibDllLdr,
+	}
+	plan.useComponents(opts)
+
+	modsDir := filepath.Join(gamePath, "mods")
+	plan.planResourceCopy("winerosetta/libDllLdr.dll", filepath.Join(gamePath, "libDllLdr.dll"), 0644)
+	plan.planMkdir(modsDir)
+	plan.planResourceCopy("winerosetta/winerosetta.dll", filepath.Join(modsDir, "winerosetta.dll"), 0644)
+	plan.planResourceCopy("winerosetta/d3d9.dll", filepath.Join(gamePath, "d3d9.dll"), 0644)
+	plan.planDllsAdd("mods/winerosetta.dll")
+	plan.planRosettaX87(false)
+
+	if err := plan.planPatchedExecutable(opts); err != nil {
+		return nil, err
+	}
+	plan.planLargeAddressAware(opts)
+
+	// Apply movie setting to Config.wtf only for versions that use divx decoder patch
+	if applyMovieSetting {
+		plan.planConfigSet("movie", "0")
+	}
+
+	plan.manifestSources = append([]manifestSource{
+		{Path: "libDllLdr.dll", Resource: "winerosetta/libDllLdr.dll"},
+		{Path: "mods/winerosetta.dll", Resource: "winerosetta/winerosetta.dll"},
+		{Path: "d3d9.dll", Resource: "winerosetta/d3d9.dll"},
+		{Path: patchedExecutableName},
+	}, rosettaManifestSources...)
+
+	return plan, nil
+}
+
+// PlanVersionUnpatch builds the unpatch plan for a game version based on its configuration
+func PlanVersionUnpatch(gamePath string, opts GameOptions) (*PatchPlan, error) {
+	debug.Printf("Planning unpatch for %s at %s (rosetta=%v, divx=%v)", opts.VersionID, gamePath, opts.UsesRosettaPatching, opts.UsesDivxDecoderPatch)
+
+	if gamePath == "" {
+		return nil, planError(ErrGamePathNotSet, "")
+	}
+
+	if opts.UsesRosettaPatching {
+		return PlanTurtleWoWUnpatch(gamePath, opts)
+	}
+
+	// Check which files exist to determine the method used
+	if utils.PathExists(filepath.Join(gamePath, "libDllLdr.dll")) {
+		return planLibDllLdrUnpatch(gamePath), nil
+	}
+	if opts.UsesDivxDecoderPatch && utils.PathExists(filepath.Join(gamePath, "DivxDecoder.dll")) {
+		return planOriginalDivxDecoderUnpatch(gamePath), nil
+	}
+
+	return &PatchPlan{
+		Title:        "Unpatch Game",
+		Root:         gamePath,
+		Target:       PlanTargetGame,
+		Unpatch:      true,
+		EmptyMessage: "No patches found to remove.",
+	}, nil
+}
+
+// planLibDllLdrUnpatch builds the plan that removes libDllLdr.dll and the patched executables
+func planLibDllLdrUnpatch(gamePath string) *PatchPlan {
+	plan := &PatchPlan{
+		Title:          "Unpatch Game",
+		Root:           gamePath,
+		Target:         PlanTargetGame,
+		Unpatch:        true,
+		SuccessMessage: "Game unpatching completed successfully.",
+		EmptyMessage:   "No patches found to remove.",
+	}
+
+	plan.planRemoveInstalled(filepath.Join(gamePath, "libDllLdr.dll"), false)
+	plan.planRemoveInstalled(filepath.Join(gamePath, "mods", "winerosetta.dll"), true)
+	plan.planRemoveInstalled(filepath.Join(gamePath, "d3d9.dll"), true)
+
+	// Remove patched executables - check for multiple possible names
+	for _, execName := range []string{
+		"Wow_patched.exe",
+		"Project-Epoch_patched.exe", // Legacy name
+		"Ascension_patched.exe",     // New name for EpochSilicon
+	} {
+		plan.planDelete(filepath.Join(gamePath, execName), true)
+	}
+
+	plan.planDelete(filepath.Join(gamePath, "rosettax87"), true)
+	plan.planDllsRemove(true, "mods/winerosetta.dll")
+	plan.planManifestRemoval()
+	return plan
+}
+
+// planOriginalDivxDecoderUnpatch builds the plan that restores the original DivxDecoder.dll
+func planOriginalDivxDecoderUnpatch(gamePath string) *PatchPlan {
+	plan := &PatchPlan{
+		Title:          "Unpatch Game",
+		Root:           gamePath,
+		Target:         PlanTargetGame,
+		Unpatch:        true,
+		SuccessMessage: "Game unpatching completed successfully.",
+		EmptyMessage:   "No patches found to remove.",
+	}
+
+	divxDecoderPath := filepath.Join(gamePath, "DivxDecoder.dll")
+	divxDecoderBackupPath := filepath.Join(gamePath, "DivxDecoder.dll.backup")
+
+	if utils.PathExists(divxDecoderBackupPath) {
+		// Renaming over the patched file removes it and restores the backup in one step
+		plan.add(PlanAction{Kind: PlanRename, Source: divxDecoderBackupPath, Path: divxDecoderPath, Overwrite: utils.PathExists(divxDecoderPath), Optional: true})
+	} else {
+		plan.planRemoveInstalled(divxDecoderPath, true)
+	}
+
+	plan.planRemoveInstalled(filepath.Join(gamePath, "d3d9.dll"), true)
+	plan.planDelete(filepath.Join(gamePath, "rosettax87"), true)
+	plan.planManifestRemoval()
+	return plan
+}
+
+// PlanCrossOverPatch builds the plan that creates the unsigned wineloader2 copy
+func PlanCrossOverPatch(crossoverPath string) (*PatchPlan, error) {
+	return PlanRuntimePatch(wine.NewCrossOver(crossoverPath))
+}
+
+// PlanCrossOverUnpatch builds the plan that removes wineloader2
+func PlanCrossOverUnpatch(crossoverPath string) (*PatchPlan, error) {
+	return PlanRuntimeUnpatch(wine.NewCrossOver(crossoverPath))
+}
+
+// runtimeRoot returns the directory the patch steps of a runtime change
+func runtimeRoot(runtime wine.Runtime) string {
+	if runtime.Kind() == wine.KindCrossOver {
+		return wine.CrossOverLoaderDir(runtime.Path())
+	}
+	return runtime.Path()
+}
+
+// PlanRuntimePatch builds the plan that runs the patch steps of a Wine runtime, e.g. creating
+// wineloader2 for CrossOver. Runtimes without patch steps get an empty plan.
+func PlanRuntimePatch(runtime wine.Runtime) (*PatchPlan, error) {
+	if err := runtime.Detect(); err != nil {
+		return nil, runtimeError(runtime, err)
+	}
+
+	plan := &PatchPlan{
+		Title:          "Patch " + runtime.Name(),
+		Root:           runtimeRoot(runtime),
+		Target:         PlanTargetCrossOver,
+		SuccessMessage: runtime.Name() + " patching process completed.",
+		EmptyMessage:   runtime.Name() + " needs no patching.",
+	}
+	if runtime.Kind() == wine.KindCrossOver {
+		plan.crossoverPath = runtime.Path()
+	}
+
+	for _, step := range runtime.PatchSteps() {
+		if step.Source != "" {
+			plan.add(PlanAction{Kind: PlanCopyFile, Source: step.Source, Path: step.Path, Mode: 0755, Overwrite: utils.PathExists(step.Path)})
+		} else {
+			plan.add(PlanAction{Kind: PlanRunCommand, Path: step.Path, Command: step.Command})
+		}
+	}
+	return plan, nil
+}
+
+// PlanRuntimeUnpatch builds the plan that removes the files the patch steps of a Wine runtime
+// created
+func PlanRuntimeUnpatch(runtime wine.Runtime) (*PatchPlan, error) {
+	if runtime.Path() == "" {
+		return nil, runtimeError(runtime, wine.ErrPathNotSet)
+	}
+
+	plan := &PatchPlan{
+		Title:          "Unpatch " + runtime.Name(),
+		Root:           runtimeRoot(runtime),
+		Target:         PlanTargetCrossOver,
+		Unpatch:        true,
+		SuccessMessage: runtime.Name() + " unpatching process completed.",
+		EmptyMessage:   runtime.Name() + " is not patched, nothing to remove.",
+	}
+	if runtime.Kind() == wine.KindCrossOver {
+		plan.crossoverPath = runtime.Path()
+	}
+
+	for _, step := range runtime.PatchSteps() {
+		if step.Source != "" {
+			plan.planDelete(step.Path, false)
+		}
+	}
+	return plan, nil
+}
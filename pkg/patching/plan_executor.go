@@ -0,0 +1,142 @@
+package patching
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"turtlesilicon/pkg/backup"
+	"turtlesilicon/pkg/components"
+	"turtlesilicon/pkg/debug"
+	"turtlesilicon/pkg/dllstxt"
+	"turtlesilicon/pkg/utils"
+)
+
+// executePlanAction performs a single plan action, recording every change in the journal
+func executePlanAction(action PlanAction, journal *patchJournal) error {
+	switch action.Kind {
+	case PlanCopyResource:
+		build, err := components.Resolve(action.Source, action.Version)
+		if err != nil {
+			return err
+		}
+		content, err := build.ReadFile()
+		if err != nil {
+			return err
+		}
+		if err := journal.mkdirAll(filepath.Dir(action.Path)); err != nil {
+			return err
+		}
+		return journal.writeFile(action.Path, content, action.Mode)
+
+	case PlanCopyFile:
+		if err := journal.trackFile(action.Path); err != nil {
+			return err
+		}
+		if err := utils.CopyFile(action.Source, action.Path); err != nil {
+			return err
+		}
+		return os.Chmod(action.Path, action.Mode)
+
+	case PlanRestoreFile:
+		if journal.store == nil {
+			return fmt.Errorf("backup store not available")
+		}
+		content, err := journal.store.ReadFile(backup.Entry{Path: filepath.Base(action.Path), SHA256: action.Source})
+		if err != nil {
+			return err
+		}
+		if err := journal.mkdirAll(filepath.Dir(action.Path)); err != nil {
+			return err
+		}
+		return journal.writeFile(action.Path, content, action.Mode)
+
+	case PlanRename:
+		if err := journal.trackFile(action.Path); err != nil {
+			return err
+		}
+		if err := utils.CopyFile(action.Source, action.Path); err != nil {
+			return err
+		}
+		return journal.removeAll(action.Source)
+
+	case PlanDelete:
+		return journal.removeAll(action.Path)
+
+	case PlanMkdir:
+		return journal.mkdirAll(action.Path)
+
+	case PlanDllsAdd, PlanDllsRemove:
+		dlls, err := dllstxt.Load(action.Path)
+		if err != nil {
+			return err
+		}
+		if action.Kind == PlanDllsAdd {
+			dlls.Set(dllstxt.ParseEntry(action.Entry))
+		} else {
+			dlls.Remove(action.Entry)
+		}
+		return journal.writeFile(action.Path, dlls.Bytes(), 0644)
+
+	case PlanConfigSet, PlanConfigRemove:
+		if err := journal.mkdirAll(filepath.Dir(action.Path)); err != nil {
+			return fmt.Errorf("failed to create WTF directory: %v", err)
+		}
+
+		var configText string
+		if data, err := os.ReadFile(action.Path); err == nil {
+			configText = string(data)
+		}
+
+		if action.Kind == PlanConfigSet {
+			configText = updateOrAddConfigSetting(configText, action.Setting, action.Value)
+		} else {
+			configText = removeConfigSetting(configText, action.Setting)
+		}
+		return journal.writeFile(action.Path, []byte(configText), 0644)
+
+	case PlanLargeAddress:
+		info, err := os.Stat(action.Path)
+		if err != nil {
+			return err
+		}
+		content, err := os.ReadFile(action.Path)
+		if err != nil {
+			return err
+		}
+		if err := utils.SetLargeAddressAware(content, action.Enable); err != nil {
+			return fmt.Errorf("failed to change the Large Address Aware flag of %s: %v", filepath.Base(action.Path), err)
+		}
+		return journal.writeFile(action.Path, content, info.Mode().Perm())
+
+	case PlanRunCommand:
+		if action.Creates != "" {
+			// Make sure a partially written output is removed on rollback
+			if err := journal.trackFile(action.Creates); err != nil {
+				return err
+			}
+		}
+		if action.TempDir != "" {
+			os.RemoveAll(action.TempDir)       // Clean up any existing temp directory
+			defer os.RemoveAll(action.TempDir) // Clean up after we're done
+		}
+
+		cmd := exec.Command(action.Command[0], action.Command[1:]...)
+		cmd.Dir = action.Path
+		cmd.Env = append(os.Environ(), action.Env...)
+		debug.Printf("Running command: %v", action.Command)
+
+		output, err := cmd.CombinedOutput()
+		debug.Printf("Command output: %s", string(output))
+		if err != nil {
+			return fmt.Errorf("%v\nOutput: %s", err, string(output))
+		}
+		if action.Creates != "" && !utils.PathExists(action.Creates) {
+			return fmt.Errorf("%s was not created", action.Creates)
+		}
+		return nil
+	}
+
+	return fmt.Errorf("unknown plan action %d", action.Kind)
+}
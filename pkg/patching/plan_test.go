@@ -0,0 +1,135 @@
+package patching
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLargeAddressAwareExecutable(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   GameOptions
+		want   string
+		wantOK bool
+	}{
+		{"libDllLdr", GameOptions{VersionID: "wrathsilicon", ExecutableName: "Wow.exe"}, "Wow_patched.exe", true},
+		{"Ascension", GameOptions{VersionID: "epochsilicon", ExecutableName: "Ascension.exe"}, "Ascension_patched.exe", true},
+		{"DivX decoder on 2.4.3", GameOptions{VersionID: "burningsilicon", ExecutableName: "Wow.exe", UsesDivxDecoderPatch: true}, "Wow.exe", true},
+		{"DivX decoder on 1.12.1", GameOptions{VersionID: "vanillasilicon", ExecutableName: "WoW.exe", UsesDivxDecoderPatch: true}, "WoW.exe", true},
+		{"Rosetta", GameOptions{VersionID: "turtlesilicon", ExecutableName: "WoW.exe", UsesRosettaPatching: true}, "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := LargeAddressAwareExecutable(tt.opts)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("%s: LargeAddressAwareExecutable() = %q, %v, want %q, %v", tt.name, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+// actionSummary lists the kind and path relative to the plan root of every action
+func actionSummary(plan *PatchPlan) []string {
+	var summary []string
+	for _, action := range plan.Actions {
+		entry := plan.relPath(action.Path)
+		switch {
+		case action.Entry != "":
+			entry += " " + action.Entry
+		case action.Setting != "":
+			entry += " " + action.Setting
+		}
+		summary = append(summary, fmt.Sprintf("%d %s", action.Kind, entry))
+	}
+	return summary
+}
+
+// testGameDir creates a game directory with the given files and moves the config directory, and
+// with it the backup and component stores, into a temporary directory
+func testGameDir(t *testing.T, files map[string]string) string {
+	t.Helper()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	gamePath := t.TempDir()
+	for rel, content := range files {
+		path := filepath.Join(gamePath, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return gamePath
+}
+
+func TestPlanTurtleWoWPatch(t *testing.T) {
+	config := filepath.Join("WTF", "Config.wtf")
+	tests := []struct {
+		name  string
+		files map[string]string
+		opts  GameOptions
+		want  []string
+	}{
+		{
+			name:  "fresh game directory",
+			files: map[string]string{"WoW.exe": "exe"},
+			opts:  GameOptions{VersionID: "turtlesilicon", EnableLibSiliconPatch: true},
+			want: []string{
+				fmt.Sprintf("%d mods", PlanMkdir),
+				fmt.Sprintf("%d mods/winerosetta.dll", PlanCopyResource),
+				fmt.Sprintf("%d d3d9.dll", PlanCopyResource),
+				fmt.Sprintf("%d mods/libSiliconPatch.dll", PlanCopyResource),
+				fmt.Sprintf("%d rosettax87", PlanMkdir),
+				fmt.Sprintf("%d rosettax87/rosettax87", PlanCopyResource),
+				fmt.Sprintf("%d rosettax87/libRuntimeRosettax87", PlanCopyResource),
+				fmt.Sprintf("%d dlls.txt mods/winerosetta.dll", PlanDllsAdd),
+				fmt.Sprintf("%d dlls.txt mods/libSiliconPatch.dll", PlanDllsAdd),
+				fmt.Sprintf("%d %s M2UseShaders", PlanConfigSet, config),
+				fmt.Sprintf("%d %s movie", PlanConfigSet, config),
+			},
+		},
+		{
+			name: "settings applied and libSiliconPatch disabled",
+			files: map[string]string{
+				"mods/readme.txt":  "",
+				"dlls.txt":         "mods/winerosetta.dll\r\nmods/libSiliconPatch.dll\r\n",
+				"WTF/Config.wtf":   "SET M2UseShaders \"1\"\nSET movie \"0\"\nSET shadowLOD \"1\"\n",
+				"rosettax87/stale": "",
+			},
+			opts: GameOptions{VersionID: "turtlesilicon", EnableShadowLOD: true},
+			want: []string{
+				fmt.Sprintf("%d mods/winerosetta.dll", PlanCopyResource),
+				fmt.Sprintf("%d d3d9.dll", PlanCopyResource),
+				fmt.Sprintf("%d mods/libSiliconPatch.dll", PlanCopyResource),
+				fmt.Sprintf("%d rosettax87", PlanDelete),
+				fmt.Sprintf("%d rosettax87", PlanMkdir),
+				fmt.Sprintf("%d rosettax87/rosettax87", PlanCopyResource),
+				fmt.Sprintf("%d rosettax87/libRuntimeRosettax87", PlanCopyResource),
+				fmt.Sprintf("%d dlls.txt mods/libSiliconPatch.dll", PlanDllsRemove),
+				fmt.Sprintf("%d %s shadowLOD", PlanConfigSet, config),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		gamePath := testGameDir(t, tt.files)
+		plan, err := PlanTurtleWoWPatch(gamePath, tt.opts)
+		if err != nil {
+			t.Fatalf("%s: PlanTurtleWoWPatch() error = %v", tt.name, err)
+		}
+		if got := actionSummary(plan); strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+			t.Errorf("%s: PlanTurtleWoWPatch() actions =\n%s\nwant\n%s", tt.name, strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+		}
+	}
+
+	if _, err := PlanTurtleWoWPatch("", GameOptions{}); !errors.Is(err, ErrGamePathNotSet) {
+		t.Errorf("PlanTurtleWoWPatch(\"\") error = %v, want ErrGamePathNotSet", err)
+	}
+	if _, err := PlanTurtleWoWPatch(filepath.Join(t.TempDir(), "missing"), GameOptions{}); !errors.Is(err, ErrGameDirNotFound) {
+		t.Errorf("PlanTurtleWoWPatch() of a missing directory error = %v, want ErrGameDirNotFound", err)
+	}
+}
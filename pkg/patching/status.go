@@ -0,0 +1,426 @@
+package patching
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"turtlesilicon/pkg/components"
+	"turtlesilicon/pkg/debug"
+	"turtlesilicon/pkg/dllstxt"
+	"turtlesilicon/pkg/utils"
+	"turtlesilicon/pkg/wine"
+)
+
+// PatchComponent identifies a single piece of the game or CrossOver patch
+type PatchComponent string
+
+const (
+	ComponentLoaderDLL       PatchComponent = "Loader DLL"
+	ComponentWinerosetta     PatchComponent = "winerosetta.dll"
+	ComponentD3D9            PatchComponent = "d3d9.dll"
+	ComponentLibSiliconPatch PatchComponent = "libSiliconPatch.dll"
+	ComponentRosettaX87      PatchComponent = "rosettax87"
+	ComponentPatchedExe      PatchComponent = "Patched executable"
+	ComponentLargeAddress    PatchComponent = "Large Address Aware"
+	ComponentDllsTxt         PatchComponent = "dlls.txt registration"
+	ComponentMovieSetting    PatchComponent = "Movie setting"
+	ComponentWineloader2     PatchComponent = "wineloader2"
+)
+
+// ComponentState describes whether a patch component is in place
+type ComponentState int
+
+const (
+	ComponentOK            ComponentState = iota
+	ComponentMissing                      // The component is not installed
+	ComponentInvalid                      // The component is installed but does not match what patching installs
+	ComponentNotApplicable                // The patch method does not use the component
+)
+
+func (s ComponentState) String() string {
+	switch s {
+	case ComponentOK:
+		return "OK"
+	case ComponentMissing:
+		return "Missing"
+	case ComponentInvalid:
+		return "Invalid"
+	case ComponentNotApplicable:
+		return "Not needed"
+	default:
+		return "Unknown"
+	}
+}
+
+// ComponentStatus is the state of one patch component and why it is in that state
+type ComponentStatus struct {
+	Component PatchComponent
+	State     ComponentState
+	Path      string
+	Reason    string
+}
+
+// PatchStatus is the detailed patch state of a game directory and its CrossOver installation
+type PatchStatus struct {
+	GamePath   string
+	Method     string // Patch method the game uses or would use, see PatchMethodRosetta and friends
+	Components []ComponentStatus
+}
+
+// Component returns the status of a single component, or nil if it was not checked
+func (s *PatchStatus) Component(component PatchComponent) *ComponentStatus {
+	for i := range s.Components {
+		if s.Components[i].Component == component {
+			return &s.Components[i]
+		}
+	}
+	return nil
+}
+
+func (s *PatchStatus) set(component PatchComponent, state ComponentState, path string, reason string) {
+	s.Components = append(s.Components, ComponentStatus{Component: component, State: state, Path: path, Reason: reason})
+}
+
+// GameProblems returns the game components that are missing or invalid
+func (s *PatchStatus) GameProblems() []ComponentStatus {
+	var problems []ComponentStatus
+	for _, c := range s.Components {
+		if c.Component == ComponentWineloader2 {
+			continue
+		}
+		if c.State == ComponentMissing || c.State == ComponentInvalid {
+			problems = append(problems, c)
+		}
+	}
+	return problems
+}
+
+// GamePatched returns true if every game component the patch method needs is in place
+func (s *PatchStatus) GamePatched() bool {
+	return s.GamePath != "" && len(s.GameProblems()) == 0
+}
+
+// GamePartiallyPatched returns true if some, but not all, game components are in place
+func (s *PatchStatus) GamePartiallyPatched() bool {
+	if s.GamePatched() {
+		return false
+	}
+	for _, c := range s.Components {
+		if c.Component != ComponentWineloader2 && c.Component != ComponentMovieSetting && c.State == ComponentOK {
+			return true
+		}
+	}
+	return false
+}
+
+// CrossOverPatched returns true if the Wine runtime can launch the game, for CrossOver if
+// wineloader2 is in place
+func (s *PatchStatus) CrossOverPatched() bool {
+	c := s.Component(ComponentWineloader2)
+	return c != nil && c.State == ComponentOK
+}
+
+// GameReason returns a short explanation of why the game is not patched, empty if it is
+func (s *PatchStatus) GameReason() string {
+	if s.GamePath == "" {
+		return "Game path not set"
+	}
+	problems := s.GameProblems()
+	if len(problems) == 0 {
+		return ""
+	}
+	if len(problems) > 1 {
+		return fmt.Sprintf("%s (and %d more)", problems[0].Reason, len(problems)-1)
+	}
+	return problems[0].Reason
+}
+
+// CrossOverReason returns a short explanation of why CrossOver is not patched, empty if it is
+func (s *PatchStatus) CrossOverReason() string {
+	c := s.Component(ComponentWineloader2)
+	if c == nil || c.State == ComponentOK {
+		return ""
+	}
+	return c.Reason
+}
+
+// Report returns every component with its state and reason, one per line
+func (s *PatchStatus) Report() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Patch method: %s\n", s.Method))
+	for _, c := range s.Components {
+		sb.WriteString(fmt.Sprintf("%s: %s - %s\n", c.Component, c.State, c.Reason))
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// LogStatus writes the full status to the debug log
+func (s *PatchStatus) LogStatus() {
+	debug.Printf("Patch status for %s:", s.GamePath)
+	for _, line := range strings.Split(s.Report(), "\n") {
+		debug.Printf("  %s", line)
+	}
+}
+
+// detectPatchMethod works out which patch method the game directory uses. The manifest is the
+// most reliable source, followed by the files on disk and finally the method patching would pick.
+func detectPatchMethod(gamePath string, opts GameOptions) string {
+	if opts.UsesRosettaPatching {
+		return PatchMethodRosetta
+	}
+	if manifest, err := LoadPatchManifest(gamePath); err == nil && manifest != nil && manifest.Method != "" {
+		return manifest.Method
+	}
+	if utils.PathExists(filepath.Join(gamePath, "libDllLdr.dll")) {
+		return PatchMethodLibDllLdr
+	}
+	if opts.UsesDivxDecoderPatch && (opts.VersionID == "burningsilicon" || opts.VersionID == "vanillasilicon") {
+		return PatchMethodDivxDecoder
+	}
+	return PatchMethodLibDllLdr
+}
+
+// CheckPatchStatus inspects every patch component of a game directory and, if opts has a
+// CrossOver path, the wineloader2 copy
+func CheckPatchStatus(gamePath string, opts GameOptions) *PatchStatus {
+	status := &PatchStatus{GamePath: gamePath}
+
+	if gamePath != "" {
+		// Add EpochSilicon executable migration logic
+		if opts.VersionID == "epochsilicon" {
+			migrateEpochSiliconExecutables(gamePath)
+		}
+
+		status.Method = detectPatchMethod(gamePath, opts)
+		switch status.Method {
+		case PatchMethodRosetta:
+			checkRosettaComponents(status, gamePath, opts)
+		case PatchMethodDivxDecoder:
+			checkDivxDecoderComponents(status, gamePath, opts)
+		default:
+			checkLibDllLdrComponents(status, gamePath, opts)
+		}
+		checkRosettaX87Component(status, gamePath, opts)
+
+		// Versions that use the DivX decoder patch also need movies disabled
+		if opts.UsesDivxDecoderPatch {
+			if CheckMovieSetting(gamePath) {
+				status.set(ComponentMovieSetting, ComponentOK, "", "movie is disabled in Config.wtf")
+			} else {
+				status.set(ComponentMovieSetting, ComponentMissing, "", "movie is not disabled in Config.wtf")
+			}
+		}
+	}
+
+	checkRuntimeComponent(status, opts.WineRuntime())
+	return status
+}
+
+// checkFileComponent records whether a file exists
+func checkFileComponent(status *PatchStatus, component PatchComponent, path string) {
+	name := filepath.Base(path)
+	if !utils.PathExists(path) {
+		status.set(component, ComponentMissing, path, fmt.Sprintf("%s is missing", name))
+		return
+	}
+	status.set(component, ComponentOK, path, fmt.Sprintf("%s is installed", name))
+}
+
+// checkResourceComponent records whether a file holds the component build patching installs, so
+// a file the client replaced while updating is found as well as a missing one
+func checkResourceComponent(status *PatchStatus, component PatchComponent, path string, opts GameOptions, resourceName string) {
+	name := filepath.Base(path)
+	if !utils.PathExists(path) {
+		status.set(component, ComponentMissing, path, fmt.Sprintf("%s is missing", name))
+		return
+	}
+	build := resolveComponent(opts, resourceName)
+	if !build.Matches(path) {
+		status.set(component, ComponentInvalid, path, fmt.Sprintf("%s does not match %s, it was replaced", name, describeBuild(build)))
+		return
+	}
+	status.set(component, ComponentOK, path, fmt.Sprintf("%s matches %s", name, describeBuild(build)))
+}
+
+func checkDllsTxtComponent(status *PatchStatus, gamePath string, entries ...string) {
+	dllsTxtPath := filepath.Join(gamePath, dllstxt.FileName)
+	for _, entry := range entries {
+		if isDllRegisteredInDllsTxt(gamePath, entry) {
+			reason := fmt.Sprintf("%s is registered in dlls.txt", entry)
+			if dlls, err := dllstxt.Load(dllsTxtPath); err == nil {
+				if duplicates := dlls.Duplicates(); len(duplicates) > 0 {
+					reason += fmt.Sprintf(" (listed more than once: %s)", strings.Join(duplicates, ", "))
+				}
+			}
+			status.set(ComponentDllsTxt, ComponentOK, dllsTxtPath, reason)
+			return
+		}
+	}
+	status.set(ComponentDllsTxt, ComponentMissing, dllsTxtPath, fmt.Sprintf("%s is not registered in dlls.txt", entries[0]))
+}
+
+func checkRosettaComponents(status *PatchStatus, gamePath string, opts GameOptions) {
+	status.set(ComponentLoaderDLL, ComponentNotApplicable, "", "loaded through dlls.txt")
+	checkResourceComponent(status, ComponentWinerosetta, filepath.Join(gamePath, "mods", "winerosetta.dll"), opts, "winerosetta/winerosetta.dll")
+	checkResourceComponent(status, ComponentD3D9, filepath.Join(gamePath, "d3d9.dll"), opts, "winerosetta/d3d9.dll")
+
+	libSiliconPatchPath := filepath.Join(gamePath, "mods", "libSiliconPatch.dll")
+	libSiliconPatchBuild := resolveComponent(opts, "winerosetta/libSiliconPatch.dll")
+	switch {
+	case !utils.PathExists(libSiliconPatchPath):
+		status.set(ComponentLibSiliconPatch, ComponentMissing, libSiliconPatchPath, "libSiliconPatch.dll is missing")
+	case !libSiliconPatchBuild.Matches(libSiliconPatchPath):
+		status.set(ComponentLibSiliconPatch, ComponentInvalid, libSiliconPatchPath, fmt.Sprintf("libSiliconPatch.dll does not match %s, it was replaced", describeBuild(libSiliconPatchBuild)))
+	case isDllRegisteredInDllsTxt(gamePath, "mods/libSiliconPatch.dll"):
+		status.set(ComponentLibSiliconPatch, ComponentOK, libSiliconPatchPath, "libSiliconPatch.dll is installed and enabled")
+	default:
+		status.set(ComponentLibSiliconPatch, ComponentOK, libSiliconPatchPath, "libSiliconPatch.dll is installed but disabled")
+	}
+
+	status.set(ComponentPatchedExe, ComponentNotApplicable, "", "the original executable is used")
+	status.set(ComponentLargeAddress, ComponentNotApplicable, "", "only changed on patched executables")
+	checkDllsTxtComponent(status, gamePath, "mods/winerosetta.dll", "winerosetta.dll")
+}
+
+// resolveComponent returns the component build the game version patches with, falling back to
+// the bundled build if the requested one is not available
+func resolveComponent(opts GameOptions, name string) components.Resolved {
+	build, err := components.Resolve(name, opts.ComponentVersions[name])
+	if err != nil {
+		debug.Printf("Checking against the bundled %s: %v", name, err)
+		build, _ = components.Resolve(name, components.VersionBundled)
+	}
+	return build
+}
+
+// describeBuild names a component build in status reasons
+func describeBuild(build components.Resolved) string {
+	if build.Bundled() {
+		return "the bundled version"
+	}
+	return "version " + build.Version
+}
+
+func checkDivxDecoderComponents(status *PatchStatus, gamePath string, opts GameOptions) {
+	divxDecoderPath := filepath.Join(gamePath, "DivxDecoder.dll")
+	if !utils.PathExists(divxDecoderPath) {
+		status.set(ComponentLoaderDLL, ComponentMissing, divxDecoderPath, "DivxDecoder.dll is missing")
+	} else if utils.PathExists(filepath.Join(gamePath, "DivxDecoder.dll.backup")) || resolveComponent(opts, "winerosetta/winerosetta.dll").Matches(divxDecoderPath) {
+		status.set(ComponentLoaderDLL, ComponentOK, divxDecoderPath, "DivxDecoder.dll is replaced with winerosetta")
+	} else {
+		status.set(ComponentLoaderDLL, ComponentInvalid, divxDecoderPath, "DivxDecoder.dll is the original, not winerosetta")
+	}
+
+	status.set(ComponentWinerosetta, ComponentNotApplicable, "", "provided by DivxDecoder.dll")
+	checkResourceComponent(status, ComponentD3D9, filepath.Join(gamePath, "d3d9.dll"), opts, "winerosetta/d3d9.dll")
+	status.set(ComponentLibSiliconPatch, ComponentNotApplicable, "", "only used by TurtleSilicon")
+	status.set(ComponentPatchedExe, ComponentNotApplicable, "", "the original executable is used")
+	if executableName, ok := LargeAddressAwareExecutable(opts); ok {
+		checkLargeAddressAwareComponent(status, filepath.Join(gamePath, executableName), opts.LargeAddressAware)
+	} else {
+		status.set(ComponentLargeAddress, ComponentNotApplicable, "", "the launched executable is not known")
+	}
+	status.set(ComponentDllsTxt, ComponentNotApplicable, "", "loaded as DivxDecoder.dll")
+}
+
+func checkLibDllLdrComponents(status *PatchStatus, gamePath string, opts GameOptions) {
+	checkResourceComponent(status, ComponentLoaderDLL, filepath.Join(gamePath, "libDllLdr.dll"), opts, "winerosetta/libDllLdr.dll")
+	checkResourceComponent(status, ComponentWinerosetta, filepath.Join(gamePath, "mods", "winerosetta.dll"), opts, "winerosetta/winerosetta.dll")
+	checkResourceComponent(status, ComponentD3D9, filepath.Join(gamePath, "d3d9.dll"), opts, "winerosetta/d3d9.dll")
+	status.set(ComponentLibSiliconPatch, ComponentNotApplicable, "", "only used by TurtleSilicon")
+
+	executableName := opts.ExecutableName
+	if opts.VersionID == "epochsilicon" {
+		executableName = "Ascension.exe"
+	}
+	_, patchedExecutableName := patchedExecutableNames(executableName)
+	patchedExePath := filepath.Join(gamePath, patchedExecutableName)
+	checkFileComponent(status, ComponentPatchedExe, patchedExePath)
+	checkLargeAddressAwareComponent(status, patchedExePath, opts.LargeAddressAware)
+	checkDllsTxtComponent(status, gamePath, "mods/winerosetta.dll")
+}
+
+// checkLargeAddressAwareComponent records whether the Large Address Aware flag of the launched
+// executable matches the version setting
+func checkLargeAddressAwareComponent(status *PatchStatus, exePath string, wanted bool) {
+	name := filepath.Base(exePath)
+	if !utils.PathExists(exePath) {
+		status.set(ComponentLargeAddress, ComponentNotApplicable, exePath, fmt.Sprintf("%s is missing", name))
+		return
+	}
+
+	enabled, err := utils.IsLargeAddressAware(exePath)
+	switch {
+	case err != nil:
+		status.set(ComponentLargeAddress, ComponentInvalid, exePath, fmt.Sprintf("cannot read the PE header of %s: %v", name, err))
+	case enabled && wanted:
+		status.set(ComponentLargeAddress, ComponentOK, exePath, fmt.Sprintf("%s can use up to 4 GB of memory", name))
+	case enabled:
+		status.set(ComponentLargeAddress, ComponentInvalid, exePath, fmt.Sprintf("%s is Large Address Aware but the setting is off, patch again to clear it", name))
+	case wanted:
+		status.set(ComponentLargeAddress, ComponentMissing, exePath, fmt.Sprintf("%s is not Large Address Aware yet, patch again to set it", name))
+	default:
+		status.set(ComponentLargeAddress, ComponentNotApplicable, exePath, fmt.Sprintf("%s is limited to 2 GB of memory", name))
+	}
+}
+
+func checkRosettaX87Component(status *PatchStatus, gamePath string, opts GameOptions) {
+	rosettaX87Dir := filepath.Join(gamePath, "rosettax87")
+	if !utils.DirExists(rosettaX87Dir) {
+		status.set(ComponentRosettaX87, ComponentMissing, rosettaX87Dir, "rosettax87 directory is missing")
+		return
+	}
+
+	var build components.Resolved
+	for _, source := range rosettaManifestSources {
+		path := filepath.Join(gamePath, filepath.FromSlash(source.Path))
+		name := filepath.Base(path)
+		if !utils.PathExists(path) {
+			status.set(ComponentRosettaX87, ComponentMissing, path, fmt.Sprintf("rosettax87/%s is missing", name))
+			return
+		}
+		// Verify rosettax87 binary files with hash/size verification
+		build = resolveComponent(opts, source.Resource)
+		if !build.Matches(path) {
+			status.set(ComponentRosettaX87, ComponentInvalid, path, fmt.Sprintf("rosettax87/%s does not match %s", name, describeBuild(build)))
+			return
+		}
+	}
+	status.set(ComponentRosettaX87, ComponentOK, rosettaX87Dir, fmt.Sprintf("rosettax87 binaries match %s", describeBuild(build)))
+}
+
+// checkRuntimeComponent records whether the Wine runtime can launch the game. Only CrossOver needs
+// patching, other runtimes just have to be found.
+func checkRuntimeComponent(status *PatchStatus, runtime wine.Runtime) {
+	if runtime.Kind() == wine.KindCrossOver {
+		checkWineloader2Component(status, runtime.Path())
+		return
+	}
+	if err := wine.Ready(runtime); err != nil {
+		status.set(ComponentWineloader2, ComponentMissing, runtime.Path(), err.Error())
+		return
+	}
+	status.set(ComponentWineloader2, ComponentOK, runtime.LoaderPath(), fmt.Sprintf("%s needs no patching", runtime.Name()))
+}
+
+func checkWineloader2Component(status *PatchStatus, crossoverPath string) {
+	if crossoverPath == "" {
+		status.set(ComponentWineloader2, ComponentMissing, "", "CrossOver path not set")
+		return
+	}
+	wineloader2Path := wine.NewCrossOver(crossoverPath).LoaderPath()
+	if change := CheckCrossOverChange(crossoverPath); change != nil {
+		state := ComponentInvalid
+		if change.Wineloader2Missing {
+			state = ComponentMissing
+		}
+		status.set(ComponentWineloader2, state, wineloader2Path, change.Describe()+", patch CrossOver again")
+		return
+	}
+	if utils.PathExists(wineloader2Path) {
+		status.set(ComponentWineloader2, ComponentOK, wineloader2Path, "wineloader2 is installed")
+	} else {
+		status.set(ComponentWineloader2, ComponentMissing, wineloader2Path, "wineloader2 is missing, patch CrossOver")
+	}
+}
@@ -0,0 +1,99 @@
+package patching
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"turtlesilicon/pkg/assets"
+)
+
+func TestCheckResourceComponent(t *testing.T) {
+	original, err := assets.ReadFile("winerosetta/d3d9.dll")
+	if err != nil {
+		t.Fatalf("Failed to read the bundled d3d9.dll: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		content []byte
+		want    ComponentState
+	}{
+		{"installed by patching", original, ComponentOK},
+		{"replaced by the client", []byte("MZ client d3d9"), ComponentInvalid},
+		{"removed by the client", nil, ComponentMissing},
+	}
+
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), "d3d9.dll")
+		if tt.content != nil {
+			if err := os.WriteFile(path, tt.content, 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		status := &PatchStatus{}
+		checkResourceComponent(status, ComponentD3D9, path, GameOptions{}, "winerosetta/d3d9.dll")
+		if got := status.Component(ComponentD3D9).State; got != tt.want {
+			t.Errorf("%s: state = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCheckVersionPatchingStatusDivxDecoder(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+
+	winerosetta, err := assets.ReadFile("winerosetta/winerosetta.dll")
+	if err != nil {
+		t.Fatalf("Failed to read the bundled winerosetta.dll: %v", err)
+	}
+	// The Large Address Aware flag is bit 0x20 of the characteristics at offset 22 of the PE header
+	largeAddressAware := append([]byte(nil), winerosetta...)
+	peOffset := int(largeAddressAware[0x3c]) | int(largeAddressAware[0x3d])<<8
+	largeAddressAware[peOffset+22] |= 0x20
+
+	tests := []struct {
+		name string
+		exe  []byte
+		want bool
+	}{
+		{"executable without the flag", winerosetta, true},
+		{"executable missing", nil, true},
+		{"executable with the flag but the setting off", largeAddressAware, false},
+	}
+
+	for _, tt := range tests {
+		gamePath := t.TempDir()
+		files := map[string][]byte{
+			"DivxDecoder.dll": winerosetta,
+			"WTF/Config.wtf":  []byte("SET movie \"0\"\n"),
+		}
+		for path, resource := range map[string]string{
+			"d3d9.dll":                        "winerosetta/d3d9.dll",
+			"rosettax87/rosettax87":           "rosettax87/rosettax87",
+			"rosettax87/libRuntimeRosettax87": "rosettax87/libRuntimeRosettax87",
+		} {
+			content, err := assets.ReadFile(resource)
+			if err != nil {
+				t.Fatalf("Failed to read the bundled %s: %v", resource, err)
+			}
+			files[path] = content
+		}
+		if tt.exe != nil {
+			// burningsilicon launches WoW.exe according to its version settings
+			files["WoW.exe"] = tt.exe
+		}
+		for name, content := range files {
+			path := filepath.Join(gamePath, filepath.FromSlash(name))
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, content, 0755); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if got := CheckVersionPatchingStatus(gamePath, false, true, "burningsilicon"); got != tt.want {
+			t.Errorf("%s: CheckVersionPatchingStatus() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
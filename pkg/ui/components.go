@@ -67,11 +67,17 @@ func createOptionsComponents() {
 	})
 	vanillaTweaksCheckbox.SetChecked(currentVer.Settings.EnableVanillaTweaks)
 	launcher.EnableVanillaTweaks = currentVer.Settings.EnableVanillaTweaks
+	launcher.VanillaTweakSelection = currentVer.Settings.VanillaTweaks
+
+	vanillaTweaksButton = widget.NewButton("Choose Tweaks", func() {
+		showVanillaTweaksPopup()
+	})
 
 	// Apply version capability check for vanilla-tweaks
 	if !currentVer.SupportsVanillaTweaks {
 		vanillaTweaksCheckbox.Disable()
 		vanillaTweaksCheckbox.SetChecked(false)
+		vanillaTweaksButton.Disable()
 		launcher.EnableVanillaTweaks = false
 	}
 
@@ -88,6 +94,43 @@ func createOptionsComponents() {
 	autoDeleteWdbCheckbox.SetChecked(currentVer.Settings.AutoDeleteWdb)
 	launcher.AutoDeleteWdb = currentVer.Settings.AutoDeleteWdb
 
+	autoRepatchCheckbox = widget.NewCheck("Automatically repair patches after game updates", func(checked bool) {
+		// Save to current version settings
+		currentVer := GetCurrentVersion()
+		if currentVer != nil {
+			currentVer.Settings.AutoRepatchAfterUpdate = checked
+			SaveCurrentVersion(currentVer)
+		}
+		debug.Printf("Auto-repatch after update enabled: %v", checked)
+	})
+	autoRepatchCheckbox.SetChecked(currentVer.Settings.AutoRepatchAfterUpdate)
+
+	autoCrossOverCheckbox = widget.NewCheck("Automatically re-patch CrossOver after CrossOver updates", func(checked bool) {
+		// Save to current version settings
+		currentVer := GetCurrentVersion()
+		if currentVer != nil {
+			currentVer.Settings.AutoRepatchCrossOver = checked
+			SaveCurrentVersion(currentVer)
+		}
+		debug.Printf("Auto-repatch CrossOver enabled: %v", checked)
+	})
+	autoCrossOverCheckbox.SetChecked(currentVer.Settings.AutoRepatchCrossOver)
+
+	largeAddressCheckbox = widget.NewCheck("Let the game executable use up to 4 GB of memory (Large Address Aware)", func(checked bool) {
+		// Save to current version settings, the flag is changed the next time the game is patched
+		currentVer := GetCurrentVersion()
+		if currentVer != nil && currentVer.Settings.LargeAddressAware != checked {
+			currentVer.Settings.LargeAddressAware = checked
+			SaveCurrentVersion(currentVer)
+			UpdateAllStatuses()
+		}
+		debug.Printf("Large Address Aware enabled: %v", checked)
+	})
+	largeAddressCheckbox.SetChecked(currentVer.Settings.LargeAddressAware)
+	if !patching.SupportsLargeAddressAware(patching.GameOptionsForVersion(currentVer)) {
+		largeAddressCheckbox.Disable()
+	}
+
 	// Create recommended settings button with help icon
 	applyRecommendedSettingsButton = widget.NewButton("Apply recommended settings", func() {
 		err := launcher.ApplyRecommendedSettings()
@@ -155,7 +198,13 @@ func createPatchingButtons(myWindow fyne.Window) {
 		if currentVer != nil {
 			paths.CrossoverPath = currentVer.CrossOverPath
 		}
-		patching.PatchCrossOver(myWindow, UpdateAllStatuses)
+		plan, err := patching.PlanRuntimePatch(currentWineRuntime())
+		if err != nil {
+			dialog.ShowError(err, myWindow)
+			UpdateAllStatuses()
+			return
+		}
+		showPatchPlanConfirmation(myWindow, plan)
 	})
 	unpatchCrossOverButton = widget.NewButton("Unpatch CrossOver", func() {
 		// Ensure CrossOver path is synced for CrossOver unpatching
@@ -163,7 +212,13 @@ func createPatchingButtons(myWindow fyne.Window) {
 		if currentVer != nil {
 			paths.CrossoverPath = currentVer.CrossOverPath
 		}
-		patching.UnpatchCrossOver(myWindow, UpdateAllStatuses)
+		plan, err := patching.PlanRuntimeUnpatch(currentWineRuntime())
+		if err != nil {
+			dialog.ShowError(err, myWindow)
+			UpdateAllStatuses()
+			return
+		}
+		showPatchPlanConfirmation(myWindow, plan)
 	})
 }
 
@@ -284,7 +339,8 @@ func createWineRegistryComponents() {
 				remapOperationInProgress = false
 			}()
 
-			if err := utils.SetOptionAsAltEnabled(true); err != nil {
+			runtime := currentWineRuntime()
+			if err := utils.SetOptionAsAltEnabled(runtime.ToolLoaderPath(), runtime.Prefix(), true); err != nil {
 				debug.Printf("Failed to enable Option-as-Alt mapping: %v", err)
 				// Update UI on main thread
 				fyne.Do(func() {
@@ -326,7 +382,8 @@ func createWineRegistryComponents() {
 				remapOperationInProgress = false
 			}()
 
-			if err := utils.SetOptionAsAltEnabled(false); err != nil {
+			runtime := currentWineRuntime()
+			if err := utils.SetOptionAsAltEnabled(runtime.ToolLoaderPath(), runtime.Prefix(), false); err != nil {
 				debug.Printf("Failed to disable Option-as-Alt mapping: %v", err)
 				// Update UI on main thread
 				fyne.Do(func() {
@@ -367,10 +424,11 @@ func updateWineRegistryStatus() {
 func updateWineRegistryStatusWithMethod(useWineCommand bool) {
 	if useWineCommand {
 		// Use Wine command for accurate check after modifications
-		currentWineRegistryEnabled = utils.CheckOptionAsAltEnabled()
+		runtime := currentWineRuntime()
+		currentWineRegistryEnabled = utils.CheckOptionAsAltEnabled(runtime.ToolLoaderPath(), runtime.Prefix())
 	} else {
 		// Use fast file-based check for regular status updates
-		currentWineRegistryEnabled = utils.CheckOptionAsAltEnabledFast()
+		currentWineRegistryEnabled = utils.CheckOptionAsAltEnabledFast(currentWineRuntime().Prefix())
 	}
 
 	// Update UI with simple white text
@@ -607,7 +665,6 @@ func createGraphicsSettingsComponents() {
 		}
 
 		err := patching.ApplyGraphicsSettingsForVersion(
-			currentWindow,
 			currentVer.GamePath,
 			currentVer.Settings.ReduceTerrainDistance,
 			currentVer.Settings.SetMultisampleTo2x,
@@ -724,6 +781,7 @@ func refreshLeftButtonsContainer(optionsButton, troubleshootingButton, addonsBut
 		if err == nil && currentVer.SupportsDLLLoading {
 			modsButton := widget.NewButton("Mods", func() {
 				modManager := mods.NewModManager(myWindow, vm)
+				modManager.SetLaunchGame(func() { launchGame(myWindow) })
 				modManager.ShowModManager()
 			})
 			leftButtons = container.NewHBox(
@@ -763,6 +821,7 @@ func RefreshLeftButtons() {
 		if err == nil && currentVer.SupportsDLLLoading {
 			modsButton := widget.NewButton("Mods", func() {
 				modManager := mods.NewModManager(currentWindow, vm)
+				modManager.SetLaunchGame(func() { launchGame(currentWindow) })
 				modManager.ShowModManager()
 			})
 			leftButtons.Objects = []fyne.CanvasObject{
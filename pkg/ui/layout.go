@@ -134,10 +134,10 @@ func updateLogoForVersion(versionID string) {
 	debug.Printf("Updated logo to: %s", iconPath)
 }
 
-// createPathSelectionForm creates the form for selecting CrossOver and game paths
+// createPathSelectionForm creates the form for selecting the Wine runtime and game paths
 func createPathSelectionForm(myWindow fyne.Window) *widget.Form {
 	pathSelectionForm := widget.NewForm(
-		widget.NewFormItem("CrossOver Path:", container.NewBorder(nil, nil, nil, widget.NewButton("Set/Change", func() {
+		widget.NewFormItem("Wine Runtime:", container.NewBorder(nil, nil, nil, widget.NewButton("Set/Change", func() {
 			SelectCurrentVersionCrossOverPath(myWindow)
 		}), crossoverPathLabel)),
 		widget.NewFormItem("Game Path:", container.NewBorder(nil, nil, nil, widget.NewButton("Set/Change", func() {
@@ -158,6 +158,7 @@ func createPatchOperationsLayout() fyne.CanvasObject {
 		container.NewGridWithColumns(4,
 			widget.NewLabel("CrossOver Patch:"), crossoverStatusLabel, patchCrossOverButton, unpatchCrossOverButton,
 		),
+		patchStatusReasonLabel,
 		// Service UI removed - rosettax87 now uses direct execution instead of service
 		// container.NewGridWithColumns(4,
 		//	widget.NewLabel("RosettaX87 Service:"), serviceStatusLabel, startServiceButton, stopServiceButton,
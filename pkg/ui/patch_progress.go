@@ -0,0 +1,106 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"turtlesilicon/pkg/debug"
+	"turtlesilicon/pkg/patching"
+	"turtlesilicon/pkg/paths"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// applyPatchPlan applies a plan with the engine in the background while the progress popup
+// follows its events, and reports the outcome with the usual dialogs
+func applyPatchPlan(myWindow fyne.Window, updateAllStatuses func(), plan *patching.PatchPlan) {
+	if plan.NothingToApply() {
+		dialog.ShowInformation("Info", plan.EmptyMessage, myWindow)
+		updateAllStatuses()
+		return
+	}
+
+	progressPopup, messageLabel := createPatchingProgressPopup(myWindow)
+	progressPopup.Show()
+
+	engine := patching.NewEngine(func(event patching.PatchEvent) {
+		if event.Kind != patching.EventStepStarted {
+			return
+		}
+		fyne.Do(func() {
+			messageLabel.SetText(fmt.Sprintf("Step %d of %d: %s", event.Step, event.Total, event.Message))
+		})
+	})
+
+	// Run the plan in a goroutine to keep UI responsive
+	go func() {
+		_, err := engine.Apply(plan)
+
+		fyne.DoAndWait(func() {
+			progressPopup.Hide()
+
+			if err != nil {
+				errMsg := err.Error()
+				if strings.Contains(errMsg, "operation not permitted") {
+					errMsg += "\n\nSolution: Open System Settings, go to Privacy & Security > App Management, and enable TurtleSilicon."
+				}
+				dialog.ShowError(errors.New(errMsg), myWindow)
+				debug.Println(errMsg)
+				if plan.Target == patching.PlanTargetCrossOver {
+					paths.PatchesAppliedCrossOver = false
+				} else {
+					paths.PatchesAppliedTurtleWoW = false
+				}
+				updateAllStatuses()
+				return
+			}
+
+			if plan.Target == patching.PlanTargetCrossOver {
+				paths.PatchesAppliedCrossOver = !plan.Unpatch
+			} else if plan.Unpatch {
+				paths.PatchesAppliedTurtleWoW = false
+			}
+			dialog.ShowInformation("Success", plan.SuccessMessage, myWindow)
+			updateAllStatuses()
+		})
+	}()
+}
+
+// createPatchingProgressPopup creates a modal popup to show patching progress. The returned
+// label shows the step that is currently running.
+func createPatchingProgressPopup(myWindow fyne.Window) (*widget.PopUp, *widget.Label) {
+	// Create progress message
+	titleLabel := widget.NewLabel("Patching Game")
+	titleLabel.TextStyle = fyne.TextStyle{Bold: true}
+
+	messageLabel := widget.NewLabel("Please wait while the game is being patched...")
+	messageLabel.Wrapping = fyne.TextWrapWord
+
+	// Create a progress bar (indeterminate)
+	progressBar := widget.NewProgressBarInfinite()
+	progressBar.Start()
+
+	// Create content container
+	content := container.NewVBox(
+		titleLabel,
+		widget.NewSeparator(),
+		messageLabel,
+		widget.NewSeparator(),
+		progressBar,
+	)
+
+	// Create the popup
+	popup := widget.NewModalPopUp(
+		container.NewPadded(content),
+		myWindow.Canvas(),
+	)
+
+	// Set popup size
+	popup.Resize(fyne.NewSize(350, 170))
+
+	return popup, messageLabel
+}
@@ -13,13 +13,16 @@ import (
 	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/widget"
 
-	"howett.net/plist"
-
+	"turtlesilicon/pkg/assets"
+	"turtlesilicon/pkg/backup"
+	"turtlesilicon/pkg/components"
 	"turtlesilicon/pkg/debug"
+	"turtlesilicon/pkg/launcher"
 	"turtlesilicon/pkg/patching"
 	"turtlesilicon/pkg/paths"
 	"turtlesilicon/pkg/utils"
 	"turtlesilicon/pkg/version"
+	"turtlesilicon/pkg/wine"
 )
 
 // showOptionsPopup creates and shows an integrated popup window for options
@@ -48,8 +51,11 @@ func showOptionsPopup() {
 		widget.NewSeparator(),
 		metalHudCheckbox,
 		showTerminalCheckbox,
-		vanillaTweaksCheckbox,
+		container.NewBorder(nil, nil, nil, vanillaTweaksButton, vanillaTweaksCheckbox),
 		autoDeleteWdbCheckbox,
+		autoRepatchCheckbox,
+		autoCrossOverCheckbox,
+		largeAddressCheckbox,
 		widget.NewSeparator(),
 		container.NewBorder(nil, nil, nil, container.NewHBox(enableOptionAsAltButton, disableOptionAsAltButton), optionAsAltStatusLabel),
 	)
@@ -243,6 +249,9 @@ func showDebugLogPopup() {
 		RosettaX87ServiceRunning: paths.RosettaX87ServiceRunning,
 		ServiceStarting:          paths.ServiceStarting,
 	}
+	if currentVer := GetCurrentVersion(); currentVer != nil {
+		debugInfo.PatchStatusReport = patching.CheckPatchStatus(currentVer.GamePath, currentGameOptions()).Report()
+	}
 
 	// Get current version info
 	var gameVersionInfo *debug.GameVersionInfo = nil
@@ -350,7 +359,7 @@ func showTroubleshootingPopup() {
 	}
 
 	// --- CrossOver Version Check ---
-	crossoverVersion := getCrossoverVersion(paths.CrossoverPath)
+	crossoverVersion := wine.NewCrossOver(paths.CrossoverPath).Version()
 	var crossoverStatusShort *widget.Label
 	var crossoverStatusDetail *widget.Label
 	if crossoverVersion == "" {
@@ -408,6 +417,21 @@ func showTroubleshootingPopup() {
 		deleteVanillaTweaksFile()
 	})
 
+	// --- Verify Patched Files ---
+	verifyPatchButton := widget.NewButton("Verify", func() {
+		verifyGamePatchInPopup()
+	})
+
+	// --- Compatibility Components ---
+	componentsButton := widget.NewButton("Manage", func() {
+		showComponentsPopup()
+	})
+
+	// --- Backups of Replaced Files ---
+	backupsButton := widget.NewButton("Backups", func() {
+		showBackupsPopup()
+	})
+
 	// --- Generate Debug Log ---
 	debugLogButton := widget.NewButton("Show Debug Log", func() {
 		showDebugLogPopup()
@@ -476,6 +500,9 @@ func showTroubleshootingPopup() {
 	rowWine := container.NewBorder(nil, nil, widget.NewLabel(wineLabel), wineDeleteButton, nil)
 	rowVanillaTweaks := container.NewBorder(nil, nil, widget.NewLabel("Delete vanilla tweaks (only necessary after a new patch):"), vanillaTweaksDeleteButton, nil)
 
+	rowVerifyPatch := container.NewBorder(nil, nil, widget.NewLabel("Verify patched files and repair broken ones:"), verifyPatchButton, nil)
+	rowComponents := container.NewBorder(nil, nil, widget.NewLabel("Update or roll back winerosetta, d3d9 and rosettax87 builds:"), componentsButton, nil)
+	rowBackups := container.NewBorder(nil, nil, widget.NewLabel("Restore game files TurtleSilicon replaced or deleted:"), backupsButton, nil)
 	rowDebugLog := container.NewBorder(nil, nil, widget.NewLabel("Show debug log for support:"), debugLogButton, nil)
 	rowResetTurtleSilicon := container.NewBorder(nil, nil, widget.NewLabel("Reset TurtleSilicon (deletes all preferences and settings):"), resetTurtleSiliconButton, nil)
 	appMgmtNote := widget.NewLabel("Please ensure TurtleSilicon is enabled in System Settings > Privacy & Security > App Management.")
@@ -487,6 +514,9 @@ func showTroubleshootingPopup() {
 	content.Add(rowWDB)
 	content.Add(rowWine)
 	content.Add(rowVanillaTweaks)
+	content.Add(rowVerifyPatch)
+	content.Add(rowComponents)
+	content.Add(rowBackups)
 	content.Add(rowDebugLog)
 	content.Add(rowResetTurtleSilicon)
 	content.Add(widget.NewSeparator())
@@ -554,27 +584,6 @@ func showTroubleshootingPopup() {
 	popup.Show()
 }
 
-// getCrossoverVersion reads the Info.plist and returns the version string, or "" if not found
-func getCrossoverVersion(appPath string) string {
-	if appPath == "" {
-		return ""
-	}
-	plistPath := filepath.Join(appPath, "Contents", "Info.plist")
-	f, err := os.Open(plistPath)
-	if err != nil {
-		return ""
-	}
-	defer f.Close()
-	var data struct {
-		Version string `plist:"CFBundleShortVersionString"`
-	}
-	decoder := plist.NewDecoder(f)
-	if err := decoder.Decode(&data); err != nil {
-		return ""
-	}
-	return data.Version
-}
-
 // isCrossoverVersionRecommended returns true if version >= 25.0.1
 func isCrossoverVersionRecommended(version string) bool {
 	parts := strings.Split(version, ".")
@@ -844,3 +853,333 @@ func resetTurtleSilicon() {
 		}
 	}, currentWindow).Show()
 }
+
+// verifyGamePatchInPopup checks the patched files against the patch manifest and offers to repair them
+func verifyGamePatchInPopup() {
+	currentVer := GetCurrentVersion()
+	gamePath := ""
+
+	if currentVer != nil && currentVer.GamePath != "" {
+		gamePath = currentVer.GamePath
+	} else {
+		// Fall back to legacy path
+		gamePath = paths.TurtlewowPath
+	}
+
+	if gamePath == "" {
+		dialog.ShowInformation("Path Not Set", "No game path is set. Please set your game directory first.", currentWindow)
+		return
+	}
+
+	result, err := patching.VerifyPatchManifest(gamePath)
+	if err != nil {
+		dialog.ShowError(err, currentWindow)
+		return
+	}
+
+	if result.OK() {
+		dialog.ShowInformation("Patch Verified", result.Summary(), currentWindow)
+		return
+	}
+
+	msg := result.Summary() + "\n\nRepair will restore only these files without re-patching the game. Continue?"
+	dialog.NewConfirm("Patch Problems Found", msg, func(confirm bool) {
+		if !confirm {
+			return
+		}
+
+		repair, err := patching.RepairPatchManifest(gamePath)
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("Failed to repair patched files: %v", err), currentWindow)
+			UpdateAllStatuses()
+			return
+		}
+
+		summary := fmt.Sprintf("Restored %d file(s) and removed %d unexpected file(s).", len(repair.Restored), len(repair.Removed))
+		if len(repair.Unrepairable) > 0 {
+			summary += "\n\nThe following files are generated during patching and need a full re-patch:\n- " + strings.Join(repair.Unrepairable, "\n- ")
+		}
+		dialog.ShowInformation("Repair Complete", summary, currentWindow)
+		UpdateAllStatuses()
+	}, currentWindow).Show()
+}
+
+// componentVersionOptions lists the builds a component can be switched to
+func componentVersionOptions(name string) []string {
+	options := []string{components.VersionBundled, components.VersionLatest}
+	for _, cached := range components.CachedVersions(name) {
+		options = append(options, cached.Version)
+	}
+	return options
+}
+
+// showComponentsPopup lets the user choose, update and roll back the compatibility component
+// builds the current version is patched with
+func showComponentsPopup() {
+	if currentWindow == nil {
+		return
+	}
+	currentVer := GetCurrentVersion()
+	if currentVer == nil {
+		dialog.ShowError(fmt.Errorf("no current version selected"), currentWindow)
+		return
+	}
+
+	titleLabel := widget.NewLabel("Compatibility Components")
+	titleLabel.TextStyle = fyne.TextStyle{Bold: true}
+
+	instructionLabel := widget.NewLabel(fmt.Sprintf("Choose the build of each component used when patching %s. \"latest\" uses the newest downloaded build. Patch the game again after changing a selection.", currentVer.DisplayName))
+	instructionLabel.Wrapping = fyne.TextWrapWord
+	instructionLabel.TextStyle = fyne.TextStyle{Italic: true}
+
+	installed := components.Installed(currentVer.ID)
+	selects := make(map[string]*widget.Select)
+	rows := container.NewVBox()
+	for _, name := range assets.Names() {
+		name := name
+
+		versionSelect := widget.NewSelect(componentVersionOptions(name), nil)
+		selected := currentVer.Settings.ComponentVersions[name]
+		if selected == "" {
+			selected = components.VersionBundled
+		}
+		versionSelect.SetSelected(selected)
+		versionSelect.OnChanged = func(requested string) {
+			if currentVer.Settings.ComponentVersions == nil {
+				currentVer.Settings.ComponentVersions = make(map[string]string)
+			}
+			if requested == components.VersionBundled {
+				delete(currentVer.Settings.ComponentVersions, name)
+			} else {
+				currentVer.Settings.ComponentVersions[name] = requested
+			}
+			if err := SaveCurrentVersion(currentVer); err != nil {
+				dialog.ShowError(err, currentWindow)
+				return
+			}
+			UpdateAllStatuses()
+		}
+		selects[name] = versionSelect
+
+		rollbackButton := widget.NewButton("Roll Back", func() {
+			previous, err := components.Rollback(currentVer.ID, name)
+			if err != nil {
+				dialog.ShowError(err, currentWindow)
+				return
+			}
+			versionSelect.SetSelected(previous)
+			dialog.NewConfirm("Component Rolled Back", fmt.Sprintf("%s will use %s again. Patch the game now to install it?", name, previous), func(confirm bool) {
+				if confirm {
+					PatchCurrentVersion(currentWindow)
+				}
+			}, currentWindow).Show()
+		})
+
+		label := name
+		if installedVersion, ok := installed[name]; ok {
+			label = fmt.Sprintf("%s (installed: %s)", name, installedVersion)
+		}
+		rows.Add(container.NewBorder(nil, nil, widget.NewLabel(label), container.NewHBox(versionSelect, rollbackButton), nil))
+	}
+
+	var checkButton *widget.Button
+	checkButton = widget.NewButton("Check for Updates", func() {
+		checkButton.Disable()
+		go func() {
+			var downloaded []string
+			index, err := components.FetchIndex(components.DefaultIndexURL)
+			if err == nil {
+				for _, update := range index.Updates() {
+					if _, err = components.Download(update.Name, update.Release); err != nil {
+						break
+					}
+					downloaded = append(downloaded, fmt.Sprintf("%s %s", update.Name, update.Release.Version))
+				}
+			}
+
+			fyne.Do(func() {
+				checkButton.Enable()
+				for name, versionSelect := range selects {
+					versionSelect.Options = componentVersionOptions(name)
+					versionSelect.Refresh()
+				}
+				switch {
+				case err != nil:
+					dialog.ShowError(fmt.Errorf("failed to update components: %v", err), currentWindow)
+				case len(downloaded) == 0:
+					dialog.ShowInformation("Components Up to Date", "No newer component builds are available.", currentWindow)
+				default:
+					dialog.ShowInformation("Components Downloaded", "Downloaded:\n- "+strings.Join(downloaded, "\n- ")+"\n\nComponents set to \"latest\" use them the next time the game is patched.", currentWindow)
+				}
+				UpdateAllStatuses()
+			})
+		}()
+	})
+	checkButton.Importance = widget.HighImportance
+
+	closeButton := widget.NewButton("Close", func() {})
+
+	content := container.NewBorder(
+		container.NewVBox(titleLabel, instructionLabel, widget.NewSeparator()), // top
+		container.NewHBox(checkButton, widget.NewSeparator(), closeButton),     // bottom
+		nil,                        // left
+		nil,                        // right
+		container.NewVScroll(rows), // center
+	)
+
+	popup := widget.NewModalPopUp(container.NewPadded(content), currentWindow.Canvas())
+	closeButton.OnTapped = func() {
+		popup.Hide()
+	}
+
+	canvasSize := currentWindow.Canvas().Size()
+	popup.Resize(fyne.NewSize(canvasSize.Width*0.8, canvasSize.Height*0.8))
+	popup.Show()
+}
+
+// showBackupsPopup lists the files in the backup store of the current game directory and lets the
+// user restore the original version of each
+func showBackupsPopup() {
+	if currentWindow == nil {
+		return
+	}
+	currentVer := GetCurrentVersion()
+	if currentVer == nil || currentVer.GamePath == "" {
+		dialog.ShowError(fmt.Errorf("game path not set"), currentWindow)
+		return
+	}
+	store, err := backup.Open(currentVer.GamePath)
+	if err != nil {
+		dialog.ShowError(err, currentWindow)
+		return
+	}
+
+	titleLabel := widget.NewLabel("Backups of Replaced Files")
+	titleLabel.TextStyle = fyne.TextStyle{Bold: true}
+
+	instructionLabel := widget.NewLabel("TurtleSilicon keeps a copy of every game file it overwrites or deletes. Restoring a file brings back the version the game had before TurtleSilicon first changed it. The current file is backed up as well.")
+	instructionLabel.Wrapping = fyne.TextWrapWord
+	instructionLabel.TextStyle = fyne.TextStyle{Italic: true}
+
+	rows := container.NewVBox()
+	for _, path := range store.Paths() {
+		path := path
+		history := store.History(path)
+		original := history[0]
+
+		label := widget.NewLabel(fmt.Sprintf("%s (%d version(s), original from %s, %s)", path, len(history), original.Time.Format("2006-01-02 15:04"), original.Operation))
+		label.Wrapping = fyne.TextWrapWord
+		restoreButton := widget.NewButton("Restore Original", func() {
+			dialog.NewConfirm("Restore Original", fmt.Sprintf("Replace %s with the version from %s?", path, original.Time.Format("2006-01-02 15:04")), func(confirm bool) {
+				if !confirm {
+					return
+				}
+				if err := store.Restore(original); err != nil {
+					dialog.ShowError(err, currentWindow)
+					return
+				}
+				UpdateAllStatuses()
+				dialog.ShowInformation("File Restored", fmt.Sprintf("%s was restored.", path), currentWindow)
+			}, currentWindow).Show()
+		})
+		rows.Add(container.NewBorder(nil, nil, nil, restoreButton, label))
+	}
+	if len(rows.Objects) == 0 {
+		rows.Add(widget.NewLabel("No files have been backed up for this game directory yet."))
+	}
+
+	closeButton := widget.NewButton("Close", func() {})
+
+	content := container.NewBorder(
+		container.NewVBox(titleLabel, instructionLabel, widget.NewSeparator()), // top
+		container.NewHBox(closeButton),                                         // bottom
+		nil,                                                                    // left
+		nil,                                                                    // right
+		container.NewVScroll(rows),                                             // center
+	)
+
+	popup := widget.NewModalPopUp(container.NewPadded(content), currentWindow.Canvas())
+	closeButton.OnTapped = func() {
+		popup.Hide()
+	}
+
+	canvasSize := currentWindow.Canvas().Size()
+	popup.Resize(fyne.NewSize(canvasSize.Width*0.8, canvasSize.Height*0.8))
+	popup.Show()
+}
+
+// showVanillaTweaksPopup lets the user choose which vanilla-tweaks are applied to WoW_tweaked.exe
+func showVanillaTweaksPopup() {
+	if currentWindow == nil {
+		return
+	}
+	currentVer := GetCurrentVersion()
+	if currentVer == nil {
+		dialog.ShowError(fmt.Errorf("no current version selected"), currentWindow)
+		return
+	}
+
+	selection := currentVer.Settings.VanillaTweaks
+	if selection == nil {
+		selection = launcher.DefaultVanillaTweakSelection()
+	}
+
+	checks := make(map[launcher.VanillaTweak]*widget.Check)
+	entries := make(map[launcher.VanillaTweak]*widget.Entry)
+	rows := container.NewVBox()
+	for _, info := range launcher.VanillaTweaks {
+		value, enabled := selection[string(info.Tweak)]
+		if !enabled {
+			value = info.Default
+		}
+
+		entry := widget.NewEntry()
+		entry.SetText(strconv.FormatFloat(value, 'f', -1, 64))
+		check := widget.NewCheck(info.Name, nil)
+		check.SetChecked(enabled)
+
+		description := widget.NewLabel(fmt.Sprintf("%s (%g to %g)", info.Description, info.Min, info.Max))
+		description.TextStyle = fyne.TextStyle{Italic: true}
+		description.Wrapping = fyne.TextWrapWord
+
+		checks[info.Tweak] = check
+		entries[info.Tweak] = entry
+		rows.Add(container.NewBorder(nil, nil, check, nil, entry))
+		rows.Add(description)
+	}
+
+	intro := widget.NewLabel("WoW_tweaked.exe is created from WoW.exe with the selected tweaks the next time the game is launched.")
+	intro.Wrapping = fyne.TextWrapWord
+	content := container.NewBorder(intro, nil, nil, nil, container.NewVScroll(rows))
+
+	tweaksDialog := dialog.NewCustomConfirm("Vanilla Tweaks", "Save", "Cancel", content, func(confirm bool) {
+		if !confirm {
+			return
+		}
+
+		newSelection := make(map[string]float64)
+		for _, info := range launcher.VanillaTweaks {
+			if !checks[info.Tweak].Checked {
+				continue
+			}
+			value, err := strconv.ParseFloat(strings.TrimSpace(entries[info.Tweak].Text), 64)
+			if err != nil || value < info.Min || value > info.Max {
+				dialog.ShowError(fmt.Errorf("%s must be a number between %g and %g", info.Name, info.Min, info.Max), currentWindow)
+				return
+			}
+			newSelection[string(info.Tweak)] = value
+		}
+
+		currentVer.Settings.VanillaTweaks = newSelection
+		if err := SaveCurrentVersion(currentVer); err != nil {
+			dialog.ShowError(err, currentWindow)
+			return
+		}
+		launcher.VanillaTweakSelection = newSelection
+		debug.Printf("Vanilla tweaks selection saved: %v", newSelection)
+	}, currentWindow)
+
+	windowSize := currentWindow.Canvas().Size()
+	tweaksDialog.Resize(fyne.NewSize(windowSize.Width*0.8, windowSize.Height*0.8))
+	tweaksDialog.Show()
+}
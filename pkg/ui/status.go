@@ -1,13 +1,14 @@
 package ui
 
 import (
-	"path/filepath"
+	"strings"
 	"time"
 
 	"turtlesilicon/pkg/patching"
 	"turtlesilicon/pkg/paths"
 	"turtlesilicon/pkg/service"
 	"turtlesilicon/pkg/utils"
+	"turtlesilicon/pkg/wine"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/theme"
@@ -46,8 +47,14 @@ func updateVersionStatus() {
 		return
 	}
 
-	// Update CrossOver status for current version
-	if currentVer.CrossOverPath == "" {
+	// Check every patch component once and show why something is not patched
+	patchStatus := patching.CheckPatchStatus(currentVer.GamePath, currentGameOptions())
+	patchStatus.LogStatus()
+	updatePatchStatusReason(patchStatus)
+
+	// Update Wine runtime status for current version
+	runtime := currentWineRuntime()
+	if runtime.Path() == "" {
 		crossoverPathLabel.Segments = []widget.RichTextSegment{&widget.TextSegment{Text: "Not set", Style: widget.RichTextStyle{ColorName: theme.ColorNameError}}}
 		crossoverStatusLabel.Segments = []widget.RichTextSegment{&widget.TextSegment{Text: "Not Applied", Style: widget.RichTextStyle{ColorName: theme.ColorNameError}}}
 		// Disable both CrossOver buttons when path not set
@@ -58,9 +65,19 @@ func updateVersionStatus() {
 			unpatchCrossOverButton.Disable()
 		}
 	} else {
-		crossoverPathLabel.Segments = []widget.RichTextSegment{&widget.TextSegment{Text: currentVer.CrossOverPath, Style: widget.RichTextStyle{ColorName: theme.ColorNameSuccess}}}
-		wineloader2Path := filepath.Join(currentVer.CrossOverPath, "Contents", "SharedSupport", "CrossOver", "CrossOver-Hosted Application", "wineloader2")
-		if utils.PathExists(wineloader2Path) {
+		crossoverPathLabel.Segments = []widget.RichTextSegment{&widget.TextSegment{Text: runtimePathText(runtime), Style: widget.RichTextStyle{ColorName: theme.ColorNameSuccess}}}
+		if patchStatus.CrossOverPatched() && len(runtime.PatchSteps()) == 0 {
+			// Runtimes other than CrossOver are launched as they are
+			crossoverStatusLabel.Segments = []widget.RichTextSegment{&widget.TextSegment{Text: "Not Needed", Style: widget.RichTextStyle{ColorName: theme.ColorNameSuccess}}}
+			gamePatched, _ := paths.GetVersionPatchingStatus(currentVer.ID)
+			paths.SetVersionPatchingStatus(currentVer.ID, gamePatched, true)
+			if patchCrossOverButton != nil {
+				patchCrossOverButton.Disable()
+			}
+			if unpatchCrossOverButton != nil {
+				unpatchCrossOverButton.Disable()
+			}
+		} else if patchStatus.CrossOverPatched() {
 			crossoverStatusLabel.Segments = []widget.RichTextSegment{&widget.TextSegment{Text: "Applied", Style: widget.RichTextStyle{ColorName: theme.ColorNameSuccess}}}
 			gamePatched, _ := paths.GetVersionPatchingStatus(currentVer.ID)
 			paths.SetVersionPatchingStatus(currentVer.ID, gamePatched, true)
@@ -101,9 +118,7 @@ func updateVersionStatus() {
 	} else {
 		turtlewowPathLabel.Segments = []widget.RichTextSegment{&widget.TextSegment{Text: currentVer.GamePath, Style: widget.RichTextStyle{ColorName: theme.ColorNameSuccess}}}
 
-		// Check if patches are Applied using version-aware checking
-		patchesApplied := patching.CheckVersionPatchingStatus(currentVer.GamePath, currentVer.UsesRosettaPatching, currentVer.UsesDivxDecoderPatch, currentVer.ID)
-		if patchesApplied {
+		if patchStatus.GamePatched() {
 			turtlewowStatusLabel.Segments = []widget.RichTextSegment{&widget.TextSegment{Text: "Applied", Style: widget.RichTextStyle{ColorName: theme.ColorNameSuccess}}}
 			// Update button states - patches applied
 			if patchTurtleWoWButton != nil {
@@ -113,7 +128,11 @@ func updateVersionStatus() {
 				unpatchTurtleWoWButton.Enable()
 			}
 		} else {
-			turtlewowStatusLabel.Segments = []widget.RichTextSegment{&widget.TextSegment{Text: "Not Applied", Style: widget.RichTextStyle{ColorName: theme.ColorNameError}}}
+			statusText := "Not Applied"
+			if patchStatus.GamePartiallyPatched() {
+				statusText = "Incomplete"
+			}
+			turtlewowStatusLabel.Segments = []widget.RichTextSegment{&widget.TextSegment{Text: statusText, Style: widget.RichTextStyle{ColorName: theme.ColorNameError}}}
 			// Update button states - patches not applied
 			if patchTurtleWoWButton != nil {
 				patchTurtleWoWButton.Enable()
@@ -127,6 +146,28 @@ func updateVersionStatus() {
 	turtlewowStatusLabel.Refresh()
 }
 
+// updatePatchStatusReason shows why the game or CrossOver is not patched below the patch buttons
+func updatePatchStatusReason(patchStatus *patching.PatchStatus) {
+	if patchStatusReasonLabel == nil {
+		return
+	}
+
+	var reasons []string
+	if reason := patchStatus.GameReason(); reason != "" && patchStatus.GamePath != "" {
+		reasons = append(reasons, "Game: "+reason)
+	}
+	if reason := patchStatus.CrossOverReason(); reason != "" {
+		reasons = append(reasons, currentWineRuntime().Name()+": "+reason)
+	}
+
+	if len(reasons) == 0 {
+		patchStatusReasonLabel.Hide()
+		return
+	}
+	patchStatusReasonLabel.SetText(strings.Join(reasons, "\n"))
+	patchStatusReasonLabel.Show()
+}
+
 // updateCrossoverStatus updates CrossOver path and patch status
 func updateCrossoverStatus() {
 	if paths.CrossoverPath == "" {
@@ -134,8 +175,7 @@ func updateCrossoverStatus() {
 		paths.PatchesAppliedCrossOver = false // Reset if path is cleared
 	} else {
 		crossoverPathLabel.Segments = []widget.RichTextSegment{&widget.TextSegment{Text: paths.CrossoverPath, Style: widget.RichTextStyle{ColorName: theme.ColorNameSuccess}}}
-		wineloader2Path := filepath.Join(paths.CrossoverPath, "Contents", "SharedSupport", "CrossOver", "CrossOver-Hosted Application", "wineloader2")
-		if utils.PathExists(wineloader2Path) {
+		if utils.PathExists(wine.NewCrossOver(paths.CrossoverPath).LoaderPath()) {
 			paths.PatchesAppliedCrossOver = true
 		}
 	}
@@ -210,17 +250,13 @@ func updatePlayButtonState() {
 	// Use version-aware checking
 	currentVer := GetCurrentVersion()
 	if currentVer != nil {
-		// Check if both game and CrossOver paths are set
+		// Check if both the game and the Wine runtime are set up
 		gamePatchesApplied := currentVer.GamePath != "" && patching.CheckVersionPatchingStatus(currentVer.GamePath, currentVer.UsesRosettaPatching, currentVer.UsesDivxDecoderPatch, currentVer.ID)
 
-		// Check CrossOver status
-		crossoverPatchesApplied := false
-		if currentVer.CrossOverPath != "" {
-			wineloader2Path := filepath.Join(currentVer.CrossOverPath, "Contents", "SharedSupport", "CrossOver", "CrossOver-Hosted Application", "wineloader2")
-			crossoverPatchesApplied = utils.PathExists(wineloader2Path)
-		}
+		// Check Wine runtime status, for CrossOver whether wineloader2 exists
+		runtimeReady := wine.Ready(currentWineRuntime()) == nil
 
-		launchEnabled = gamePatchesApplied && crossoverPatchesApplied
+		launchEnabled = gamePatchesApplied && runtimeReady
 	} else {
 		// Fallback to legacy system
 		launchEnabled = paths.PatchesAppliedTurtleWoW && paths.PatchesAppliedCrossOver &&
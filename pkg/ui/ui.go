@@ -19,6 +19,9 @@ func CreateUI(myWindow fyne.Window) fyne.CanvasObject {
 	turtlewowStatusLabel = widget.NewRichText()
 	crossoverStatusLabel = widget.NewRichText()
 	serviceStatusLabel = widget.NewRichText()
+	patchStatusReasonLabel = widget.NewLabel("")
+	patchStatusReasonLabel.Wrapping = fyne.TextWrapWord
+	patchStatusReasonLabel.Hide()
 
 	// Initialize version system
 	if err := InitializeVersionSystem(); err != nil {
@@ -36,6 +39,8 @@ func CreateUI(myWindow fyne.Window) fyne.CanvasObject {
 	// Check for new TurtleWoW users after UI is ready
 	defer func() {
 		CheckForFirstTimeUser(myWindow)
+		// Offer to re-create wineloader2 if CrossOver was updated since it was patched
+		CheckForCrossOverUpdate(myWindow)
 		// Show new user popup for TurtleWoW if needed
 		CheckAndShowNewUserPopup()
 	}()
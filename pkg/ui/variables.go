@@ -17,6 +17,9 @@ var (
 	crossoverStatusLabel *widget.RichText
 	serviceStatusLabel   *widget.RichText
 
+	// Explains why the game or CrossOver is not patched
+	patchStatusReasonLabel *widget.Label
+
 	// Version management
 	VersionDropdown    *widget.Select
 	VersionTitleButton *widget.Button
@@ -40,7 +43,11 @@ var (
 	metalHudCheckbox      *widget.Check
 	showTerminalCheckbox  *widget.Check
 	vanillaTweaksCheckbox *widget.Check
+	vanillaTweaksButton   *widget.Button
 	autoDeleteWdbCheckbox *widget.Check
+	autoRepatchCheckbox   *widget.Check
+	largeAddressCheckbox  *widget.Check
+	autoCrossOverCheckbox *widget.Check
 
 	// Recommended settings button
 	applyRecommendedSettingsButton *widget.Button
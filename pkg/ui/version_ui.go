@@ -9,6 +9,7 @@ import (
 	"turtlesilicon/pkg/paths"
 	"turtlesilicon/pkg/utils"
 	"turtlesilicon/pkg/version"
+	"turtlesilicon/pkg/wine"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
@@ -352,11 +353,12 @@ func updateVersionPathLabels() {
 		return
 	}
 
-	// Update CrossOver path label
-	if currentVersion.CrossOverPath == "" {
+	// Update Wine runtime path label
+	runtime := currentWineRuntime()
+	if runtime.Path() == "" {
 		crossoverPathLabel.Segments = []widget.RichTextSegment{&widget.TextSegment{Text: "Not set", Style: widget.RichTextStyle{ColorName: theme.ColorNameError}}}
 	} else {
-		crossoverPathLabel.Segments = []widget.RichTextSegment{&widget.TextSegment{Text: currentVersion.CrossOverPath, Style: widget.RichTextStyle{ColorName: theme.ColorNameSuccess}}}
+		crossoverPathLabel.Segments = []widget.RichTextSegment{&widget.TextSegment{Text: runtimePathText(runtime), Style: widget.RichTextStyle{ColorName: theme.ColorNameSuccess}}}
 	}
 	crossoverPathLabel.Refresh()
 
@@ -393,9 +395,30 @@ func updateVersionSettings() {
 		}
 		vanillaTweaksCheckbox.Refresh()
 	}
+	if vanillaTweaksButton != nil {
+		if !currentVersion.SupportsVanillaTweaks {
+			vanillaTweaksButton.Disable()
+		} else {
+			vanillaTweaksButton.Enable()
+		}
+	}
 	if autoDeleteWdbCheckbox != nil {
 		autoDeleteWdbCheckbox.SetChecked(settings.AutoDeleteWdb)
 	}
+	if autoRepatchCheckbox != nil {
+		autoRepatchCheckbox.SetChecked(settings.AutoRepatchAfterUpdate)
+	}
+	if autoCrossOverCheckbox != nil {
+		autoCrossOverCheckbox.SetChecked(settings.AutoRepatchCrossOver)
+	}
+	if largeAddressCheckbox != nil {
+		largeAddressCheckbox.SetChecked(settings.LargeAddressAware)
+		if patching.SupportsLargeAddressAware(patching.GameOptionsForVersion(currentVersion)) {
+			largeAddressCheckbox.Enable()
+		} else {
+			largeAddressCheckbox.Disable()
+		}
+	}
 
 	// Update graphics settings checkboxes
 	if reduceTerrainDistanceCheckbox != nil {
@@ -476,6 +499,7 @@ func RefreshUIForCurrentVersion() {
 	// Update launcher variables to match current version settings
 	launcher.EnableMetalHud = currentVersion.Settings.EnableMetalHud
 	launcher.EnableVanillaTweaks = currentVersion.Settings.EnableVanillaTweaks
+	launcher.VanillaTweakSelection = currentVersion.Settings.VanillaTweaks
 	launcher.AutoDeleteWdb = currentVersion.Settings.AutoDeleteWdb
 	launcher.CustomEnvVars = currentVersion.Settings.EnvironmentVariables
 
@@ -489,6 +513,20 @@ func RefreshUIForCurrentVersion() {
 	if autoDeleteWdbCheckbox != nil {
 		autoDeleteWdbCheckbox.SetChecked(currentVersion.Settings.AutoDeleteWdb)
 	}
+	if autoRepatchCheckbox != nil {
+		autoRepatchCheckbox.SetChecked(currentVersion.Settings.AutoRepatchAfterUpdate)
+	}
+	if autoCrossOverCheckbox != nil {
+		autoCrossOverCheckbox.SetChecked(currentVersion.Settings.AutoRepatchCrossOver)
+	}
+	if largeAddressCheckbox != nil {
+		largeAddressCheckbox.SetChecked(currentVersion.Settings.LargeAddressAware)
+		if patching.SupportsLargeAddressAware(patching.GameOptionsForVersion(currentVersion)) {
+			largeAddressCheckbox.Enable()
+		} else {
+			largeAddressCheckbox.Disable()
+		}
+	}
 	if showTerminalCheckbox != nil {
 		showTerminalCheckbox.SetChecked(currentVersion.Settings.ShowTerminalNormally)
 	}
@@ -581,7 +619,28 @@ func setGamePathForCurrentVersion(myWindow fyne.Window, selectedPath string) {
 
 }
 
-// Version-aware CrossOver path selection
+// currentWineRuntime returns the Wine runtime of the current version, or CrossOver at the legacy
+// path if no version is selected
+func currentWineRuntime() wine.Runtime {
+	if currentVer := GetCurrentVersion(); currentVer != nil {
+		if runtime, err := wine.ForVersion(currentVer); err == nil {
+			return runtime
+		}
+	}
+	return wine.NewCrossOver(paths.CrossoverPath)
+}
+
+// runtimePathText returns how the location of a runtime is shown, naming runtimes other than
+// CrossOver
+func runtimePathText(runtime wine.Runtime) string {
+	if runtime.Kind() == wine.KindCrossOver {
+		return runtime.Path()
+	}
+	return runtime.Name() + ": " + runtime.Path()
+}
+
+// Version-aware Wine runtime selection. CrossOver.app, a Wine build or a Game Porting Toolkit
+// build can be chosen.
 func SelectCurrentVersionCrossOverPath(myWindow fyne.Window) {
 	if currentVersion == nil {
 		dialog.ShowError(fmt.Errorf("no current version selected"), myWindow)
@@ -604,7 +663,25 @@ func SelectCurrentVersionCrossOverPath(myWindow fyne.Window) {
 		}
 		selectedPath := uri.Path()
 
+		// Wine builds and Game Porting Toolkit builds are used instead of CrossOver; anything
+		// else is kept as the CrossOver path, the status explains what is missing
+		if runtime, err := wine.Detect(selectedPath); err == nil && runtime.Kind() != wine.KindCrossOver {
+			currentVersion.WineRuntime = runtime.Kind()
+			currentVersion.WineRuntimePath = selectedPath
+			if err := currentVersionManager.UpdateVersion(currentVersion); err != nil {
+				dialog.ShowError(fmt.Errorf("failed to save Wine runtime: %v", err), myWindow)
+				return
+			}
+			paths.SetVersionPatchingStatus(currentVersion.ID, false, false)
+			debug.Printf("%s set for version %s: %s", runtime.Name(), currentVersion.ID, selectedPath)
+			updateVersionPathLabels()
+			UpdateAllStatuses()
+			return
+		}
+
 		currentVersion.CrossOverPath = selectedPath
+		currentVersion.WineRuntime = ""
+		currentVersion.WineRuntimePath = ""
 		if err := currentVersionManager.UpdateVersion(currentVersion); err != nil {
 			dialog.ShowError(fmt.Errorf("failed to save CrossOver path: %v", err), myWindow)
 			return
@@ -658,12 +735,25 @@ func proceedWithPatching(myWindow fyne.Window) {
 	debug.Printf("Current Version Uses DivX: %v", currentVersion.UsesDivxDecoderPatch)
 	debug.Printf("=== UI PATCHING DEBUG END ===")
 
-	patching.PatchVersionGame(myWindow, UpdateAllStatuses, currentVersion.GamePath, currentVersion.UsesRosettaPatching, currentVersion.UsesDivxDecoderPatch, currentVersion.ExecutableName, currentVersion.ID)
+	opts := currentGameOptions()
+	plan, err := patching.PlanVersionPatch(currentVersion.GamePath, opts)
+	if err != nil {
+		dialog.ShowError(err, myWindow)
+		debug.Println(err.Error())
+		UpdateAllStatuses()
+		return
+	}
 
-	// Update patching status
-	gamePatched := patching.CheckVersionPatchingStatus(currentVersion.GamePath, currentVersion.UsesRosettaPatching, currentVersion.UsesDivxDecoderPatch, currentVersion.ID)
-	crossoverPatched, _ := paths.GetVersionPatchingStatus(currentVersion.ID)
-	paths.SetVersionPatchingStatus(currentVersion.ID, gamePatched, crossoverPatched)
+	// Remember the libSiliconPatch and shadowLOD defaults once they are installed
+	patchedVersion := currentVersion
+	plan.OnApplied = func() {
+		patching.RememberPatchDefaults(patchedVersion, opts)
+		if err := currentVersionManager.UpdateVersion(patchedVersion); err != nil {
+			debug.Printf("Warning: failed to save version settings: %v", err)
+		}
+	}
+
+	showPatchPlanConfirmation(myWindow, plan)
 }
 
 // Version-aware unpatching
@@ -673,9 +763,62 @@ func UnpatchCurrentVersion(myWindow fyne.Window) {
 		return
 	}
 
-	patching.UnpatchVersionGame(myWindow, UpdateAllStatuses, currentVersion.GamePath, currentVersion.UsesRosettaPatching, currentVersion.UsesDivxDecoderPatch, currentVersion.ID)
+	plan, err := patching.PlanVersionUnpatch(currentVersion.GamePath, currentGameOptions())
+	if err != nil {
+		dialog.ShowError(err, myWindow)
+		return
+	}
+
+	showPatchPlanConfirmation(myWindow, plan)
+}
 
-	// Update patching status
+// currentGameOptions returns the patch options for the current version
+func currentGameOptions() patching.GameOptions {
+	opts := patching.GameOptionsForVersion(currentVersion)
+	if opts.CrossOverPath == "" {
+		opts.CrossOverPath = paths.CrossoverPath
+	}
+	return opts
+}
+
+// showPatchPlanConfirmation lists every change a patch plan would make and applies it once confirmed
+func showPatchPlanConfirmation(myWindow fyne.Window, plan *patching.PatchPlan) {
+	if plan.IsEmpty() {
+		// Nothing to confirm, let the plan report that there is nothing to do
+		applyPatchPlan(myWindow, UpdateAllStatuses, plan)
+		return
+	}
+
+	intro := widget.NewLabel(fmt.Sprintf("The following changes will be made in %s:", plan.Root))
+	intro.Wrapping = fyne.TextWrapWord
+	intro.TextStyle = fyne.TextStyle{Italic: true}
+
+	actionsLabel := widget.NewLabel(plan.Summary())
+	actionsLabel.Wrapping = fyne.TextWrapWord
+
+	content := container.NewBorder(intro, nil, nil, nil, container.NewVScroll(actionsLabel))
+
+	confirmDialog := dialog.NewCustomConfirm(plan.Title, "Apply", "Cancel", content, func(confirm bool) {
+		if !confirm {
+			debug.Printf("Plan %q cancelled by user", plan.Title)
+			return
+		}
+		applyPatchPlan(myWindow, func() {
+			UpdateAllStatuses()
+			updatePatchingStatusForCurrentVersion()
+		}, plan)
+	}, myWindow)
+
+	windowSize := myWindow.Canvas().Size()
+	confirmDialog.Resize(fyne.NewSize(windowSize.Width*0.85, windowSize.Height*0.7))
+	confirmDialog.Show()
+}
+
+// updatePatchingStatusForCurrentVersion stores the game patch state of the current version
+func updatePatchingStatusForCurrentVersion() {
+	if currentVersion == nil {
+		return
+	}
 	gamePatched := patching.CheckVersionPatchingStatus(currentVersion.GamePath, currentVersion.UsesRosettaPatching, currentVersion.UsesDivxDecoderPatch, currentVersion.ID)
 	crossoverPatched, _ := paths.GetVersionPatchingStatus(currentVersion.ID)
 	paths.SetVersionPatchingStatus(currentVersion.ID, gamePatched, crossoverPatched)
@@ -721,7 +864,7 @@ func launchGame(myWindow fyne.Window) {
 		myWindow,
 		currentVersion.ID,
 		currentVersion.GamePath,
-		currentVersion.CrossOverPath,
+		currentWineRuntime(),
 		currentVersion.ExecutableName,
 		currentVersion.Settings.EnableMetalHud,
 		currentVersion.Settings.EnvironmentVariables,
@@ -733,3 +876,48 @@ func launchGame(myWindow fyne.Window) {
 func CheckForFirstTimeUser(myWindow fyne.Window) {
 	// No longer showing first-time user dialogs - users can use Set/Change Game Path directly
 }
+
+// CheckForCrossOverUpdate re-creates wineloader2 when CrossOver changed since it was patched. It
+// asks first unless the user opted in to re-patching CrossOver automatically.
+func CheckForCrossOverUpdate(myWindow fyne.Window) {
+	currentVer := GetCurrentVersion()
+	if currentVer == nil || currentVer.CrossOverPath == "" || currentWineRuntime().Kind() != wine.KindCrossOver {
+		return
+	}
+	change := patching.CheckCrossOverChange(currentVer.CrossOverPath)
+	if change == nil {
+		return
+	}
+	debug.Printf("CrossOver update detected: %s", change.Describe())
+
+	plan, err := patching.PlanCrossOverPatch(currentVer.CrossOverPath)
+	if err != nil {
+		debug.Printf("Cannot re-create wineloader2: %v", err)
+		return
+	}
+
+	if currentVer.Settings.AutoRepatchCrossOver {
+		go func() {
+			if _, err := patching.NewEngine(nil).Apply(plan); err != nil {
+				debug.Printf("Automatic CrossOver re-patch failed: %v", err)
+			} else {
+				debug.Println("wineloader2 re-created after CrossOver update")
+			}
+			fyne.Do(UpdateAllStatuses)
+		}()
+		return
+	}
+
+	title := "CrossOver Updated"
+	if change.Unrecorded {
+		title = "Re-create wineloader2"
+	}
+	message := fmt.Sprintf("%s.\n\nTurtleSilicon launches the game with wineloader2, a copy of CrossOver's wineloader. Re-create it now?", change.Describe())
+	dialog.ShowConfirm(title, message, func(confirm bool) {
+		if !confirm {
+			debug.Println("Re-creating wineloader2 declined by user")
+			return
+		}
+		applyPatchPlan(myWindow, UpdateAllStatuses, plan)
+	}, myWindow)
+}
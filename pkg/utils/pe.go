@@ -0,0 +1,83 @@
+package utils
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// ImageFileLargeAddressAware is the COFF characteristics bit that lets a 32-bit executable use
+// more than 2 GB of address space
+const ImageFileLargeAddressAware = 0x0020
+
+// ErrNotPE is returned for files that do not have a valid PE header
+var ErrNotPE = errors.New("not a PE executable")
+
+// peHeaderOffsets returns the offsets of the COFF characteristics and the optional header
+// checksum in a PE image
+func peHeaderOffsets(content []byte) (characteristics int, checksum int, err error) {
+	if len(content) < 0x40 || content[0] != 'M' || content[1] != 'Z' {
+		return 0, 0, ErrNotPE
+	}
+	peOffset := int(binary.LittleEndian.Uint32(content[0x3C:]))
+	// PE signature (4) + COFF header (20) + optional header up to and including CheckSum (68)
+	if peOffset <= 0 || peOffset+4+20+68 > len(content) || string(content[peOffset:peOffset+4]) != "PE\x00\x00" {
+		return 0, 0, ErrNotPE
+	}
+	coffOffset := peOffset + 4
+	return coffOffset + 18, coffOffset + 20 + 64, nil
+}
+
+// PEChecksum computes the checksum the Windows loader expects in the optional header. The four
+// bytes of the checksum field at checksumOffset count as zero.
+func PEChecksum(content []byte, checksumOffset int) uint32 {
+	var sum uint64
+	for i := 0; i < len(content); i += 2 {
+		word := uint64(checksumByte(content, i, checksumOffset)) | uint64(checksumByte(content, i+1, checksumOffset))<<8
+		sum += word
+		sum = (sum & 0xFFFF) + (sum >> 16)
+	}
+	sum = (sum & 0xFFFF) + (sum >> 16)
+	return uint32(sum) + uint32(len(content))
+}
+
+// checksumByte returns the byte at i for the checksum, zero past the end and inside the field
+func checksumByte(content []byte, i int, checksumOffset int) byte {
+	if i >= len(content) || (i >= checksumOffset && i < checksumOffset+4) {
+		return 0
+	}
+	return content[i]
+}
+
+// IsLargeAddressAware reports whether an executable has the Large Address Aware flag set
+func IsLargeAddressAware(path string) (bool, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+	characteristics, _, err := peHeaderOffsets(content)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", path, err)
+	}
+	return binary.LittleEndian.Uint16(content[characteristics:])&ImageFileLargeAddressAware != 0, nil
+}
+
+// SetLargeAddressAware sets or clears the Large Address Aware flag of a PE image in place and
+// updates its checksum
+func SetLargeAddressAware(content []byte, enable bool) error {
+	characteristics, checksum, err := peHeaderOffsets(content)
+	if err != nil {
+		return err
+	}
+
+	flags := binary.LittleEndian.Uint16(content[characteristics:])
+	if enable {
+		flags |= ImageFileLargeAddressAware
+	} else {
+		flags &^= ImageFileLargeAddressAware
+	}
+	binary.LittleEndian.PutUint16(content[characteristics:], flags)
+	binary.LittleEndian.PutUint32(content[checksum:], PEChecksum(content, checksum))
+	return nil
+}
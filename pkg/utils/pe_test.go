@@ -0,0 +1,72 @@
+package utils
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testPE returns a small image with a PE header at an odd offset and the checksum field at 153
+func testPE() []byte {
+	content := make([]byte, 301)
+	for i := range content {
+		content[i] = byte(i*7 + 3)
+	}
+	content[0], content[1] = 'M', 'Z'
+	binary.LittleEndian.PutUint32(content[0x3C:], 65)
+	copy(content[65:], "PE\x00\x00")
+	return content
+}
+
+func TestPEChecksum(t *testing.T) {
+	tests := []struct {
+		name    string
+		content []byte
+		offset  int
+		want    uint32
+	}{
+		// Reference values from the algorithm of CheckSumMappedFile
+		{"checksum field at an odd offset", testPE(), 153, 0x68D2},
+		{"odd length", []byte{0x01, 0x02, 0x03}, 100, 0x0204 + 3},
+		{"field skipped", []byte{0x10, 0x00, 0xFF, 0xFF, 0xFF, 0xFF, 0x20, 0x00}, 2, 0x0030 + 8},
+	}
+
+	for _, tt := range tests {
+		if got := PEChecksum(tt.content, tt.offset); got != tt.want {
+			t.Errorf("%s: PEChecksum() = %#x, want %#x", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSetLargeAddressAware(t *testing.T) {
+	tests := []struct {
+		enable       bool
+		wantChecksum uint32
+	}{
+		{false, 0x48D2},
+		{true, 0x68D2},
+	}
+
+	for _, tt := range tests {
+		content := testPE()
+		if err := SetLargeAddressAware(content, tt.enable); err != nil {
+			t.Fatalf("SetLargeAddressAware(%v) failed: %v", tt.enable, err)
+		}
+		if got := binary.LittleEndian.Uint32(content[153:]); got != tt.wantChecksum {
+			t.Errorf("SetLargeAddressAware(%v) checksum = %#x, want %#x", tt.enable, got, tt.wantChecksum)
+		}
+
+		path := filepath.Join(t.TempDir(), "Wow.exe")
+		if err := os.WriteFile(path, content, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if enabled, err := IsLargeAddressAware(path); err != nil || enabled != tt.enable {
+			t.Errorf("IsLargeAddressAware() = %v, %v, want %v", enabled, err, tt.enable)
+		}
+	}
+
+	if err := SetLargeAddressAware([]byte("not an executable"), true); err != ErrNotPE {
+		t.Errorf("SetLargeAddressAware() on a non-PE file = %v, want %v", err, ErrNotPE)
+	}
+}
@@ -18,6 +18,9 @@ type UserPrefs struct {
 	AutoDeleteWdb           bool   `json:"auto_delete_wdb"`
 	EnableMetalHud          bool   `json:"enable_metal_hud"`
 
+	// Index the mod manager's catalog is loaded from, empty for the default catalog
+	ModCatalogURL string `json:"mod_catalog_url,omitempty"`
+
 	// Graphics settings
 	ReduceTerrainDistance bool `json:"reduce_terrain_distance"`
 	SetMultisampleTo2x    bool `json:"set_multisample_to_2x"`
@@ -1,10 +1,9 @@
 package utils
 
 import (
-	"crypto/md5"
+	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -13,10 +12,8 @@ import (
 	"path/filepath"
 	"strings"
 
+	"turtlesilicon/pkg/assets"
 	"turtlesilicon/pkg/debug"
-
-	"fyne.io/fyne/v2"
-	"fyne.io/fyne/v2/dialog"
 )
 
 // min returns the smaller of two integers
@@ -105,18 +102,17 @@ func CopyDir(src string, dst string) error {
 }
 
 // RunOsascript runs an AppleScript command using osascript.
-func RunOsascript(scriptString string, myWindow fyne.Window) bool {
+func RunOsascript(scriptString string) error {
 	debug.Printf("Executing AppleScript: %s", scriptString)
 	cmd := exec.Command("osascript", "-e", scriptString)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
-		errMsg := fmt.Sprintf("AppleScript failed: %v\nOutput: %s", err, string(output))
-		dialog.ShowError(errors.New(errMsg), myWindow)
-		debug.Println(errMsg)
-		return false
+		err = fmt.Errorf("AppleScript failed: %v\nOutput: %s", err, string(output))
+		debug.Println(err.Error())
+		return err
 	}
 	debug.Printf("osascript output: %s", string(output))
-	return true
+	return nil
 }
 
 // EscapeStringForAppleScript escapes a string for AppleScript.
@@ -523,14 +519,7 @@ func TestDMGMount(dmgPath string) (string, string, error) {
 	return mountPoint, newAppPath, nil
 }
 
-// calculateResourceHash calculates the MD5 hash of a bundled resource
-func calculateResourceHash(resource *fyne.StaticResource) string {
-	hash := md5.New()
-	hash.Write(resource.Content())
-	return hex.EncodeToString(hash.Sum(nil))
-}
-
-// calculateFileHash calculates the MD5 hash of a local file
+// calculateFileHash calculates the SHA-256 hash of a local file
 func calculateFileHash(filePath string) (string, error) {
 	file, err := os.Open(filePath)
 	if err != nil {
@@ -538,7 +527,7 @@ func calculateFileHash(filePath string) (string, error) {
 	}
 	defer file.Close()
 
-	hash := md5.New()
+	hash := sha256.New()
 	if _, err := io.Copy(hash, file); err != nil {
 		return "", err
 	}
@@ -546,64 +535,45 @@ func calculateFileHash(filePath string) (string, error) {
 	return hex.EncodeToString(hash.Sum(nil)), nil
 }
 
-// CompareFileWithBundledResource compares both size and hash of a file on disk with a bundled resource
+// CompareFileWithBundledResource compares both size and hash of a file on disk with the manifest
+// entry of a bundled asset
 func CompareFileWithBundledResource(filePath, resourceName string) bool {
-	// Check if the file exists first
-	if !PathExists(filePath) {
+	asset, ok := assets.Lookup(resourceName)
+	if !ok {
+		debug.Printf("Unknown bundled asset %s", resourceName)
 		return false
 	}
+	return FileMatchesDigest(filePath, asset.Size, asset.SHA256)
+}
 
-	// Get file info for the existing file
+// FileMatchesDigest returns true if the file on disk has exactly the given size and SHA-256 hash
+func FileMatchesDigest(filePath string, size int64, sha256Hash string) bool {
 	fileInfo, err := os.Stat(filePath)
 	if err != nil {
-		debug.Printf("Failed to stat file %s: %v", filePath, err)
-		return false
-	}
-
-	// Load the bundled resource
-	resource, err := fyne.LoadResourceFromPath(resourceName)
-	if err != nil {
-		debug.Printf("Failed to load bundled resource %s: %v", resourceName, err)
+		if !os.IsNotExist(err) {
+			debug.Printf("Failed to stat file %s: %v", filePath, err)
+		}
 		return false
 	}
 
 	// Compare sizes first (quick check)
-	fileSize := fileInfo.Size()
-	resourceSize := int64(len(resource.Content()))
-
-	debug.Printf("Comparing file sizes - %s: %d bytes vs bundled %s: %d bytes",
-		filePath, fileSize, resourceName, resourceSize)
-
-	if fileSize != resourceSize {
-		debug.Printf("Size mismatch for %s: file=%d, resource=%d", filePath, fileSize, resourceSize)
+	if fileInfo.Size() != size {
+		debug.Printf("Size mismatch for %s: file=%d, expected=%d", filePath, fileInfo.Size(), size)
 		return false
 	}
 
-	// Calculate and compare hashes for integrity verification
 	fileHash, err := calculateFileHash(filePath)
 	if err != nil {
 		debug.Printf("Failed to calculate hash for %s: %v", filePath, err)
 		return false
 	}
 
-	// Type assert to StaticResource for hash calculation
-	staticResource, ok := resource.(*fyne.StaticResource)
-	if !ok {
-		debug.Printf("Failed to convert resource to StaticResource for %s", resourceName)
-		return false
-	}
-
-	resourceHash := calculateResourceHash(staticResource)
-
-	debug.Printf("Comparing file hashes - %s: %s vs bundled %s: %s",
-		filePath, fileHash, resourceName, resourceHash)
-
-	if fileHash != resourceHash {
-		debug.Printf("Hash mismatch for %s: file=%s, resource=%s", filePath, fileHash, resourceHash)
+	if fileHash != sha256Hash {
+		debug.Printf("Hash mismatch for %s: file=%s, expected=%s", filePath, fileHash, sha256Hash)
 		return false
 	}
 
-	debug.Printf("File verification successful for %s: size=%d, hash=%s", filePath, fileSize, fileHash)
+	debug.Printf("File verification successful for %s: size=%d, sha256=%s", filePath, size, fileHash)
 	return true
 }
 
@@ -13,22 +13,17 @@ const (
 	wineRegistrySection = "[Software\\\\Wine\\\\Mac Driver]"
 	leftOptionKey       = "\"LeftOptionIsAlt\"=\"Y\""
 	rightOptionKey      = "\"RightOptionIsAlt\"=\"Y\""
-	wineLoaderPath      = "/Applications/CrossOver.app/Contents/SharedSupport/CrossOver/CrossOver-Hosted Application/wineloader2"
 	registryKeyPath     = "HKEY_CURRENT_USER\\Software\\Wine\\Mac Driver"
 )
 
-// GetWineUserRegPath returns the path to the Wine user.reg file
-func GetWineUserRegPath() (string, error) {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		return "", fmt.Errorf("failed to get user home directory: %v", err)
-	}
-	return filepath.Join(homeDir, ".wine", "user.reg"), nil
+// GetWineUserRegPath returns the path to the user.reg file of a Wine prefix
+func GetWineUserRegPath(winePrefix string) string {
+	return filepath.Join(winePrefix, "user.reg")
 }
 
 // queryRegistryValue queries a specific registry value using Wine's reg command
-func queryRegistryValue(winePrefix, valueName string) bool {
-	cmd := exec.Command(wineLoaderPath, "reg", "query", registryKeyPath, "/v", valueName)
+func queryRegistryValue(loaderPath, winePrefix, valueName string) bool {
+	cmd := exec.Command(loaderPath, "reg", "query", registryKeyPath, "/v", valueName)
 	cmd.Env = append(os.Environ(), fmt.Sprintf("WINEPREFIX=%s", winePrefix))
 
 	output, err := cmd.Output()
@@ -42,15 +37,15 @@ func queryRegistryValue(winePrefix, valueName string) bool {
 	return strings.Contains(outputStr, valueName) && strings.Contains(outputStr, "Y")
 }
 
-func setRegistryValuesOptimized(winePrefix string, enabled bool) error {
+func setRegistryValuesOptimized(loaderPath, winePrefix string, enabled bool) error {
 	if enabled {
-		return addBothRegistryValues(winePrefix)
+		return addBothRegistryValues(loaderPath, winePrefix)
 	} else {
-		return deleteBothRegistryValues(winePrefix)
+		return deleteBothRegistryValues(loaderPath, winePrefix)
 	}
 }
 
-func addBothRegistryValues(winePrefix string) error {
+func addBothRegistryValues(loaderPath, winePrefix string) error {
 	batchContent := fmt.Sprintf(`@echo off
 reg add "%s" /v "LeftOptionIsAlt" /t REG_SZ /d "Y" /f
 reg add "%s" /v "RightOptionIsAlt" /t REG_SZ /d "Y" /f
@@ -66,7 +61,7 @@ reg add "%s" /v "RightOptionIsAlt" /t REG_SZ /d "Y" /f
 	defer os.Remove(batchFile)
 
 	// Run the batch file with Wine
-	cmd := exec.Command(wineLoaderPath, "cmd", "/c", batchFile)
+	cmd := exec.Command(loaderPath, "cmd", "/c", batchFile)
 	cmd.Env = append(os.Environ(), fmt.Sprintf("WINEPREFIX=%s", winePrefix))
 
 	output, err := cmd.CombinedOutput()
@@ -78,7 +73,7 @@ reg add "%s" /v "RightOptionIsAlt" /t REG_SZ /d "Y" /f
 	return nil
 }
 
-func deleteBothRegistryValues(winePrefix string) error {
+func deleteBothRegistryValues(loaderPath, winePrefix string) error {
 	batchContent := fmt.Sprintf(`@echo off
 reg delete "%s" /v "LeftOptionIsAlt" /f 2>nul
 reg delete "%s" /v "RightOptionIsAlt" /f 2>nul
@@ -94,7 +89,7 @@ reg delete "%s" /v "RightOptionIsAlt" /f 2>nul
 	defer os.Remove(batchFile) // Clean up
 
 	// Run the batch file with Wine
-	cmd := exec.Command(wineLoaderPath, "cmd", "/c", batchFile)
+	cmd := exec.Command(loaderPath, "cmd", "/c", batchFile)
 	cmd.Env = append(os.Environ(), fmt.Sprintf("WINEPREFIX=%s", winePrefix))
 
 	output, err := cmd.CombinedOutput()
@@ -106,36 +101,25 @@ reg delete "%s" /v "RightOptionIsAlt" /f 2>nul
 	return nil
 }
 
-// CheckOptionAsAltEnabled checks if Option keys are remapped as Alt keys in Wine registry
-func CheckOptionAsAltEnabled() bool {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		log.Printf("Failed to get user home directory: %v", err)
-		return false
-	}
-
-	winePrefix := filepath.Join(homeDir, ".wine")
-
-	// Check if CrossOver wine loader exists
-	if !PathExists(wineLoaderPath) {
-		log.Printf("CrossOver wine loader not found at: %s", wineLoaderPath)
+// CheckOptionAsAltEnabled checks if Option keys are remapped as Alt keys in the registry of a
+// Wine prefix, using loaderPath to query it
+func CheckOptionAsAltEnabled(loaderPath, winePrefix string) bool {
+	// Check if the wine loader exists
+	if !PathExists(loaderPath) {
+		log.Printf("Wine loader not found at: %s", loaderPath)
 		return false
 	}
 
 	// Query both registry values
-	leftEnabled := queryRegistryValue(winePrefix, "LeftOptionIsAlt")
-	rightEnabled := queryRegistryValue(winePrefix, "RightOptionIsAlt")
+	leftEnabled := queryRegistryValue(loaderPath, winePrefix, "LeftOptionIsAlt")
+	rightEnabled := queryRegistryValue(loaderPath, winePrefix, "RightOptionIsAlt")
 
 	return leftEnabled && rightEnabled
 }
 
 // CheckOptionAsAltEnabledFast checks status by reading user.reg file directly
-func CheckOptionAsAltEnabledFast() bool {
-	regPath, err := GetWineUserRegPath()
-	if err != nil {
-		log.Printf("Failed to get Wine registry path: %v", err)
-		return false
-	}
+func CheckOptionAsAltEnabledFast(winePrefix string) bool {
+	regPath := GetWineUserRegPath(winePrefix)
 
 	if !PathExists(regPath) {
 		log.Printf("Wine user.reg file not found at: %s", regPath)
@@ -167,34 +151,28 @@ func CheckOptionAsAltEnabledFast() bool {
 	return false
 }
 
-// SetOptionAsAltEnabled enables or disables Option key remapping in Wine registry
-func SetOptionAsAltEnabled(enabled bool) error {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		return fmt.Errorf("failed to get user home directory: %v", err)
-	}
-
-	winePrefix := filepath.Join(homeDir, ".wine")
-
-	// Check if CrossOver wine loader exists
-	if !PathExists(wineLoaderPath) {
-		return fmt.Errorf("CrossOver wine loader not found at: %s", wineLoaderPath)
+// SetOptionAsAltEnabled enables or disables Option key remapping in the registry of a Wine
+// prefix, using loaderPath to change it
+func SetOptionAsAltEnabled(loaderPath, winePrefix string, enabled bool) error {
+	// Check if the wine loader exists
+	if !PathExists(loaderPath) {
+		return fmt.Errorf("wine loader not found at: %s", loaderPath)
 	}
 
-	// Ensure the .wine directory exists
+	// Ensure the prefix directory exists
 	if err := os.MkdirAll(winePrefix, 0755); err != nil {
-		return fmt.Errorf("failed to create .wine directory: %v", err)
+		return fmt.Errorf("failed to create %s: %v", winePrefix, err)
 	}
 
 	if enabled {
-		return setRegistryValuesOptimized(winePrefix, true)
+		return setRegistryValuesOptimized(loaderPath, winePrefix, true)
 	} else {
-		err := setRegistryValuesOptimized(winePrefix, false)
+		err := setRegistryValuesOptimized(loaderPath, winePrefix, false)
 		if err != nil {
 			log.Printf("Wine registry disable failed: %v", err)
 		}
 
-		err2 := setRegistryValuesFast(false)
+		err2 := setRegistryValuesFast(winePrefix, false)
 		if err2 != nil {
 			log.Printf("File-based cleanup failed: %v", err2)
 		}
@@ -207,11 +185,8 @@ func SetOptionAsAltEnabled(enabled bool) error {
 }
 
 // setRegistryValuesFast directly modifies the user.reg file (much faster)
-func setRegistryValuesFast(enabled bool) error {
-	regPath, err := GetWineUserRegPath()
-	if err != nil {
-		return fmt.Errorf("failed to get Wine registry path: %v", err)
-	}
+func setRegistryValuesFast(winePrefix string, enabled bool) error {
+	regPath := GetWineUserRegPath(winePrefix)
 
 	// Ensure the .wine directory exists
 	wineDir := filepath.Dir(regPath)
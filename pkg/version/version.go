@@ -13,6 +13,8 @@ type GameVersion struct {
 	WoWVersion            string          `json:"wow_version"`
 	GamePath              string          `json:"game_path"`
 	CrossOverPath         string          `json:"crossover_path"`
+	WineRuntime           string          `json:"wine_runtime,omitempty"`      // Kind of Wine runtime, CrossOver when empty
+	WineRuntimePath       string          `json:"wine_runtime_path,omitempty"` // Location of runtimes other than CrossOver
 	ExecutableName        string          `json:"-"`
 	SupportsVanillaTweaks bool            `json:"supports_vanilla_tweaks"`
 	SupportsDLLLoading    bool            `json:"supports_dll_loading"`
@@ -30,6 +32,29 @@ type VersionSettings struct {
 	ShowTerminalNormally bool   `json:"show_terminal_normally"`
 	EnvironmentVariables string `json:"environment_variables"`
 
+	// Enabled vanilla tweaks and their values, see launcher.VanillaTweaks. Nil uses the defaults.
+	VanillaTweaks map[string]float64 `json:"vanilla_tweaks"`
+
+	// Set the Large Address Aware flag of the patched executable so it can use up to 4 GB
+	LargeAddressAware bool `json:"large_address_aware"`
+
+	// Reapply patch components the game client removed when it updated itself
+	AutoRepatchAfterUpdate bool `json:"auto_repatch_after_update"`
+
+	// Re-create wineloader2 without asking when CrossOver was updated since it was created
+	AutoRepatchCrossOver bool `json:"auto_repatch_crossover"`
+
+	// Compatibility component builds to patch with, keyed by component name. Values are
+	// "bundled", "latest" or a pinned version. Missing components use the bundled build.
+	ComponentVersions map[string]string `json:"component_versions,omitempty"`
+
+	// Named mod profiles. Each lists the mods/ entries of dlls.txt in load order, disabled mods
+	// with a # prefix.
+	ModProfiles map[string][]string `json:"mod_profiles,omitempty"`
+
+	// Mod profile applied to dlls.txt right before the game is launched, empty to keep dlls.txt
+	LaunchModProfile string `json:"launch_mod_profile,omitempty"`
+
 	// Graphics settings
 	ReduceTerrainDistance bool `json:"reduce_terrain_distance"`
 	SetMultisampleTo2x    bool `json:"set_multisample_to_2x"`
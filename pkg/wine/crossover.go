@@ -0,0 +1,82 @@
+package wine
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"turtlesilicon/pkg/utils"
+
+	"howett.net/plist"
+)
+
+// CrossOver is a CrossOver.app bundle. The game is launched with wineloader2, an unsigned copy
+// of CrossOver's wineloader that rosettax87 is allowed to inject into.
+type CrossOver struct {
+	path string
+}
+
+// NewCrossOver returns the CrossOver runtime of the CrossOver.app at path
+func NewCrossOver(path string) *CrossOver {
+	return &CrossOver{path: path}
+}
+
+// CrossOverLoaderDir returns the directory wineloader and wineloader2 are in
+func CrossOverLoaderDir(crossoverPath string) string {
+	return filepath.Join(crossoverPath, "Contents", "SharedSupport", "CrossOver", "CrossOver-Hosted Application")
+}
+
+func (c *CrossOver) Kind() string { return KindCrossOver }
+func (c *CrossOver) Name() string { return KindName(KindCrossOver) }
+func (c *CrossOver) Path() string { return c.path }
+
+func (c *CrossOver) Detect() error {
+	if c.path == "" {
+		return ErrPathNotSet
+	}
+	if !utils.PathExists(c.ToolLoaderPath()) {
+		return fmt.Errorf("%w: no CrossOver wineloader in %s", ErrNotFound, c.path)
+	}
+	return nil
+}
+
+// Version reads CFBundleShortVersionString from the Info.plist of the bundle
+func (c *CrossOver) Version() string {
+	if c.path == "" {
+		return ""
+	}
+	f, err := os.Open(filepath.Join(c.path, "Contents", "Info.plist"))
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	var data struct {
+		Version string `plist:"CFBundleShortVersionString"`
+	}
+	if err := plist.NewDecoder(f).Decode(&data); err != nil {
+		return ""
+	}
+	return data.Version
+}
+
+func (c *CrossOver) LoaderPath() string {
+	return filepath.Join(CrossOverLoaderDir(c.path), "wineloader2")
+}
+
+// ToolLoaderPath is CrossOver's own wineloader, which runs without any bottle
+func (c *CrossOver) ToolLoaderPath() string {
+	return filepath.Join(CrossOverLoaderDir(c.path), "wineloader")
+}
+
+func (c *CrossOver) Prefix() string { return DefaultPrefix() }
+
+// Env is empty, the loaders fall back to the default prefix on their own
+func (c *CrossOver) Env() []string { return nil }
+
+// PatchSteps copies wineloader to wineloader2 and removes the code signature of the copy
+func (c *CrossOver) PatchSteps() []PatchStep {
+	return []PatchStep{
+		{Source: c.ToolLoaderPath(), Path: c.LoaderPath()},
+		{Path: CrossOverLoaderDir(c.path), Command: []string{"codesign", "--remove-signature", c.LoaderPath()}},
+	}
+}
@@ -0,0 +1,74 @@
+package wine
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+
+	"turtlesilicon/pkg/utils"
+)
+
+// GPTK is a Wine build laid out like Apple's Game Porting Toolkit: bin/wine64 next to
+// lib/external/D3DMetal.framework, either directly at the path or in Contents/Resources/wine of
+// an app bundle. Its loader is launched as is, no patching is needed.
+type GPTK struct {
+	path string
+
+	versionOnce sync.Once
+	version     string
+}
+
+// NewGPTK returns the runtime of the Game Porting Toolkit build at path
+func NewGPTK(path string) *GPTK {
+	return &GPTK{path: path}
+}
+
+// root returns the directory that holds bin and lib, or "" if there is none
+func (g *GPTK) root() string {
+	for _, root := range wineRoots(g.path) {
+		if utils.PathExists(filepath.Join(root, "bin", "wine64")) && utils.DirExists(filepath.Join(root, "lib", "external", "D3DMetal.framework")) {
+			return root
+		}
+	}
+	return ""
+}
+
+func (g *GPTK) Kind() string { return KindGPTK }
+func (g *GPTK) Name() string { return KindName(KindGPTK) }
+func (g *GPTK) Path() string { return g.path }
+
+func (g *GPTK) Detect() error {
+	if g.path == "" {
+		return ErrPathNotSet
+	}
+	if g.root() == "" {
+		return fmt.Errorf("%w: no bin/wine64 with D3DMetal.framework in %s", ErrNotFound, g.path)
+	}
+	return nil
+}
+
+// Version runs the loader once with --version
+func (g *GPTK) Version() string {
+	g.versionOnce.Do(func() {
+		if loader := g.LoaderPath(); utils.PathExists(loader) {
+			g.version = loaderVersion(loader)
+		}
+	})
+	return g.version
+}
+
+func (g *GPTK) LoaderPath() string {
+	root := g.root()
+	if root == "" {
+		root = g.path
+	}
+	return filepath.Join(root, "bin", "wine64")
+}
+
+func (g *GPTK) ToolLoaderPath() string { return g.LoaderPath() }
+func (g *GPTK) Prefix() string         { return DefaultPrefix() }
+
+// Env enables esync, which the toolkit's Wine is built for
+func (g *GPTK) Env() []string { return []string{"WINEESYNC=1"} }
+
+func (g *GPTK) PatchSteps() []PatchStep { return nil }
@@ -0,0 +1,170 @@
+// Package wine describes the Wine builds the game can be launched with. CrossOver, a plain Wine
+// build directory and a Game Porting Toolkit style layout are supported; each game version picks
+// one of them.
+package wine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"turtlesilicon/pkg/utils"
+	"turtlesilicon/pkg/version"
+)
+
+// Kinds of runtimes, stored in version.GameVersion.WineRuntime
+const (
+	KindCrossOver = "crossover"
+	KindWine      = "wine"
+	KindGPTK      = "gptk"
+)
+
+// Kinds lists every kind of runtime in the order they are offered and detected
+var Kinds = []string{KindCrossOver, KindGPTK, KindWine}
+
+// Errors returned when a runtime cannot be used
+var (
+	ErrPathNotSet     = errors.New("Wine runtime path not set")
+	ErrNotFound       = errors.New("Wine runtime not found")
+	ErrUnknownRuntime = errors.New("unknown Wine runtime")
+)
+
+// PatchStep is one change a runtime needs before the game can be launched with it. Steps with a
+// Source copy Source to Path, the others run Command.
+type PatchStep struct {
+	Source  string
+	Path    string
+	Command []string
+}
+
+// Runtime is a Wine build TurtleSilicon launches the game and its helpers with
+type Runtime interface {
+	Kind() string
+	Name() string // Display name of the kind, e.g. "CrossOver"
+	Path() string // Location the user chose
+	// Detect returns an error wrapping ErrNotFound if Path does not hold this kind of runtime
+	Detect() error
+	// Version returns the version of the runtime, or "" if it cannot be read
+	Version() string
+	// LoaderPath is the loader the game is launched with. It may only exist after PatchSteps ran.
+	LoaderPath() string
+	// ToolLoaderPath is the loader for helpers like rundll32 and reg, usable without patching
+	ToolLoaderPath() string
+	// Prefix is the WINEPREFIX the game runs in
+	Prefix() string
+	// Env returns the environment variables the loaders need, in KEY=value form
+	Env() []string
+	// PatchSteps returns the changes needed before LoaderPath can be used, nil if there are none
+	PatchSteps() []PatchStep
+}
+
+// KindName returns the display name of a kind of runtime
+func KindName(kind string) string {
+	switch kind {
+	case KindCrossOver:
+		return "CrossOver"
+	case KindWine:
+		return "Wine"
+	case KindGPTK:
+		return "Game Porting Toolkit"
+	}
+	return kind
+}
+
+// DefaultPrefix returns the WINEPREFIX Wine uses when none is set
+func DefaultPrefix() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return ".wine"
+	}
+	return filepath.Join(homeDir, ".wine")
+}
+
+// New returns the runtime of the given kind at path. An empty kind means CrossOver.
+func New(kind string, path string) (Runtime, error) {
+	switch kind {
+	case KindCrossOver, "":
+		return NewCrossOver(path), nil
+	case KindWine:
+		return NewWine(path), nil
+	case KindGPTK:
+		return NewGPTK(path), nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownRuntime, kind)
+}
+
+// ForVersion returns the runtime a game version is configured to use. CrossOver uses the
+// version's CrossOver path, the other runtimes use its Wine runtime path.
+func ForVersion(ver *version.GameVersion) (Runtime, error) {
+	if ver.WineRuntime == "" || ver.WineRuntime == KindCrossOver {
+		return New(KindCrossOver, ver.CrossOverPath)
+	}
+	return New(ver.WineRuntime, ver.WineRuntimePath)
+}
+
+// Detect works out which kind of runtime is at path
+func Detect(path string) (Runtime, error) {
+	if path == "" {
+		return nil, ErrPathNotSet
+	}
+	for _, kind := range Kinds {
+		runtime, _ := New(kind, path)
+		if runtime.Detect() == nil {
+			return runtime, nil
+		}
+	}
+	return nil, fmt.Errorf("%w at %s", ErrNotFound, path)
+}
+
+// Ready returns nil if the game can be launched with the runtime as it is, i.e. it was found and
+// every file its patch steps create is in place
+func Ready(runtime Runtime) error {
+	if err := runtime.Detect(); err != nil {
+		return err
+	}
+	for _, step := range runtime.PatchSteps() {
+		if step.Source == "" {
+			continue
+		}
+		if !utils.PathExists(step.Path) {
+			return fmt.Errorf("%s is missing, patch %s", filepath.Base(step.Path), runtime.Name())
+		}
+	}
+	return nil
+}
+
+// EnvString returns the environment variables of the runtime for a shell command line
+func EnvString(runtime Runtime) string {
+	var vars []string
+	for _, v := range runtime.Env() {
+		key, value, _ := strings.Cut(v, "=")
+		vars = append(vars, key+"="+utils.QuotePathForShell(value))
+	}
+	return strings.Join(vars, " ")
+}
+
+// loaderVersion runs "<loader> --version" and returns its output without the "wine-" prefix
+func loaderVersion(loader string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, loader, "--version").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(strings.TrimSpace(string(output)), "wine-")
+}
+
+// firstExisting returns the first of paths that exists, or "" if none does
+func firstExisting(paths ...string) string {
+	for _, path := range paths {
+		if utils.PathExists(path) {
+			return path
+		}
+	}
+	return ""
+}
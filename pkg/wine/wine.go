@@ -0,0 +1,76 @@
+package wine
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+
+	"turtlesilicon/pkg/utils"
+)
+
+// Wine is a plain Wine build: a directory with bin/wine, or a Wine.app bundle that keeps one in
+// Contents/Resources/wine. Its loader is launched as is, no patching is needed.
+type Wine struct {
+	path string
+
+	versionOnce sync.Once
+	version     string
+}
+
+// NewWine returns the runtime of the Wine build at path
+func NewWine(path string) *Wine {
+	return &Wine{path: path}
+}
+
+// wineRoots returns the directories a Wine build can be installed in below path
+func wineRoots(path string) []string {
+	return []string{path, filepath.Join(path, "Contents", "Resources", "wine")}
+}
+
+// findLoader returns the first of the loader names found in the bin directory of a root
+func findLoader(path string, names ...string) string {
+	var candidates []string
+	for _, root := range wineRoots(path) {
+		for _, name := range names {
+			candidates = append(candidates, filepath.Join(root, "bin", name))
+		}
+	}
+	return firstExisting(candidates...)
+}
+
+func (w *Wine) Kind() string { return KindWine }
+func (w *Wine) Name() string { return KindName(KindWine) }
+func (w *Wine) Path() string { return w.path }
+
+func (w *Wine) Detect() error {
+	if w.path == "" {
+		return ErrPathNotSet
+	}
+	if findLoader(w.path, "wine", "wine64") == "" {
+		return fmt.Errorf("%w: no bin/wine in %s", ErrNotFound, w.path)
+	}
+	return nil
+}
+
+// Version runs the loader once with --version
+func (w *Wine) Version() string {
+	w.versionOnce.Do(func() {
+		if loader := w.LoaderPath(); utils.PathExists(loader) {
+			w.version = loaderVersion(loader)
+		}
+	})
+	return w.version
+}
+
+// LoaderPath prefers bin/wine, which is the only loader of newer WoW64 builds
+func (w *Wine) LoaderPath() string {
+	if loader := findLoader(w.path, "wine", "wine64"); loader != "" {
+		return loader
+	}
+	return filepath.Join(w.path, "bin", "wine")
+}
+
+func (w *Wine) ToolLoaderPath() string  { return w.LoaderPath() }
+func (w *Wine) Prefix() string          { return DefaultPrefix() }
+func (w *Wine) Env() []string           { return nil }
+func (w *Wine) PatchSteps() []PatchStep { return nil }